@@ -7,18 +7,23 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
 	"hospital_management_system/config"
+	"hospital_management_system/internal/delivery/http/handlers"
 	"hospital_management_system/internal/delivery/http/routes"
-	"hospital_management_system/internal/infra/cloudinary"
 	"hospital_management_system/internal/infra/db/postgres_db"
+	"hospital_management_system/internal/infra/mail"
 	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/infra/queue"
 	"hospital_management_system/internal/infra/rabbitmq"
 	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/storage"
+	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
@@ -29,46 +34,79 @@ func RunServer() {
 	postgres_db.ConnectDB()
 	postgres_db.Migration(postgres_db.DB)
 
-	// Initialize Cloudinary
-	cld, err := cloudinary.NewCloudinary()
+	// Load JWT signing keys and keep rotating them in the background
+	signingKeyUsecase, err := usecase.SigningKeyNewUsecase(repository.SigningKeyNewRepository(postgres_db.DB))
 	if err != nil {
-		log.Fatal("Failed to initialize Cloudinary:", err)
+		log.Fatal("Invalid JWT configuration:", err)
 	}
-
-	// Initialize CloudinaryUploader
-	cloudinaryUploader := helpers.New(cld)
+	if err := signingKeyUsecase.Init(); err != nil {
+		log.Fatal("Failed to initialize JWT signing keys:", err)
+	}
+	// Every background loop stops when ctx is cancelled on shutdown
+	ctx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go signingKeyUsecase.Run(ctx)
+
+	// Initialize object storage; local files are served from /api/v1/files
+	urlSigner := storage.NewURLSigner(config.ENV.JWTSecret, config.ENV.BaseURL+"/files")
+	store, err := storage.NewFromConfig(urlSigner)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+	// Stored links to local uploads expire, so they are re-signed when read
+	models.ResignURL = func(link string) string { return storage.Resign(store, link) }
 
 	// Initialize email repository
 	emailRepo := repository.EmailNewRepository(postgres_db.DB)
 
-	// Initialize RabbitMQ publisher
-	emailPublisher, err := rabbitmq.NewPublisher(config.ENV.RabbitMqUrl, "email_queue")
+	// One queue for every publisher and consumer; RabbitMQ reconnects by
+	// itself when the broker goes away
+	mq, err := openQueue()
+	if err != nil {
+		log.Fatal("Failed to open queue:", err)
+	}
+	defer mq.Close()
+
+	// Start the email consumer, unless the worker command sends emails
+	emailRetryPolicy, err := queue.ParseRetryPolicy(config.ENV.EmailMaxAttempts, config.ENV.EmailRetryDelay)
+	if err != nil {
+		log.Fatal("Invalid email retry configuration:", err)
+	}
+	if config.ENV.EmailWorkerEmbedded || config.ENV.QueueDriver == queue.DriverMemory {
+		transport, err := mail.NewFromConfig()
+		if err != nil {
+			log.Fatal("Failed to initialize mail transport:", err)
+		}
+		go queue.StartConsumer(
+			ctx,
+			mq,
+			queue.EmailQueue,
+			transport,
+			emailRepo,
+			emailRetryPolicy,
+		)
+	}
+
+	// Start the image worker: strips EXIF and builds thumbnails
+	imageProcessor := usecase.ImageProcessorNewUsecase(repository.ImageNewRepository(postgres_db.DB), store)
+	go queue.StartImageConsumer(ctx, mq, queue.ImageQueue, imageProcessor.Process)
+
+	// Client IPs come from forwarding headers only behind these proxies
+	trustedProxies, err := utils.ParseTrustedProxies(config.ENV.TrustedProxies)
 	if err != nil {
-		log.Fatalf("Failed to create email publisher: %v", err)
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
 	}
-	defer emailPublisher.Close()
-
-	// Start RabbitMQ email consumer
-	emailPort, _ := strconv.Atoi(config.ENV.EmailPort)
-	go rabbitmq.StartConsumer(
-		config.ENV.RabbitMqUrl,
-		"email_queue",
-		config.ENV.EmailHost,
-		emailPort,
-		config.ENV.Email,
-		config.ENV.EmailAppPassword,
-		emailRepo,
-	)
 
 	// Setup Chi router
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(middlewares.LoggingMiddleware)
+	r.Use(middlewares.RequestMeta(trustedProxies))
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", handlers.ChunkChecksumHeader},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -87,10 +125,13 @@ func RunServer() {
 		helpers.Success(w, http.StatusOK, "Welcome To Hospital Management Server", nil)
 	})
 
+	// Public keys for verifying our tokens
+	routes.RegisterWellKnownRoutes(r, handlers.JWKSNewHandler(signingKeyUsecase))
+
 	// Mount API v1 routes
 	const apiV1Prefix = "/api/v1"
 	r.Route(apiV1Prefix, func(api chi.Router) {
-		routes.SetupRoutes(api, postgres_db.DB, cloudinaryUploader)
+		routes.SetupRoutes(ctx, api, postgres_db.DB, store, urlSigner, mq)
 	})
 
 	// Create HTTP server
@@ -112,9 +153,25 @@ func RunServer() {
 	<-stop
 
 	fmt.Println("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	stopBackground()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 }
+
+// openQueue connects to the driver selected by QUEUE_DRIVER
+func openQueue() (queue.Queue, error) {
+	switch config.ENV.QueueDriver {
+	case queue.DriverRabbitMQ:
+		if config.ENV.RabbitMqUrl == "" {
+			return nil, fmt.Errorf("RABBITMQ_URL is required with QUEUE_DRIVER=%s", queue.DriverRabbitMQ)
+		}
+		return rabbitmq.Dial(config.ENV.RabbitMqUrl), nil
+	case queue.DriverMemory:
+		return queue.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_DRIVER %q", config.ENV.QueueDriver)
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"hospital_management_system/config"
+	"hospital_management_system/internal/infra/db/postgres_db"
+	"hospital_management_system/internal/infra/mail"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/pkg/helpers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RunWorker sends queued emails outside the API server, so sending can be
+// scaled and restarted on its own. On SIGTERM it stops taking new emails
+// and waits up to WORKER_DRAIN_TIMEOUT for the ones in flight.
+func RunWorker() {
+	// The API server owns migrations
+	postgres_db.ConnectDB()
+	emailRepo := repository.EmailNewRepository(postgres_db.DB)
+
+	policy, err := queue.ParseRetryPolicy(config.ENV.EmailMaxAttempts, config.ENV.EmailRetryDelay)
+	if err != nil {
+		log.Fatal("Invalid email retry configuration:", err)
+	}
+	concurrency, err := strconv.Atoi(config.ENV.EmailWorkerConcurrency)
+	if err != nil || concurrency < 1 {
+		log.Fatalf("EMAIL_WORKER_CONCURRENCY must be a positive number, got %q", config.ENV.EmailWorkerConcurrency)
+	}
+	prefetch, err := strconv.Atoi(config.ENV.EmailWorkerPrefetch)
+	if err != nil || prefetch < 1 {
+		log.Fatalf("EMAIL_WORKER_PREFETCH must be a positive number, got %q", config.ENV.EmailWorkerPrefetch)
+	}
+	domainLimit, domainPeriod, err := queue.ParseDomainRate(config.ENV.EmailDomainRate)
+	if err != nil {
+		log.Fatal("Invalid EMAIL_DOMAIN_RATE:", err)
+	}
+	drainTimeout, err := time.ParseDuration(config.ENV.WorkerDrainTimeout)
+	if err != nil {
+		log.Fatalf("WORKER_DRAIN_TIMEOUT must be a duration, got %q", config.ENV.WorkerDrainTimeout)
+	}
+
+	// The worker runs in its own process, so an in-memory queue would
+	// never see the API's messages
+	if config.ENV.QueueDriver == queue.DriverMemory {
+		log.Fatal("The worker needs a broker; QUEUE_DRIVER=memory sends emails from the API server")
+	}
+	mq, err := openQueue()
+	if err != nil {
+		log.Fatal("Failed to open queue:", err)
+	}
+	defer mq.Close()
+
+	transport, err := mail.NewFromConfig()
+	if err != nil {
+		log.Fatal("Failed to initialize mail transport:", err)
+	}
+	// Sends are not tied to the worker's context, so draining lets the
+	// emails in flight finish
+	send := func(job helpers.EmailJob) error {
+		return transport.Send(context.Background(), job)
+	}
+	worker := queue.NewEmailWorker(mq, queue.EmailWorkerConfig{
+		Queue:        queue.EmailQueue,
+		Concurrency:  concurrency,
+		Prefetch:     prefetch,
+		Policy:       policy,
+		DomainLimit:  domainLimit,
+		DomainPeriod: domainPeriod,
+	}, send, emailRepo)
+
+	// Health and metrics
+	var draining atomic.Bool
+	r := chi.NewRouter()
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case draining.Load():
+			helpers.Error(w, helpers.NewAppError(http.StatusServiceUnavailable, "Worker is shutting down"))
+		case !mq.Connected():
+			helpers.Error(w, helpers.NewAppError(http.StatusServiceUnavailable, "Queue is unreachable"))
+		default:
+			helpers.Success(w, http.StatusOK, "Worker is healthy", nil)
+		}
+	})
+	r.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		worker.WriteMetrics(w)
+	})
+	server := &http.Server{
+		Addr:    ":" + config.ENV.WorkerPort,
+		Handler: r,
+	}
+	go func() {
+		fmt.Printf("Worker health and metrics at port %s\n", config.ENV.WorkerPort)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to run worker HTTP server: %v", err)
+		}
+	}()
+
+	ctx, stopConsuming := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		log.Printf("Email worker running with %d consumers...", concurrency)
+		worker.Run(ctx)
+		close(done)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	fmt.Println("Draining email worker...")
+	draining.Store(true)
+	stopConsuming()
+	select {
+	case <-done:
+	case <-time.After(drainTimeout):
+		log.Println("Drain timed out; unacknowledged emails will be redelivered")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Worker HTTP server forced to shutdown: %v", err)
+	}
+}
@@ -10,6 +10,10 @@ import (
 type Config struct {
 	Port             string
 	JWTSecret        string
+	JWTSigningAlg    string // HS256, RS256 or EdDSA
+	JWTKeyRotation   string // how long a key signs before the next one takes over
+	JWTKeyGrace      string // how long a retired key still verifies; must exceed token lifetime
+	JWTAcceptHS256   bool   // accept legacy shared-secret tokens while migrating; turn off once they have expired
 	DBUserName       string
 	DBPassword       string
 	DBName           string
@@ -20,15 +24,47 @@ type Config struct {
 	EmailPort        string
 	EmailAppPassword string
 	Email            string
+	MailDriver       string // smtp, sendgrid, or file to write .eml files for development
+	MailFileDir      string
+	QueueDriver      string // rabbitmq, or memory to run without a broker
 	RabbitMqUrl      string
 	SendGridApiKey   string
 	CloudinaryCloudName string
 	CloudinaryApiKey string
 	CloudinaryApiSecret string
+	StorageDriver    string // cloudinary, local or s3
+	StorageLocalDir  string
+	StorageURLTTL    string // lifetime of links to local uploads; stored links are re-signed when read
+	S3Endpoint       string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3Bucket         string
+	S3Region         string
+	S3UseSSL         bool
+	StorageGCInterval string // how often to look for orphaned files; 0 disables
+	StorageGCMinAge   string // files younger than this are never treated as orphans
+	StorageGCDelete   bool   // scheduled runs delete orphans instead of only reporting them
+	SMSDriver        string // fake; real providers plug in behind sms.Sender
+	ReminderOffsets  string // comma separated, how long before a booking to remind
+	ReminderPollInterval string
+	EmailMaxAttempts string // sends per email before it is dead-lettered
+	EmailRetryDelay  string // wait after the first failure; doubles each retry
+	EmailWorkerEmbedded bool // the API server also sends emails; turn off when running the worker command
+	EmailWorkerConcurrency string // consumers in the worker command
+	EmailWorkerPrefetch string // unacknowledged emails per consumer
+	EmailDomainRate  string // sends per recipient domain, e.g. 100/1m; 0 is unlimited
+	WorkerPort       string // health and metrics endpoints of the worker command
+	WorkerDrainTimeout string // how long the worker waits for in-flight emails on shutdown
+	EmailOpenTracking bool   // add an open-tracking pixel to marketing mail
+	EmailWebhookSecret string // token bounce and provider webhooks must present; empty disables them
+	OutboxPollInterval string // how often the relay looks for unpublished messages
+	EventMaxAttempts string // handler runs per domain event before it is dead-lettered
+	EventRetryDelay  string
 	SSLStoreID       string
 	SSLStorePassword string
 	SSlSandbox      string
 	BaseURL          string
+	TrustedProxies   string // comma separated CIDRs whose X-Forwarded-For is believed
 }
 
 var ENV *Config
@@ -49,31 +85,84 @@ func getEnv(key string) string {
 	return value
 }
 
+// getEnvDefault is for optional settings
+func getEnvDefault(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// defaultStorageDriver keeps existing Cloudinary setups working and falls back
+// to the local disk when no Cloudinary credentials are configured
+func defaultStorageDriver() string {
+	if os.Getenv("CLOUDINARY_CLOUD_NAME") != "" {
+		return "cloudinary"
+	}
+	return "local"
+}
+
 func Init() {
 	loadEnv()
 
 	ENV = &Config{
 		Port:             getEnv("PORT"),
 		JWTSecret:        getEnv("JWT_SECRET"),
+		JWTSigningAlg:    getEnvDefault("JWT_SIGNING_ALG", "RS256"),
+		JWTKeyRotation:   getEnvDefault("JWT_KEY_ROTATION", "720h"),
+		JWTKeyGrace:      getEnvDefault("JWT_KEY_GRACE", "48h"),
+		JWTAcceptHS256:   getEnvDefault("JWT_ACCEPT_HS256", "false") == "true",
 		DBUserName:       getEnv("DB_USER"),
 		DBPassword:       getEnv("DB_PASSWORD"),
 		DBName:           getEnv("DB_NAME"),
 		DBHost:           getEnv("DB_HOST"),
 		DBPort:           getEnv("DB_PORT"),
 		DBSslMode:        getEnv("DB_SSLMODE"),
-		EmailHost:        getEnv("EMAIL_HOST"),
-		EmailPort:        getEnv("EMAIL_PORT"),
-		EmailAppPassword: getEnv("EMAIL_APP_PASSWORD"),
+		EmailHost:        getEnvDefault("EMAIL_HOST", ""),
+		EmailPort:        getEnvDefault("EMAIL_PORT", "587"),
+		EmailAppPassword: getEnvDefault("EMAIL_APP_PASSWORD", ""),
 		Email:            getEnv("EMAIL"),
-		RabbitMqUrl:      getEnv("RABBITMQ_URL"),
-		SendGridApiKey:   getEnv("SENDGRID_API_KEY"),
-		CloudinaryCloudName: getEnv("CLOUDINARY_CLOUD_NAME"),
-		CloudinaryApiKey: getEnv("CLOUDINARY_API_KEY"),
-		CloudinaryApiSecret: getEnv("CLOUDINARY_API_SECRET"),
+		MailDriver:       getEnvDefault("MAIL_DRIVER", "smtp"),
+		MailFileDir:      getEnvDefault("MAIL_FILE_DIR", "./mail"),
+		QueueDriver:      getEnvDefault("QUEUE_DRIVER", "rabbitmq"),
+		RabbitMqUrl:      getEnvDefault("RABBITMQ_URL", ""),
+		SendGridApiKey:   getEnvDefault("SENDGRID_API_KEY", ""),
+		CloudinaryCloudName: getEnvDefault("CLOUDINARY_CLOUD_NAME", ""),
+		CloudinaryApiKey: getEnvDefault("CLOUDINARY_API_KEY", ""),
+		CloudinaryApiSecret: getEnvDefault("CLOUDINARY_API_SECRET", ""),
+		StorageDriver:    getEnvDefault("STORAGE_DRIVER", defaultStorageDriver()),
+		StorageLocalDir:  getEnvDefault("STORAGE_LOCAL_DIR", "./uploads"),
+		StorageURLTTL:    getEnvDefault("STORAGE_URL_TTL", "15m"),
+		S3Endpoint:       getEnvDefault("S3_ENDPOINT", ""),
+		S3AccessKey:      getEnvDefault("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getEnvDefault("S3_SECRET_KEY", ""),
+		S3Bucket:         getEnvDefault("S3_BUCKET", ""),
+		S3Region:         getEnvDefault("S3_REGION", "us-east-1"),
+		S3UseSSL:         getEnvDefault("S3_USE_SSL", "false") == "true",
+		StorageGCInterval: getEnvDefault("STORAGE_GC_INTERVAL", "0"),
+		StorageGCMinAge:   getEnvDefault("STORAGE_GC_MIN_AGE", "24h"),
+		StorageGCDelete:   getEnvDefault("STORAGE_GC_DELETE", "false") == "true",
+		SMSDriver:        getEnvDefault("SMS_DRIVER", "fake"),
+		ReminderOffsets:  getEnvDefault("REMINDER_OFFSETS", "24h,2h"),
+		ReminderPollInterval: getEnvDefault("REMINDER_POLL_INTERVAL", "1m"),
+		EmailMaxAttempts: getEnvDefault("EMAIL_MAX_ATTEMPTS", "5"),
+		EmailRetryDelay:  getEnvDefault("EMAIL_RETRY_DELAY", "30s"),
+		EmailWorkerEmbedded: getEnvDefault("EMAIL_WORKER_EMBEDDED", "true") == "true",
+		EmailWorkerConcurrency: getEnvDefault("EMAIL_WORKER_CONCURRENCY", "4"),
+		EmailWorkerPrefetch: getEnvDefault("EMAIL_WORKER_PREFETCH", "10"),
+		EmailDomainRate:  getEnvDefault("EMAIL_DOMAIN_RATE", "0"),
+		WorkerPort:       getEnvDefault("WORKER_PORT", "9090"),
+		WorkerDrainTimeout: getEnvDefault("WORKER_DRAIN_TIMEOUT", "30s"),
+		EmailOpenTracking: getEnvDefault("EMAIL_OPEN_TRACKING", "false") == "true",
+		EmailWebhookSecret: getEnvDefault("EMAIL_WEBHOOK_SECRET", ""),
+		OutboxPollInterval: getEnvDefault("OUTBOX_POLL_INTERVAL", "1s"),
+		EventMaxAttempts: getEnvDefault("EVENT_MAX_ATTEMPTS", "5"),
+		EventRetryDelay:  getEnvDefault("EVENT_RETRY_DELAY", "10s"),
 		SSLStoreID:       getEnv("SSL_STORE_ID"),
 		SSLStorePassword: getEnv("SSL_STORE_PASSWORD"),
 		SSlSandbox:      getEnv("SSL_SANDBOX"),
 		BaseURL:          getEnv("BASE_URL"),
+		TrustedProxies:   getEnvDefault("TRUSTED_PROXIES", ""),
 
 	}
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditUC usecase.AuditUsecase
+}
+
+func AuditNewHandler(auditUC usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{auditUC: auditUC}
+}
+
+// GET /audit-logs/get-all?actor_id=&action=&resource_type=&resource_id=&from=&to=&page=&page_size=
+// from/to are RFC3339 timestamps
+func (h *AuditHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := &dto.AuditLogFilter{
+		ActorID:      q.Get("actor_id"),
+		Action:       q.Get("action"),
+		ResourceType: q.Get("resource_type"),
+		ResourceID:   q.Get("resource_id"),
+	}
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	if filter.ActorID != "" {
+		if _, err := uuid.Parse(filter.ActorID); err != nil {
+			helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid actor_id"))
+			return
+		}
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid from, expected RFC3339"))
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid to, expected RFC3339"))
+		return
+	}
+
+	logs, err := h.auditUC.Search(filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Audit logs fetched", logs)
+}
+
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
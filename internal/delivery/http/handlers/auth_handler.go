@@ -10,15 +10,18 @@ import (
 
 // Handler handles user-related HTTP requests
 type AuthHandler struct {
-	authUc   usecase.AuthUsecase
+	authUc     usecase.AuthUsecase
+	throttleUc usecase.LoginThrottleUsecase
 }
 
 // NewHandler creates a new User Handler
 func AuthNewHandler(
-	authUc   usecase.AuthUsecase,
+	authUc usecase.AuthUsecase,
+	throttleUc usecase.LoginThrottleUsecase,
 ) *AuthHandler {
 	return &AuthHandler{
-		authUc:   authUc,
+		authUc:     authUc,
+		throttleUc: throttleUc,
 	}
 }
 
@@ -27,13 +30,50 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req dto.LoginRequest
 	utils.BodyDecoder(w, r, &req)
 
-	token, err := h.authUc.Login(&req)
+	res, err := h.authUc.Login(r.Context(), &req)
 	if err != nil {
+		if appErr, ok := err.(*helpers.AppError); ok && appErr.Code == http.StatusTooManyRequests {
+			helpers.Error(w, appErr)
+			return
+		}
 		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, err.Error()))
 		return
 	}
 
-	helpers.Success(w, http.StatusOK, "Login successful", map[string]string{
-		"token": token,
-	})
-}
\ No newline at end of file
+	if res.TwoFactorRequired {
+		helpers.Success(w, http.StatusOK, "Two-factor code required", res)
+		return
+	}
+	if res.TwoFactorSetupRequired {
+		helpers.Success(w, http.StatusOK, "Two-factor enrollment required", res)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Login successful", res)
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req dto.TwoFactorLoginRequest
+	utils.BodyDecoder(w, r, &req)
+
+	res, err := h.authUc.VerifyTwoFactor(r.Context(), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Login successful", res)
+}
+
+// Unlock lifts a temporary lockout on a user account
+func (h *AuthHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	userID := utils.Param(r, "user_id")
+
+	if err := h.throttleUc.Unlock(r.Context(), userID); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Account unlocked successfully", nil)
+}
@@ -21,7 +21,7 @@ func (h *BookingHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateBookingRequest
 	utils.BodyDecoder(w, r, &req)
 
-	booking, err := h.bookingUC.Create(&req)
+	booking, err := h.bookingUC.Create(r.Context(), &req)
 	if err != nil {
 		helpers.Error(w, err)
 		return
@@ -34,7 +34,7 @@ func (h *BookingHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *BookingHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := utils.Param(r, "id")
 
-	booking, err := h.bookingUC.GetByID(id)
+	booking, err := h.bookingUC.GetByID(r.Context(), id)
 	if err != nil {
 		helpers.Error(w, err)
 		return
@@ -45,7 +45,7 @@ func (h *BookingHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 // GET /bookings
 func (h *BookingHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	list, err := h.bookingUC.GetAll()
+	list, err := h.bookingUC.GetAll(r.Context())
 	if err != nil {
 		helpers.Error(w, err)
 		return
@@ -61,7 +61,7 @@ func (h *BookingHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 	var req dto.UpdateBookingStatusRequest
 	utils.BodyDecoder(w, r, &req)
 
-	booking, err := h.bookingUC.UpdateStatus(id, &req)
+	booking, err := h.bookingUC.UpdateStatus(r.Context(), id, &req)
 	if err != nil {
 		helpers.Error(w, err)
 		return
@@ -74,7 +74,7 @@ func (h *BookingHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 func (h *BookingHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := utils.Param(r, "id")
 
-	if err := h.bookingUC.Delete(id); err != nil {
+	if err := h.bookingUC.Delete(r.Context(), id); err != nil {
 		helpers.Error(w, err)
 		return
 	}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type DocumentHandler struct {
+	documentUC usecase.DocumentUsecase
+}
+
+func DocumentNewHandler(documentUC usecase.DocumentUsecase) *DocumentHandler {
+	return &DocumentHandler{documentUC: documentUC}
+}
+
+// maxDocumentForm bounds the multipart body; the validator enforces the file limit
+const maxDocumentForm = 32 << 20
+
+// POST /documents/upload
+// multipart form: "file" plus JSON metadata in "data"
+func (h *DocumentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxDocumentForm); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid form data"))
+		return
+	}
+
+	var req dto.DocumentUploadRequest
+	if err := json.Unmarshal([]byte(r.FormValue("data")), &req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON in data field"))
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "No file provided"))
+		return
+	}
+	defer file.Close()
+
+	doc, err := h.documentUC.Upload(r.Context(), file, fileHeader, &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Document uploaded successfully", doc)
+}
+
+// POST /documents/{id}/versions
+// multipart form: "file" and an optional "note"
+func (h *DocumentHandler) AddVersion(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxDocumentForm); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid form data"))
+		return
+	}
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "No file provided"))
+		return
+	}
+	defer file.Close()
+
+	doc, err := h.documentUC.AddVersion(r.Context(), id, file, fileHeader, r.FormValue("note"))
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Document version added", doc)
+}
+
+// GET /documents/get/{id}
+func (h *DocumentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	doc, err := h.documentUC.GetByID(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Document retrieved", doc)
+}
+
+// GET /documents/patient/{patient_id}?category=&tag=&page=&page_size=
+func (h *DocumentHandler) ListByPatient(w http.ResponseWriter, r *http.Request) {
+	patientID, err := uuid.Parse(utils.Param(r, "patient_id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid patient ID"))
+		return
+	}
+
+	q := r.URL.Query()
+	filter := &dto.DocumentFilter{
+		PatientID: patientID,
+		Category:  q.Get("category"),
+		Tag:       q.Get("tag"),
+	}
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	docs, err := h.documentUC.ListByPatient(r.Context(), filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Documents fetched", docs)
+}
+
+// GET /documents/{id}/download?version=
+func (h *DocumentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid version"))
+			return
+		}
+		version = n
+	}
+
+	link, err := h.documentUC.DownloadURL(r.Context(), id, version)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Download link created", link)
+}
+
+// PUT /documents/{id}/tags
+func (h *DocumentHandler) UpdateTags(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.DocumentTagsRequest
+	utils.BodyDecoder(w, r, &req)
+
+	doc, err := h.documentUC.UpdateTags(r.Context(), id, req.Tags)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Tags updated", doc)
+}
+
+// POST /documents/{id}/access
+func (h *DocumentHandler) GrantAccess(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.DocumentAccessRequest
+	utils.BodyDecoder(w, r, &req)
+
+	if err := h.documentUC.GrantAccess(r.Context(), id, &req); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Access granted", nil)
+}
+
+// DELETE /documents/{id}/access/{user_id}
+func (h *DocumentHandler) RevokeAccess(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(utils.Param(r, "user_id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid user ID"))
+		return
+	}
+
+	if err := h.documentUC.RevokeAccess(r.Context(), id, userID); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Access revoked", nil)
+}
+
+// DELETE /documents/delete/{id}
+func (h *DocumentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.documentUC.Delete(r.Context(), id); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Document deleted", nil)
+}
+
+func documentIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid document ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// maxBounceBody bounds bounce messages, which may carry the original email
+const maxBounceBody = 10 << 20
+
+// trackingPixel is a transparent 1x1 GIF
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// unsubscribePage confirms an unsubscribe before it is applied
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<p>Stop sending marketing emails and appointment reminders to {{.Address}}? Emails about your account, bookings and payments are still sent.</p>
+<form method="post" action="{{.Action}}"><button type="submit">Unsubscribe</button></form>
+</body>
+</html>
+`))
+
+type EmailHandler struct {
+	emailUC    usecase.EmailUsecase
+	trackingUC usecase.EmailTrackingUsecase
+}
+
+func EmailNewHandler(emailUC usecase.EmailUsecase, trackingUC usecase.EmailTrackingUsecase) *EmailHandler {
+	return &EmailHandler{emailUC: emailUC, trackingUC: trackingUC}
+}
+
+// GET /emails/get-all?user_id=&email=&type=&status=&from=&to=&page=&page_size=
+// from/to are RFC3339 timestamps
+func (h *EmailHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := &dto.EmailFilter{
+		UserID: q.Get("user_id"),
+		Email:  q.Get("email"),
+		Type:   q.Get("type"),
+		Status: q.Get("status"),
+	}
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	if filter.UserID != "" {
+		if _, err := uuid.Parse(filter.UserID); err != nil {
+			helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid user_id"))
+			return
+		}
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid from, expected RFC3339"))
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid to, expected RFC3339"))
+		return
+	}
+
+	emails, err := h.emailUC.Search(filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Emails fetched", emails)
+}
+
+// GET /emails/{id}
+func (h *EmailHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid email ID"))
+		return
+	}
+
+	email, err := h.emailUC.Get(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Email fetched", email)
+}
+
+// GET /emails/{id}/body
+// Renders the HTML body as sent. The sandbox keeps its scripts and forms
+// from running with the viewer's session.
+func (h *EmailHandler) Body(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid email ID"))
+		return
+	}
+
+	email, err := h.emailUC.Get(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(email.Body))
+}
+
+// POST /emails/{id}/resend
+func (h *EmailHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid email ID"))
+		return
+	}
+
+	email, err := h.emailUC.Resend(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Email resent", email)
+}
+
+// POST /emails/{id}/requeue
+func (h *EmailHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid email ID"))
+		return
+	}
+
+	email, err := h.emailUC.Requeue(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Email requeued", email)
+}
+
+// POST /emails/requeue-failed
+func (h *EmailHandler) RequeueFailed(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.emailUC.RequeueFailed(r.Context())
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Failed emails requeued", resp)
+}
+
+// GET /emails/unsubscribe?token=
+// Only asks for confirmation: link scanners and prefetchers follow GET links,
+// so the address is suppressed by the form's POST
+func (h *EmailHandler) UnsubscribeForm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	address, err := h.trackingUC.UnsubscribeAddress(token)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	unsubscribePage.Execute(w, map[string]string{
+		"Address": address,
+		"Action":  "?token=" + url.QueryEscape(token),
+	})
+}
+
+// POST /emails/unsubscribe?token=
+// Sent by the confirmation page and by mail clients' one-click unsubscribe
+// (RFC 8058)
+func (h *EmailHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if err := h.trackingUC.Unsubscribe(r.URL.Query().Get("token")); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "You have been unsubscribed", nil)
+}
+
+// GET /emails/{id}/open?sig=
+// Always answers with the pixel, so a bad link shows nothing odd in the mail
+func (h *EmailHandler) Open(w http.ResponseWriter, r *http.Request) {
+	if id, err := uuid.Parse(utils.Param(r, "id")); err == nil {
+		h.trackingUC.RecordOpen(id, r.URL.Query().Get("sig"))
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(trackingPixel)
+}
+
+// POST /emails/webhooks/dsn?token=
+// Body is a raw bounce message (multipart/report), e.g. piped from the
+// bounce mailbox
+func (h *EmailHandler) BounceDSN(w http.ResponseWriter, r *http.Request) {
+	if err := h.trackingUC.VerifyWebhook(r.URL.Query().Get("token")); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	result, err := h.trackingUC.ProcessDSN(http.MaxBytesReader(w, r.Body, maxBounceBody))
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Bounce processed", result)
+}
+
+// POST /emails/webhooks/events?token=
+// Body is a JSON array of provider events
+func (h *EmailHandler) ProviderEvents(w http.ResponseWriter, r *http.Request) {
+	if err := h.trackingUC.VerifyWebhook(r.URL.Query().Get("token")); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	var events []dto.EmailProviderEvent
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBounceBody)).Decode(&events); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid event payload"))
+		return
+	}
+
+	result, err := h.trackingUC.ProcessProviderEvents(events)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Events processed", result)
+}
+
+// GET /emails/suppressions?email=&reason=&page=&page_size=
+func (h *EmailHandler) ListSuppressions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := &dto.EmailSuppressionFilter{
+		Email:  q.Get("email"),
+		Reason: q.Get("reason"),
+	}
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	suppressions, err := h.trackingUC.ListSuppressions(filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Suppressions fetched", suppressions)
+}
+
+// DELETE /emails/suppressions/{id}
+func (h *EmailHandler) DeleteSuppression(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid suppression ID"))
+		return
+	}
+
+	if err := h.trackingUC.DeleteSuppression(r.Context(), id); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Suppression deleted", nil)
+}
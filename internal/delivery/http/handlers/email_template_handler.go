@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+	"strconv"
+)
+
+type EmailTemplateHandler struct {
+	templateUC usecase.EmailTemplateUsecase
+	emailUC    usecase.EmailUsecase
+}
+
+func EmailTemplateNewHandler(templateUC usecase.EmailTemplateUsecase, emailUC usecase.EmailUsecase) *EmailTemplateHandler {
+	return &EmailTemplateHandler{templateUC: templateUC, emailUC: emailUC}
+}
+
+// GET /email-templates/get-all
+func (h *EmailTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	summaries, err := h.templateUC.List(r.Context())
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Email templates fetched", summaries)
+}
+
+// GET /email-templates/{type}/versions
+func (h *EmailTemplateHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.templateUC.Versions(r.Context(), emailTypeParam(r))
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Template versions fetched", versions)
+}
+
+// POST /email-templates/{type}/versions
+func (h *EmailTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req dto.EmailTemplateRequest
+	utils.BodyDecoder(w, r, &req)
+
+	tmpl, err := h.templateUC.Create(r.Context(), emailTypeParam(r), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Template version saved", tmpl)
+}
+
+// POST /email-templates/{type}/versions/{version}/activate
+func (h *EmailTemplateHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(utils.Param(r, "version"))
+	if err != nil || version < 1 {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid version"))
+		return
+	}
+
+	tmpl, err := h.templateUC.Activate(r.Context(), emailTypeParam(r), version)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Template version activated", tmpl)
+}
+
+// DELETE /email-templates/{type}/active
+// reverts the type to the bundled default template
+func (h *EmailTemplateHandler) ResetToDefault(w http.ResponseWriter, r *http.Request) {
+	if err := h.templateUC.ResetToDefault(r.Context(), emailTypeParam(r)); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Template reset to default", nil)
+}
+
+// POST /email-templates/{type}/preview
+func (h *EmailTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req dto.EmailTemplatePreviewRequest
+	utils.BodyDecoder(w, r, &req)
+
+	rendered, err := h.templateUC.Preview(r.Context(), emailTypeParam(r), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Template rendered", rendered)
+}
+
+// POST /email-templates/{type}/test
+func (h *EmailTemplateHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	var req dto.EmailTemplateTestRequest
+	utils.BodyDecoder(w, r, &req)
+
+	rendered, err := h.emailUC.SendTest(r.Context(), emailTypeParam(r), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusAccepted, "Test email queued", rendered)
+}
+
+func emailTypeParam(r *http.Request) models.EmailType {
+	return models.EmailType(utils.Param(r, "type"))
+}
@@ -186,7 +186,7 @@ func (h *ImageHandler) GetImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	image, err := h.imageUc.GetImageByID(id)
+	image, err := h.imageUc.GetImageByID(r.Context(), id)
 	if err != nil {
 		if appErr, ok := err.(*helpers.AppError); ok {
 			helpers.Error(w, appErr)
@@ -199,6 +199,29 @@ func (h *ImageHandler) GetImage(w http.ResponseWriter, r *http.Request) {
 	helpers.Success(w, http.StatusOK, "Image retrieved successfully", image)
 }
 
+// GetImageURL returns a short-lived signed link to the image file.
+// ?variant=thumbnail|medium selects a generated variant.
+func (h *ImageHandler) GetImageURL(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid image ID"))
+		return
+	}
+
+	link, err := h.imageUc.GetImageURL(r.Context(), id, r.URL.Query().Get("variant"))
+	if err != nil {
+		if appErr, ok := err.(*helpers.AppError); ok {
+			helpers.Error(w, appErr)
+			return
+		}
+		helpers.Error(w, helpers.NewAppError(http.StatusInternalServerError, "Failed to create image link"))
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Image link created successfully", link)
+}
+
 // GetUserImages retrieves all images for a user
 func (h *ImageHandler) GetUserImages(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "user_id")
@@ -219,7 +242,7 @@ func (h *ImageHandler) GetUserImages(w http.ResponseWriter, r *http.Request) {
 		pageSize = 10
 	}
 
-	images, err := h.imageUc.GetUserImages(userID, page, pageSize)
+	images, err := h.imageUc.GetUserImages(r.Context(), userID, page, pageSize)
 	if err != nil {
 		if appErr, ok := err.(*helpers.AppError); ok {
 			helpers.Error(w, appErr)
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+)
+
+type JWKSHandler struct {
+	signingKeyUC usecase.SigningKeyUsecase
+}
+
+func JWKSNewHandler(signingKeyUC usecase.SigningKeyUsecase) *JWKSHandler {
+	return &JWKSHandler{signingKeyUC: signingKeyUC}
+}
+
+// GET /.well-known/jwks.json
+// Served as a bare JWK set (RFC 7517) rather than the usual envelope so
+// standard JWT libraries can consume it directly
+func (h *JWKSHandler) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(h.signingKeyUC.JWKS())
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type LabHandler struct {
+	labUC usecase.LabUsecase
+}
+
+func LabNewHandler(labUC usecase.LabUsecase) *LabHandler {
+	return &LabHandler{labUC: labUC}
+}
+
+// POST /lab/tests/create
+func (h *LabHandler) CreateTest(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateLabTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	test, err := h.labUC.CreateTest(r.Context(), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Lab test created successfully", test)
+}
+
+// GET /lab/tests/get-all?search=&active=&page=&page_size=
+func (h *LabHandler) ListTests(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := &dto.LabTestFilter{Search: q.Get("search")}
+	filter.ActiveOnly, _ = strconv.ParseBool(q.Get("active"))
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	tests, err := h.labUC.ListTests(filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Lab tests fetched", tests)
+}
+
+// GET /lab/tests/get/{id}
+func (h *LabHandler) GetTest(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid lab test ID"))
+		return
+	}
+
+	test, err := h.labUC.GetTest(id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Lab test fetched", test)
+}
+
+// PATCH /lab/tests/update/{id}
+func (h *LabHandler) UpdateTest(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid lab test ID"))
+		return
+	}
+
+	var req dto.UpdateLabTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	test, err := h.labUC.UpdateTest(r.Context(), id, &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Lab test updated successfully", test)
+}
+
+// POST /lab/orders/create
+func (h *LabHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateLabOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	order, err := h.labUC.CreateOrder(r.Context(), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Lab order created successfully", order)
+}
+
+// GET /lab/orders/get-all?patient_id=&ordered_by=&status=&priority=&from=&to=&page=&page_size=
+// from/to are RFC3339 timestamps
+func (h *LabHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := &dto.LabOrderFilter{
+		PatientID: q.Get("patient_id"),
+		OrderedBy: q.Get("ordered_by"),
+		Status:    q.Get("status"),
+		Priority:  q.Get("priority"),
+	}
+	h.listOrders(w, r, filter)
+}
+
+// GET /lab/orders/patient/{patient_id}?status=&page=&page_size=
+// The lab section of the patient record
+func (h *LabHandler) ListByPatient(w http.ResponseWriter, r *http.Request) {
+	patientID, err := uuid.Parse(utils.Param(r, "patient_id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid patient ID"))
+		return
+	}
+
+	filter := &dto.LabOrderFilter{
+		PatientID: patientID.String(),
+		Status:    r.URL.Query().Get("status"),
+	}
+	h.listOrders(w, r, filter)
+}
+
+func (h *LabHandler) listOrders(w http.ResponseWriter, r *http.Request, filter *dto.LabOrderFilter) {
+	q := r.URL.Query()
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	for name, value := range map[string]string{"patient_id": filter.PatientID, "ordered_by": filter.OrderedBy} {
+		if value == "" {
+			continue
+		}
+		if _, err := uuid.Parse(value); err != nil {
+			helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid "+name))
+			return
+		}
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid from, expected RFC3339"))
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid to, expected RFC3339"))
+		return
+	}
+
+	orders, err := h.labUC.ListOrders(r.Context(), filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Lab orders fetched", orders)
+}
+
+// GET /lab/orders/get/{id}
+func (h *LabHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.labUC.GetOrder(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Lab order fetched", order)
+}
+
+// POST /lab/orders/{id}/access
+func (h *LabHandler) GrantAccess(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.LabOrderAccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	if err := h.labUC.GrantAccess(r.Context(), id, &req); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Access granted", nil)
+}
+
+// DELETE /lab/orders/{id}/access/{user_id}
+func (h *LabHandler) RevokeAccess(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(utils.Param(r, "user_id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid user ID"))
+		return
+	}
+
+	if err := h.labUC.RevokeAccess(r.Context(), id, userID); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Access revoked", nil)
+}
+
+// POST /lab/orders/{id}/cancel
+func (h *LabHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.CancelLabOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	order, err := h.labUC.CancelOrder(r.Context(), id, &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Lab order cancelled", order)
+}
+
+// POST /lab/orders/{id}/samples
+func (h *LabHandler) CollectSample(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.CollectLabSampleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	order, err := h.labUC.CollectSample(r.Context(), id, &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Sample collected", order)
+}
+
+// PUT /lab/orders/{id}/tests/{test_id}/results
+// test_id is the ID of the test within the order
+func (h *LabHandler) EnterResults(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+	testID, err := uuid.Parse(utils.Param(r, "test_id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid test ID"))
+		return
+	}
+
+	var req dto.EnterLabResultsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	order, err := h.labUC.EnterResults(r.Context(), id, testID, &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Results saved", order)
+}
+
+// POST /lab/orders/{id}/release
+func (h *LabHandler) Release(w http.ResponseWriter, r *http.Request) {
+	id, ok := labOrderIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.labUC.Release(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Results released", order)
+}
+
+func labOrderIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid lab order ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/realtime"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// streamHeartbeat keeps idle streams from being cut by proxies
+const streamHeartbeat = 25 * time.Second
+
+type NotificationHandler struct {
+	notifyUC usecase.NotificationUsecase
+}
+
+func NotificationNewHandler(notifyUC usecase.NotificationUsecase) *NotificationHandler {
+	return &NotificationHandler{notifyUC: notifyUC}
+}
+
+// GET /notifications/preferences
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := h.notifyUC.Preferences(r.Context())
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Notification preferences fetched", prefs)
+}
+
+// PUT /notifications/preferences
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var req dto.NotificationPreferencesRequest
+	utils.BodyDecoder(w, r, &req)
+
+	prefs, err := h.notifyUC.UpdatePreferences(r.Context(), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Notification preferences updated", prefs)
+}
+
+// GET /notifications/get-all?unread=true&page=&page_size=
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := &dto.NotificationFilter{UnreadOnly: q.Get("unread") == "true"}
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+
+	list, err := h.notifyUC.List(r.Context(), filter)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Notifications fetched", list)
+}
+
+// GET /notifications/unread-count
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	count, err := h.notifyUC.UnreadCount(r.Context())
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Unread notifications counted", count)
+}
+
+// PUT /notifications/{id}/read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid notification ID"))
+		return
+	}
+
+	if err := h.notifyUC.MarkRead(r.Context(), id); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Notification marked as read", nil)
+}
+
+// PUT /notifications/read-all
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	count, err := h.notifyUC.MarkAllRead(r.Context())
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "All notifications marked as read", count)
+}
+
+// GET /notifications/stream
+// Server-Sent Events: "notification" for each new inbox entry and
+// "unread_count" whenever the count changes. Browsers may pass the JWT as
+// ?access_token= since EventSource cannot set headers.
+func (h *NotificationHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.Error(w, helpers.NewAppError(http.StatusInternalServerError, "Streaming is not supported"))
+		return
+	}
+
+	events, unsubscribe, err := h.notifyUC.Subscribe(r.Context())
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx would otherwise buffer the stream
+	w.WriteHeader(http.StatusOK)
+
+	// Start with the current count so the client needs no extra request
+	if count, err := h.notifyUC.UnreadCount(r.Context()); err == nil {
+		writeStreamEvent(w, realtime.Event{Name: usecase.UnreadCountStreamEvent, Data: count})
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, open := <-events:
+			if !open {
+				return
+			}
+			if err := writeStreamEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, ev realtime.Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		log.Println("Failed to encode stream event:", err)
+		return nil
+	}
+	if ev.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", ev.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, data)
+	return err
+}
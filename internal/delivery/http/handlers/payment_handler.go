@@ -41,7 +41,7 @@ func (h *PaymentHandler) Success(w http.ResponseWriter, r *http.Request) {
 	cb.PaymentDate = r.FormValue("tran_date")
 	cb.Status = r.FormValue("status")
 
-	if err := h.uc.HandleSuccessCallback(cb); err != nil {
+	if err := h.uc.HandleSuccessCallback(r.Context(), cb); err != nil {
 		helpers.Error(w, err)
 		return
 	}
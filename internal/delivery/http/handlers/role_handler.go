@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+)
+
+type RoleHandler struct {
+	roleUC usecase.RoleUsecase
+}
+
+func RoleNewHandler(roleUC usecase.RoleUsecase) *RoleHandler {
+	return &RoleHandler{roleUC: roleUC}
+}
+
+// POST /roles/create
+func (h *RoleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	role, err := h.roleUC.Create(r.Context(), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusCreated, "Role created successfully", role)
+}
+
+// GET /roles/get-all
+func (h *RoleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleUC.GetAll()
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Roles fetched successfully", roles)
+}
+
+// GET /roles/get/{id}
+func (h *RoleHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id := utils.Param(r, "id")
+
+	role, err := h.roleUC.GetByID(id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Role retrieved successfully", role)
+}
+
+// PATCH /roles/update/{id}
+func (h *RoleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := utils.Param(r, "id")
+
+	var req dto.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	role, err := h.roleUC.Update(r.Context(), id, &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Role updated successfully", role)
+}
+
+// DELETE /roles/delete/{id}
+func (h *RoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := utils.Param(r, "id")
+
+	if err := h.roleUC.Delete(r.Context(), id); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Role deleted successfully", nil)
+}
+
+// PUT /roles/assign/{user_id}
+func (h *RoleHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	userID := utils.Param(r, "user_id")
+
+	var req dto.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	if err := h.roleUC.AssignRole(r.Context(), userID, &req); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Role assigned successfully", nil)
+}
+
+// GET /roles/permissions
+func (h *RoleHandler) GetAllPermissions(w http.ResponseWriter, r *http.Request) {
+	perms, err := h.roleUC.GetAllPermissions()
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Permissions fetched successfully", perms)
+}
+
+// POST /roles/permissions/create
+func (h *RoleHandler) CreatePermission(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreatePermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON body"))
+		return
+	}
+
+	perm, err := h.roleUC.CreatePermission(&req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusCreated, "Permission created successfully", perm)
+}
@@ -3,19 +3,21 @@ package handlers
 import (
 	"encoding/json"
 	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/storage"
 	"hospital_management_system/internal/pkg/helpers"
 	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/pkg/validators"
 	"hospital_management_system/internal/usecase"
 	"net/http"
 )
 
 type RoomHandler struct {
-	roomUC   usecase.RoomUsecase
-	uploader *helpers.CloudinaryUploader
+	roomUC  usecase.RoomUsecase
+	storage storage.Storage
 }
 
-func RoomNewHandler(roomUC usecase.RoomUsecase, uploader *helpers.CloudinaryUploader) *RoomHandler {
-	return &RoomHandler{roomUC: roomUC, uploader: uploader}
+func RoomNewHandler(roomUC usecase.RoomUsecase, store storage.Storage) *RoomHandler {
+	return &RoomHandler{roomUC: roomUC, storage: store}
 }
 
 // POST /rooms/create
@@ -37,20 +39,27 @@ func (h *RoomHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var uploadedKey string
 	file, fileHeader, err := r.FormFile("image")
 	if err == nil {
 		defer file.Close()
-		uploadOpts := &helpers.UploadOptions{Folder: "room_images"}
-		uploadedImage, err := h.uploader.UploadImage(file, fileHeader, uploadOpts)
+		contentType, err := validators.ValidateImage(file, fileHeader)
+		if err != nil {
+			helpers.Error(w, err)
+			return
+		}
+		uploadedImage, err := storage.PutMultipart(r.Context(), h.storage, file, fileHeader, "room_images", contentType)
 		if err != nil {
 			helpers.Error(w, helpers.NewAppError(http.StatusInternalServerError, "Failed to upload image"))
 			return
 		}
+		uploadedKey = uploadedImage.Key
 		req.Image = &uploadedImage.URL
 	}
 
 	room, err := h.roomUC.Create(&req)
 	if err != nil {
+		storage.Discard(r.Context(), h.storage, uploadedKey)
 		helpers.Error(w, err)
 		return
 	}
@@ -112,16 +121,22 @@ func (h *RoomHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Handle image upload
+	var uploadedKey string
 	file, fileHeader, err := r.FormFile("image")
 	if err == nil {
 		defer file.Close()
 
-		uploadOpts := &helpers.UploadOptions{Folder: "room_images"}
-		uploadedImage, err := h.uploader.UploadImage(file, fileHeader, uploadOpts)
+		contentType, err := validators.ValidateImage(file, fileHeader)
+		if err != nil {
+			helpers.Error(w, err)
+			return
+		}
+		uploadedImage, err := storage.PutMultipart(r.Context(), h.storage, file, fileHeader, "room_images", contentType)
 		if err != nil {
 			helpers.Error(w, helpers.NewAppError(http.StatusInternalServerError, "Failed to upload image"))
 			return
 		}
+		uploadedKey = uploadedImage.Key
 		// fmt.Printf(uploadedImage.URL)
 		// Update profile image URL in request
 		req.Image = &uploadedImage.URL
@@ -132,6 +147,7 @@ func (h *RoomHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	room, err := h.roomUC.Update(id, &req)
 	if err != nil {
+		storage.Discard(r.Context(), h.storage, uploadedKey)
 		helpers.Error(w, err)
 		return
 	}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+)
+
+type StorageGCHandler struct {
+	gcUC usecase.StorageGCUsecase
+}
+
+func StorageGCNewHandler(gcUC usecase.StorageGCUsecase) *StorageGCHandler {
+	return &StorageGCHandler{gcUC: gcUC}
+}
+
+// POST /storage/gc?dry_run=false
+// Reports orphaned files; they are only deleted when dry_run=false is passed
+func (h *StorageGCHandler) Run(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dry_run") != "false"
+
+	report, err := h.gcUC.Run(r.Context(), dryRun)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	message := "Orphaned files deleted"
+	if dryRun {
+		message = "Orphaned files found (dry run)"
+	}
+	helpers.Success(w, http.StatusOK, message, report)
+}
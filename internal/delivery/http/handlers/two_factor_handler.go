@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/pkg/utils/jwt"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+)
+
+type TwoFactorHandler struct {
+	twoFactorUc usecase.TwoFactorUsecase
+	authUc      usecase.AuthUsecase
+}
+
+func TwoFactorNewHandler(twoFactorUc usecase.TwoFactorUsecase, authUc usecase.AuthUsecase) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorUc: twoFactorUc, authUc: authUc}
+}
+
+// POST /auth/2fa/enroll — accepts an access token or a setup token from Login
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := enrollmentClaims(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.twoFactorUc.Enroll(claims.UserID)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	helpers.Success(w, http.StatusOK, "Scan the URI with your authenticator app", res)
+}
+
+// POST /auth/2fa/enable — accepts an access token or a setup token from Login
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	claims, ok := enrollmentClaims(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.TwoFactorCodeRequest
+	utils.BodyDecoder(w, r, &req)
+
+	codes, err := h.twoFactorUc.Enable(claims.UserID, req.Code)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+
+	res := &dto.TwoFactorEnableResponse{RecoveryCodes: codes}
+	if claims.Purpose == jwt.PurposeTwoFactorSetup {
+		token, err := h.authUc.IssueAccessToken(claims.UserID)
+		if err != nil {
+			helpers.Error(w, err)
+			return
+		}
+		res.Token = token
+	}
+
+	helpers.Success(w, http.StatusOK, "Two-factor authentication enabled. Store the recovery codes safely.", res)
+}
+
+// GET /auth/2fa/status
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	jwtClaims, err := jwt.GetUserDataFromReqJWT(r)
+	if err != nil || jwtClaims == nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, "Unauthorized"))
+		return
+	}
+
+	res, err := h.twoFactorUc.Status(jwtClaims.UserID)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Two-factor status fetched", res)
+}
+
+// POST /auth/2fa/disable
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	jwtClaims, err := jwt.GetUserDataFromReqJWT(r)
+	if err != nil || jwtClaims == nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, "Unauthorized"))
+		return
+	}
+
+	var req dto.TwoFactorCodeRequest
+	utils.BodyDecoder(w, r, &req)
+
+	if err := h.twoFactorUc.Disable(jwtClaims.UserID, req.Code); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// POST /auth/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	jwtClaims, err := jwt.GetUserDataFromReqJWT(r)
+	if err != nil || jwtClaims == nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, "Unauthorized"))
+		return
+	}
+
+	var req dto.TwoFactorCodeRequest
+	utils.BodyDecoder(w, r, &req)
+
+	codes, err := h.twoFactorUc.RegenerateRecoveryCodes(jwtClaims.UserID, req.Code)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Recovery codes regenerated", &dto.TwoFactorEnableResponse{RecoveryCodes: codes})
+}
+
+// POST /auth/2fa/reset/{user_id} — admin only
+func (h *TwoFactorHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	userID := utils.Param(r, "user_id")
+
+	if err := h.twoFactorUc.Reset(r.Context(), userID); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Two-factor authentication reset", nil)
+}
+
+// enrollmentClaims accepts a regular access token or a two-factor setup token
+func enrollmentClaims(w http.ResponseWriter, r *http.Request) (*jwt.UserClaims, bool) {
+	claims, err := jwt.GetUserDataFromReqJWT(r)
+	if err != nil || claims == nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, "Unauthorized"))
+		return nil, false
+	}
+	if claims.Purpose != "" && claims.Purpose != jwt.PurposeTwoFactorSetup {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, "Unauthorized"))
+		return nil, false
+	}
+	return claims, true
+}
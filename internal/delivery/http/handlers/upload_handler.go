@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ChunkChecksumHeader carries the hex SHA-256 of a chunk body
+const ChunkChecksumHeader = "X-Chunk-Checksum"
+
+// UploadHandler implements resumable uploads: init a session, PUT the
+// chunks in any order (re-sending is safe), then complete
+type UploadHandler struct {
+	uploadUC usecase.UploadUsecase
+}
+
+func UploadNewHandler(uploadUC usecase.UploadUsecase) *UploadHandler {
+	return &UploadHandler{uploadUC: uploadUC}
+}
+
+// POST /uploads/init
+func (h *UploadHandler) Init(w http.ResponseWriter, r *http.Request) {
+	var req dto.UploadInitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid request body"))
+		return
+	}
+
+	session, err := h.uploadUC.Init(r.Context(), &req)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusCreated, "Upload started", session)
+}
+
+// PUT /uploads/{id}/chunks/{index}
+// raw chunk bytes as the body, X-Chunk-Checksum: <sha256 hex>
+func (h *UploadHandler) PutChunk(w http.ResponseWriter, r *http.Request) {
+	id, ok := uploadIDParam(w, r)
+	if !ok {
+		return
+	}
+	index, err := strconv.Atoi(utils.Param(r, "index"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid chunk index"))
+		return
+	}
+	checksum := r.Header.Get(ChunkChecksumHeader)
+	if checksum == "" {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, ChunkChecksumHeader+" header is required"))
+		return
+	}
+
+	session, err := h.uploadUC.PutChunk(r.Context(), id, index, r.Body, checksum)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Chunk received", session)
+}
+
+// GET /uploads/{id}
+func (h *UploadHandler) Status(w http.ResponseWriter, r *http.Request) {
+	id, ok := uploadIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := h.uploadUC.Status(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Upload retrieved successfully", session)
+}
+
+// POST /uploads/{id}/complete
+func (h *UploadHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	id, ok := uploadIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.uploadUC.Complete(r.Context(), id)
+	if err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusCreated, "Upload completed", result)
+}
+
+// DELETE /uploads/{id}
+func (h *UploadHandler) Abort(w http.ResponseWriter, r *http.Request) {
+	id, ok := uploadIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.uploadUC.Abort(r.Context(), id); err != nil {
+		helpers.Error(w, err)
+		return
+	}
+	helpers.Success(w, http.StatusOK, "Upload aborted", nil)
+}
+
+func uploadIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(utils.Param(r, "id"))
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid upload ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
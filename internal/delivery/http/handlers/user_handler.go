@@ -5,20 +5,21 @@ import (
 	"net/http"
 
 	"hospital_management_system/internal/dto"
-	"hospital_management_system/internal/infra/rabbitmq"
+	"hospital_management_system/internal/infra/storage"
 	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
 	"hospital_management_system/internal/pkg/utils/jwt"
+	"hospital_management_system/internal/pkg/validators"
 	"hospital_management_system/internal/usecase"
 )
 
 // Handler handles user-related HTTP requests
 type UserHandler struct {
-	userUc    usecase.UserUsecase
-	otpUC     usecase.OtpUsecase
-	emailUC   usecase.EmailUsecase
-	publisher *rabbitmq.Publisher
-	uploader  *helpers.CloudinaryUploader
+	userUc  usecase.UserUsecase
+	otpUC   usecase.OtpUsecase
+	emailUC usecase.EmailUsecase
+	roleUC  usecase.RoleUsecase
+	storage storage.Storage
 }
 
 // NewHandler creates a new User Handler
@@ -26,19 +27,50 @@ func UserNewHandler(
 	userUc usecase.UserUsecase,
 	otpUC usecase.OtpUsecase,
 	emailUC usecase.EmailUsecase,
-	publisher *rabbitmq.Publisher,
-	uploader *helpers.CloudinaryUploader,
+	roleUC usecase.RoleUsecase,
+	store storage.Storage,
 ) *UserHandler {
 	return &UserHandler{
-		userUc:    userUc,
-		otpUC:     otpUC,
-		emailUC:   emailUC,
-		publisher: publisher,
-		uploader:  uploader,
+		userUc:  userUc,
+		otpUC:   otpUC,
+		emailUC: emailUC,
+		roleUC:  roleUC,
+		storage: store,
 	}
 }
 
-func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+// RegisterPatient is public, so the role is always patient
+func (h *UserHandler) RegisterPatient(w http.ResponseWriter, r *http.Request) {
+	h.register(w, r, func(req *dto.RegisterRequest) error {
+		req.Role = models.RolePatient
+		return nil
+	})
+}
+
+func (h *UserHandler) RegisterDoctor(w http.ResponseWriter, r *http.Request) {
+	h.register(w, r, func(req *dto.RegisterRequest) error {
+		req.Role = models.RoleDoctor
+		return nil
+	})
+}
+
+func (h *UserHandler) RegisterAdmin(w http.ResponseWriter, r *http.Request) {
+	h.register(w, r, func(req *dto.RegisterRequest) error {
+		req.Role = models.RoleAdmin
+		return nil
+	})
+}
+
+// RegisterStaff takes the role from the request; it must be an existing
+// staff role, never admin
+func (h *UserHandler) RegisterStaff(w http.ResponseWriter, r *http.Request) {
+	h.register(w, r, func(req *dto.RegisterRequest) error {
+		return h.roleUC.CheckStaffRole(req.Role)
+	})
+}
+
+// register creates the account once setRole has settled the request's role
+func (h *UserHandler) register(w http.ResponseWriter, r *http.Request, setRole func(req *dto.RegisterRequest) error) {
 	// Parse multipart form
 	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB limit
 		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid form data"))
@@ -52,18 +84,28 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid JSON data"))
 		return
 	}
+	if err := setRole(&req); err != nil {
+		helpers.Error(w, err)
+		return
+	}
 
 	// Handle image upload
+	var uploadedKey string
 	file, fileHeader, err := r.FormFile("image")
-	if err == nil {
+	if err == nil && (req.Doctor != nil || req.Patient != nil) {
 		defer file.Close()
 
-		uploadOpts := &helpers.UploadOptions{Folder: "user_profiles"}
-		uploadedImage, err := h.uploader.UploadImage(file, fileHeader, uploadOpts)
+		contentType, err := validators.ValidateImage(file, fileHeader)
+		if err != nil {
+			helpers.Error(w, err)
+			return
+		}
+		uploadedImage, err := storage.PutMultipart(r.Context(), h.storage, file, fileHeader, "user_profiles", contentType)
 		if err != nil {
 			helpers.Error(w, helpers.NewAppError(http.StatusInternalServerError, "Failed to upload image"))
 			return
 		}
+		uploadedKey = uploadedImage.Key
 		// fmt.Printf(uploadedImage.URL)
 		// Update profile image URL in request
 		if req.Doctor != nil {
@@ -71,18 +113,22 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		} else if req.Patient != nil {
 			req.Patient.ProfileImageURL = uploadedImage.URL
 		}
+	} else if err == nil {
+		file.Close()
 	} else if err != http.ErrMissingFile {
 		helpers.Error(w, helpers.NewAppError(http.StatusBadRequest, "Invalid image file"))
 		return
 	}
 
-	// Register the user
+	// Register the user; the image is uploaded first, so drop it if that fails
 	user, err := h.userUc.Register(&req)
 	if err != nil {
+		storage.Discard(r.Context(), h.storage, uploadedKey)
 		helpers.Error(w, err)
 		return
 	}
 	if user == nil {
+		storage.Discard(r.Context(), h.storage, uploadedKey)
 		helpers.Error(w, err)
 		return
 	}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	searchAuditLogsRoute = "/get-all"
+)
+
+func RegisterAuditRoutes(r chi.Router, handler *handlers.AuditHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/audit-logs"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceAuditLogs, models.ActionRead)).Get(searchAuditLogsRoute, handler.Search)
+	})
+}
@@ -2,6 +2,8 @@ package routes
 
 import (
 	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
@@ -9,13 +11,41 @@ import (
 
 const (
 	loginRoute    = "/login"
+
+	twoFactorVerifyRoute        = "/2fa/verify"
+	twoFactorEnrollRoute        = "/2fa/enroll"
+	twoFactorEnableRoute        = "/2fa/enable"
+	twoFactorStatusRoute        = "/2fa/status"
+	twoFactorDisableRoute       = "/2fa/disable"
+	twoFactorRecoveryCodesRoute = "/2fa/recovery-codes"
+	twoFactorResetRoute         = "/2fa/reset/{user_id}"
+	unlockAccountRoute          = "/unlock/{user_id}"
 )
 
-func RegisterAuthRoutes(r chi.Router, handler *handlers.AuthHandler, userUC usecase.UserUsecase) {
+func RegisterAuthRoutes(r chi.Router, handler *handlers.AuthHandler, twoFactorHandler *handlers.TwoFactorHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const userRoutePrefix = "/auth"
 
 	r.Route(userRoutePrefix, func(r chi.Router) {
 		// Public routes
 		r.Post(loginRoute, handler.Login)
+		r.Post(twoFactorVerifyRoute, handler.VerifyTwoFactor)
+
+		// Accept either an access token or the setup token returned by Login
+		r.Post(twoFactorEnrollRoute, twoFactorHandler.Enroll)
+		r.Post(twoFactorEnableRoute, twoFactorHandler.Enable)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.Authenticated(userUC))
+			r.Get(twoFactorStatusRoute, twoFactorHandler.Status)
+			r.Post(twoFactorDisableRoute, twoFactorHandler.Disable)
+			r.Post(twoFactorRecoveryCodesRoute, twoFactorHandler.RegenerateRecoveryCodes)
+		})
+
+		// Admin account recovery
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceUsers, models.ActionUpdate))
+			r.Post(twoFactorResetRoute, twoFactorHandler.Reset)
+			r.Post(unlockAccountRoute, handler.Unlock)
+		})
 	})
 }
@@ -17,25 +17,18 @@ const (
 	deleteBookingDeleteRoute = "/delete/{id}"
 )
 
-func RegisterBookingRoutes(r chi.Router, handler *handlers.BookingHandler, userUC usecase.UserUsecase) {
+func RegisterBookingRoutes(r chi.Router, handler *handlers.BookingHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const bookingRoutePrefix = "/bookings"
 
 	r.Route(bookingRoutePrefix, func(r chi.Router) {
 
-		// Patient routes → Create a booking
-		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{models.RolePatient}))
-			r.Post(bookingCreateRoute, handler.Create)
-		})
+		// Create a booking
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceBookings, models.ActionCreate)).Post(bookingCreateRoute, handler.Create)
 
-		// Admin + Doctor routes
-		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{models.RoleAdmin, models.RoleDoctor}))
-
-			r.Get(getBookingListRoute, handler.GetAll)
-			r.Get(getBookingByIDRoute, handler.GetByID)
-			r.Put(changeBookingStatusRoute, handler.UpdateStatus)
-			r.Delete(deleteBookingDeleteRoute, handler.Delete)
-		})
+		// Staff routes
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceBookings, models.ActionRead)).Get(getBookingListRoute, handler.GetAll)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceBookings, models.ActionRead)).Get(getBookingByIDRoute, handler.GetByID)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceBookings, models.ActionUpdate)).Put(changeBookingStatusRoute, handler.UpdateStatus)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceBookings, models.ActionDelete)).Delete(deleteBookingDeleteRoute, handler.Delete)
 	})
 }
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	uploadDocumentRoute       = "/upload"
+	getDocumentRoute          = "/get/{id}"
+	getPatientDocumentsRoute  = "/patient/{patient_id}"
+	addDocumentVersionRoute   = "/{id}/versions"
+	downloadDocumentRoute     = "/{id}/download"
+	updateDocumentTagsRoute   = "/{id}/tags"
+	grantDocumentAccessRoute  = "/{id}/access"
+	revokeDocumentAccessRoute = "/{id}/access/{user_id}"
+	deleteDocumentRoute       = "/delete/{id}"
+)
+
+// RegisterDocumentRoutes mounts the patient document vault. Role permissions
+// gate each route; the usecase additionally checks access to each document.
+func RegisterDocumentRoutes(r chi.Router, handler *handlers.DocumentHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/documents"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceDocuments, models.ActionCreate)).Post(uploadDocumentRoute, handler.Upload)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceDocuments, models.ActionRead))
+			r.Get(getDocumentRoute, handler.GetByID)
+			r.Get(getPatientDocumentsRoute, handler.ListByPatient)
+			r.Get(downloadDocumentRoute, handler.Download)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceDocuments, models.ActionUpdate))
+			r.Post(addDocumentVersionRoute, handler.AddVersion)
+			r.Put(updateDocumentTagsRoute, handler.UpdateTags)
+			r.Post(grantDocumentAccessRoute, handler.GrantAccess)
+			r.Delete(revokeDocumentAccessRoute, handler.RevokeAccess)
+		})
+
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceDocuments, models.ActionDelete)).Delete(deleteDocumentRoute, handler.Delete)
+	})
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	searchEmailsRoute        = "/get-all"
+	getEmailRoute            = "/{id}"
+	emailBodyRoute           = "/{id}/body"
+	resendEmailRoute         = "/{id}/resend"
+	requeueEmailRoute        = "/{id}/requeue"
+	requeueFailedEmailsRoute = "/requeue-failed"
+	openEmailRoute           = "/{id}/open"
+	unsubscribeEmailRoute    = "/unsubscribe"
+	bounceWebhookRoute       = "/webhooks/dsn"
+	providerWebhookRoute     = "/webhooks/events"
+	suppressionsRoute        = "/suppressions"
+	suppressionRoute         = "/suppressions/{id}"
+)
+
+func RegisterEmailRoutes(r chi.Router, handler *handlers.EmailHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/emails"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionRead)).Get(searchEmailsRoute, handler.Search)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionRead)).Get(getEmailRoute, handler.Get)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionRead)).Get(emailBodyRoute, handler.Body)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionUpdate)).Post(resendEmailRoute, handler.Resend)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionUpdate)).Post(requeueEmailRoute, handler.Requeue)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionUpdate)).Post(requeueFailedEmailsRoute, handler.RequeueFailed)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionRead)).Get(suppressionsRoute, handler.ListSuppressions)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmails, models.ActionDelete)).Delete(suppressionRoute, handler.DeleteSuppression)
+
+		// Public: opened from the recipient's mail client
+		r.Get(openEmailRoute, handler.Open)
+		r.Get(unsubscribeEmailRoute, handler.UnsubscribeForm)
+		r.Post(unsubscribeEmailRoute, handler.Unsubscribe)
+
+		// Bounce reports and provider events, authenticated by EMAIL_WEBHOOK_SECRET
+		r.Post(bounceWebhookRoute, handler.BounceDSN)
+		r.Post(providerWebhookRoute, handler.ProviderEvents)
+	})
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	listEmailTemplatesRoute    = "/get-all"
+	emailTemplateVersionsRoute = "/{type}/versions"
+	activateEmailTemplateRoute = "/{type}/versions/{version}/activate"
+	resetEmailTemplateRoute    = "/{type}/active"
+	previewEmailTemplateRoute  = "/{type}/preview"
+	testEmailTemplateRoute     = "/{type}/test"
+)
+
+func RegisterEmailTemplateRoutes(r chi.Router, handler *handlers.EmailTemplateHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/email-templates"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionRead)).Get(listEmailTemplatesRoute, handler.List)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionRead)).Get(emailTemplateVersionsRoute, handler.Versions)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionCreate)).Post(emailTemplateVersionsRoute, handler.Create)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionUpdate)).Post(activateEmailTemplateRoute, handler.Activate)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionUpdate)).Delete(resetEmailTemplateRoute, handler.ResetToDefault)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionRead)).Post(previewEmailTemplateRoute, handler.Preview)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceEmailTemplates, models.ActionCreate)).Post(testEmailTemplateRoute, handler.SendTest)
+	})
+}
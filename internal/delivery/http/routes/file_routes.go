@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	downloadFileRoute = "/*"
+)
+
+// RegisterFileRoutes mounts the signed download endpoint. The signature is
+// the authorization, so no auth middleware is applied.
+func RegisterFileRoutes(r chi.Router, handler *handlers.FileHandler) {
+	const prefix = "/files"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.Get(downloadFileRoute, handler.Download)
+	})
+}
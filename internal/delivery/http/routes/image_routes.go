@@ -13,25 +13,23 @@ const (
 	uploadImage          = "/upload"
 	uploadMultipleImages = "/upload-multiple"
 	getImage             = "/{id}"
+	getImageURL          = "/{id}/url"
 	getUserImages        = "/user/{user_id}"
 	deleteImage          = "/delete/{id}"
 )
 
-func RegisterImageRoutes(r chi.Router, handler *handlers.ImageHandler, userUC usecase.UserUsecase) {
+func RegisterImageRoutes(r chi.Router, handler *handlers.ImageHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const imageRoutePrefix = "/images"
 
 	r.Route(imageRoutePrefix, func(r chi.Router) {
 
 		// Protected routes
-		r.Group(func(r chi.Router) {
-			// Any authenticated user can access profile
-			r.Use(middlewares.Auth(userUC, []string{models.RoleAdmin, models.RoleDoctor, models.RolePatient}))
-			r.Post(uploadImage, handler.UploadImage)
-			r.Post(uploadMultipleImages, handler.UploadMultipleImages)
-			r.Get(getImage, handler.GetImage)
-			r.Get(getUserImages, handler.GetUserImages)
-			r.Delete(deleteImage, handler.DeleteImage)
-		})
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceImages, models.ActionCreate)).Post(uploadImage, handler.UploadImage)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceImages, models.ActionCreate)).Post(uploadMultipleImages, handler.UploadMultipleImages)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceImages, models.ActionRead)).Get(getImage, handler.GetImage)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceImages, models.ActionRead)).Get(getImageURL, handler.GetImageURL)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceImages, models.ActionRead)).Get(getUserImages, handler.GetUserImages)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceImages, models.ActionDelete)).Delete(deleteImage, handler.DeleteImage)
 	})
 
 }
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	createLabTestRoute        = "/tests/create"
+	getAllLabTestsRoute       = "/tests/get-all"
+	getLabTestRoute           = "/tests/get/{id}"
+	updateLabTestRoute        = "/tests/update/{id}"
+	createLabOrderRoute       = "/orders/create"
+	getAllLabOrdersRoute      = "/orders/get-all"
+	getLabOrderRoute          = "/orders/get/{id}"
+	getPatientLabOrdersRoute  = "/orders/patient/{patient_id}"
+	grantLabOrderAccessRoute  = "/orders/{id}/access"
+	revokeLabOrderAccessRoute = "/orders/{id}/access/{user_id}"
+	cancelLabOrderRoute       = "/orders/{id}/cancel"
+	collectLabSampleRoute     = "/orders/{id}/samples"
+	enterLabResultsRoute      = "/orders/{id}/tests/{test_id}/results"
+	releaseLabResultsRoute    = "/orders/{id}/release"
+)
+
+// RegisterLabRoutes mounts the lab catalogue and orders. Doctors order,
+// lab technicians collect, enter and release; the usecase keeps patients
+// to their own orders and doctors to their patients' and shared ones.
+func RegisterLabRoutes(r chi.Router, handler *handlers.LabHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/lab"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabTests, models.ActionCreate)).Post(createLabTestRoute, handler.CreateTest)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabTests, models.ActionRead)).Get(getAllLabTestsRoute, handler.ListTests)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabTests, models.ActionRead)).Get(getLabTestRoute, handler.GetTest)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabTests, models.ActionUpdate)).Patch(updateLabTestRoute, handler.UpdateTest)
+
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabOrders, models.ActionCreate)).Post(createLabOrderRoute, handler.CreateOrder)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabOrders, models.ActionRead))
+			r.Get(getAllLabOrdersRoute, handler.ListOrders)
+			r.Get(getLabOrderRoute, handler.GetOrder)
+			r.Get(getPatientLabOrdersRoute, handler.ListByPatient)
+			// The usecase lets only the patient, ordering doctor or an admin share
+			r.Post(grantLabOrderAccessRoute, handler.GrantAccess)
+			r.Delete(revokeLabOrderAccessRoute, handler.RevokeAccess)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabOrders, models.ActionUpdate))
+			r.Post(cancelLabOrderRoute, handler.CancelOrder)
+			r.Post(collectLabSampleRoute, handler.CollectSample)
+		})
+
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabResults, models.ActionCreate)).Put(enterLabResultsRoute, handler.EnterResults)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceLabResults, models.ActionUpdate)).Post(releaseLabResultsRoute, handler.Release)
+	})
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	notificationPreferencesRoute = "/preferences"
+	listNotificationsRoute       = "/get-all"
+	unreadNotificationsRoute     = "/unread-count"
+	markNotificationReadRoute    = "/{id}/read"
+	markAllNotificationsRoute    = "/read-all"
+	notificationStreamRoute      = "/stream"
+)
+
+// RegisterNotificationRoutes mounts the caller's own inbox and notification settings
+func RegisterNotificationRoutes(r chi.Router, handler *handlers.NotificationHandler, userUC usecase.UserUsecase) {
+	const prefix = "/notifications"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.TokenFromQuery, middlewares.Authenticated(userUC)).Get(notificationStreamRoute, handler.Stream)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.Authenticated(userUC))
+
+			r.Get(notificationPreferencesRoute, handler.GetPreferences)
+			r.Put(notificationPreferencesRoute, handler.UpdatePreferences)
+			r.Get(listNotificationsRoute, handler.List)
+			r.Get(unreadNotificationsRoute, handler.UnreadCount)
+			r.Put(markNotificationReadRoute, handler.MarkRead)
+			r.Put(markAllNotificationsRoute, handler.MarkAllRead)
+		})
+	})
+}
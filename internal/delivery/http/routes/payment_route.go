@@ -17,25 +17,19 @@ const (
 	getAllPaymentsRoute = "/get-all"
 )
 
-func RegisterPaymentRoutes(r chi.Router, handler *handlers.PaymentHandler, userUC usecase.UserUsecase) {
+func RegisterPaymentRoutes(r chi.Router, handler *handlers.PaymentHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const prefix = "/payments"
 
 	r.Route(prefix, func(r chi.Router) {
 
 		// Protected routes (User must be logged in)
 		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{
-				models.RolePatient,
-				models.RoleAdmin,
-				models.RoleDoctor,
-			}))
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourcePayments, models.ActionCreate))
 			r.Post(initPaymentRoute, handler.Init) // user initiates payment
 		})
  		// admin gets all payments
 		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{
-				models.RoleAdmin,
-			}))
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourcePayments, models.ActionRead))
 			r.Get(getAllPaymentsRoute, handler.GetAll)
 		})
 
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getAllRolesRoute       = "/get-all"
+	getRoleByIDRoute       = "/get/{id}"
+	createRoleRoute        = "/create"
+	updateRoleRoute        = "/update/{id}"
+	deleteRoleRoute        = "/delete/{id}"
+	assignRoleRoute        = "/assign/{user_id}"
+	getAllPermissionsRoute = "/permissions"
+	createPermissionRoute  = "/permissions/create"
+)
+
+func RegisterRoleRoutes(r chi.Router, handler *handlers.RoleHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/roles"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceRoles, models.ActionRead))
+			r.Get(getAllRolesRoute, handler.GetAll)
+			r.Get(getRoleByIDRoute, handler.GetByID)
+			r.Get(getAllPermissionsRoute, handler.GetAllPermissions)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceRoles, models.ActionCreate))
+			r.Post(createRoleRoute, handler.Create)
+			r.Post(createPermissionRoute, handler.CreatePermission)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceRoles, models.ActionUpdate))
+			r.Patch(updateRoleRoute, handler.Update)
+			r.Put(assignRoleRoute, handler.AssignRole)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceRoles, models.ActionDelete))
+			r.Delete(deleteRoleRoute, handler.Delete)
+		})
+	})
+}
@@ -18,17 +18,14 @@ const (
 	getAllRoomsRoute   = "/get-all"
 )
 
-func RegisterRoomRoutes(r chi.Router, handler *handlers.RoomHandler, userUC usecase.UserUsecase) {
+func RegisterRoomRoutes(r chi.Router, handler *handlers.RoomHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const prefix = "/rooms"
 
 	r.Route(prefix, func(r chi.Router) {
-		// Admin-only protected routes
-		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{models.RoleAdmin}))
-			r.Post(createRoomRoute, handler.Create)
-			r.Patch(updateRoomRoute, handler.Update)
-			r.Delete(deleteRoomRoute, handler.Delete)
-		})
+		// Protected routes
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceRooms, models.ActionCreate)).Post(createRoomRoute, handler.Create)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceRooms, models.ActionUpdate)).Patch(updateRoomRoute, handler.Update)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceRooms, models.ActionDelete)).Delete(deleteRoomRoute, handler.Delete)
 
 		// Public routes
 		r.Get(getAllRoomsRoute, handler.GetRooms)
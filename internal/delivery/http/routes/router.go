@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"context"
 	"log"
 
 	"github.com/go-chi/chi/v5"
@@ -8,17 +9,32 @@ import (
 
 	"hospital_management_system/config"
 	"hospital_management_system/internal/delivery/http/handlers"
-	"hospital_management_system/internal/infra/rabbitmq"
+	"hospital_management_system/internal/events"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/realtime"
 	"hospital_management_system/internal/infra/repository"
-	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/infra/sms"
+	"hospital_management_system/internal/infra/storage"
 	"hospital_management_system/internal/usecase"
 )
 
-func SetupRoutes(r chi.Router, db *gorm.DB, cloudinaryUploader *helpers.CloudinaryUploader) {
-	// Initialize RabbitMQ publisher dependencies
-	publisher, err := rabbitmq.NewPublisher(config.ENV.RabbitMqUrl, "email_queue")
+// SetupRoutes wires every usecase and registers its routes. Background
+// loops it starts run until ctx is cancelled.
+func SetupRoutes(ctx context.Context, r chi.Router, db *gorm.DB, store storage.Storage, signer *storage.URLSigner, mq queue.Queue) {
+	// The exchange and queues outbox messages route to must exist before
+	// their consumers have started
+	if err := mq.DeclareExchange(events.Exchange); err != nil {
+		log.Fatalf("Failed to declare events exchange: %v", err)
+	}
+	for _, name := range []string{queue.EmailQueue, queue.ImageQueue, queue.NotificationQueue} {
+		if err := mq.DeclareQueue(name); err != nil {
+			log.Fatalf("Failed to declare queue %s: %v", name, err)
+		}
+	}
+	outboxRepo := repository.OutboxNewRepository(db)
+	outboxRelay, err := usecase.OutboxRelayNewUsecase(outboxRepo, mq)
 	if err != nil {
-		log.Fatalf("Failed to create RabbitMQ publisher: %v", err)
+		log.Fatalf("Invalid outbox configuration: %v", err)
 	}
 
 	// Initialize Doctor dependencies
@@ -33,33 +49,83 @@ func SetupRoutes(r chi.Router, db *gorm.DB, cloudinaryUploader *helpers.Cloudina
 
 	// Initialize User dependencies
 	userRepo := repository.UserNewRepository(db)
-	userUsecase := usecase.UserNewUsecase(userRepo, doctorUsecase, patientUsecase)
-
-	// Initialize Auth dependencies
-
-	authUsecase := usecase.AuthNewUsecase(userRepo)
-	authHandler := handlers.AuthNewHandler(authUsecase)
+	userUsecase := usecase.UserNewUsecase(userRepo, doctorUsecase, patientUsecase, outboxRepo)
+
+	// Initialize Audit dependencies
+	auditLogRepo := repository.AuditLogNewRepository(db)
+	auditUsecase := usecase.AuditNewUsecase(auditLogRepo)
+	auditHandler := handlers.AuditNewHandler(auditUsecase)
+
+	// Initialize Role dependencies
+	roleRepo := repository.RoleNewRepository(db)
+	roleUsecase := usecase.RoleNewUsecase(roleRepo, userRepo, auditUsecase)
+	roleHandler := handlers.RoleNewHandler(roleUsecase)
+	if err := roleUsecase.SeedDefaults(); err != nil {
+		log.Fatalf("Failed to seed roles and permissions: %v", err)
+	}
 
 	// Initialize Email dependencies
 	emailRepo := repository.EmailNewRepository(db)
-	emailUsecase := usecase.EmailNewUsecase(emailRepo)
+	emailTemplateRepo := repository.EmailTemplateNewRepository(db)
+	emailTemplateUsecase := usecase.EmailTemplateNewUsecase(emailTemplateRepo, auditUsecase)
+	emailSuppressionRepo := repository.EmailSuppressionNewRepository(db)
+	emailTrackingUsecase := usecase.EmailTrackingNewUsecase(emailRepo, emailSuppressionRepo, auditUsecase)
+	emailUsecase := usecase.EmailNewUsecase(emailRepo, outboxRepo, emailTemplateUsecase, emailTrackingUsecase, auditUsecase)
+	emailTemplateHandler := handlers.EmailTemplateNewHandler(emailTemplateUsecase, emailUsecase)
+	emailHandler := handlers.EmailNewHandler(emailUsecase, emailTrackingUsecase)
+
+	// Initialize Notification dependencies
+	smsSender, err := sms.NewFromConfig()
+	if err != nil {
+		log.Fatalf("Failed to create SMS sender: %v", err)
+	}
+	notificationRepo := repository.NotificationNewRepository(db)
+	notificationHub := realtime.NewHub()
+	notificationHub.Connect(ctx, mq) // reach streams open on other instances
+	notificationUsecase := usecase.NotificationNewUsecase(notificationRepo, userRepo, outboxRepo, emailUsecase, emailTemplateUsecase, smsSender, notificationHub)
+	// Notifications are retried on the same schedule as emails
+	notificationRetryPolicy, err := queue.ParseRetryPolicy(config.ENV.EmailMaxAttempts, config.ENV.EmailRetryDelay)
+	if err != nil {
+		log.Fatalf("Invalid notification retry configuration: %v", err)
+	}
+	go queue.StartNotificationConsumer(ctx, mq, queue.NotificationQueue, notificationRetryPolicy, notificationUsecase.Deliver)
+	notificationHandler := handlers.NotificationNewHandler(notificationUsecase)
+
+	// Initialize Auth dependencies
+	twoFactorRepo := repository.TwoFactorNewRepository(db)
+	twoFactorUsecase := usecase.TwoFactorNewUsecase(twoFactorRepo, userRepo, roleUsecase, auditUsecase)
+	loginThrottleRepo := repository.LoginThrottleNewRepository(db)
+	loginThrottleUsecase := usecase.LoginThrottleNewUsecase(loginThrottleRepo, userRepo, notificationUsecase, auditUsecase)
+	authUsecase := usecase.AuthNewUsecase(userRepo, twoFactorUsecase, roleUsecase, loginThrottleUsecase, auditUsecase)
+	authHandler := handlers.AuthNewHandler(authUsecase, loginThrottleUsecase)
+	twoFactorHandler := handlers.TwoFactorNewHandler(twoFactorUsecase, authUsecase)
 
 	// Initialize OTP dependencies
 	otpRepo := repository.OtpNewRepository(db)
-	otpUsecase := usecase.OtpNewUsecase(otpRepo, emailUsecase, userUsecase, publisher)
+	otpUsecase := usecase.OtpNewUsecase(otpRepo, outboxRepo, notificationUsecase, userUsecase)
 
-	userHandler := handlers.UserNewHandler(userUsecase, otpUsecase, emailUsecase, publisher, cloudinaryUploader)
+	userHandler := handlers.UserNewHandler(userUsecase, otpUsecase, emailUsecase, roleUsecase, store)
 	otpHandler := handlers.OtpNewHandler(otpUsecase)
 
 	// Initialize Image dependencies
 	imageRepo := repository.ImageNewRepository(db)
-	imageUsecase := usecase.ImageNewUsecase(imageRepo, cloudinaryUploader)
+	imageUsecase := usecase.ImageNewUsecase(imageRepo, store, outboxRepo, auditUsecase)
 	imageHandler := handlers.ImageNewHandler(imageUsecase)
 
+	// Initialize Document dependencies
+	documentRepo := repository.DocumentNewRepository(db)
+	documentUsecase := usecase.DocumentNewUsecase(documentRepo, imageRepo, userRepo, store, auditUsecase)
+	documentHandler := handlers.DocumentNewHandler(documentUsecase)
+
+	// Initialize resumable upload dependencies
+	uploadSessionRepo := repository.UploadSessionNewRepository(db)
+	uploadUsecase := usecase.UploadNewUsecase(uploadSessionRepo, roleUsecase, imageUsecase, documentUsecase)
+	uploadHandler := handlers.UploadNewHandler(uploadUsecase)
+
 	// Initialize Room dependencies
 	roomRepo := repository.RoomNewRepository(db)
 	roomUsecase := usecase.RoomNewUsecase(roomRepo)
-	roomHandler := handlers.RoomNewHandler(roomUsecase, cloudinaryUploader)
+	roomHandler := handlers.RoomNewHandler(roomUsecase, store)
 
 	// Initialize Service dependencies
 	serviceRepo := repository.ServiceNewRepository(db)
@@ -68,23 +134,68 @@ func SetupRoutes(r chi.Router, db *gorm.DB, cloudinaryUploader *helpers.Cloudina
 
 	// Initialize Booking dependencies
 	bookingRepo := repository.BookingNewRepository(db)
-	bookingUsecase := usecase.BookingNewUsecase(bookingRepo, patientRepo, roomRepo, serviceRepo)
+	bookingReminderRepo := repository.BookingReminderNewRepository(db)
+	reminderUsecase, err := usecase.ReminderNewUsecase(bookingReminderRepo, bookingRepo, userRepo, notificationUsecase)
+	if err != nil {
+		log.Fatalf("Invalid reminder configuration: %v", err)
+	}
+	go reminderUsecase.Start(ctx)
+	bookingUsecase := usecase.BookingNewUsecase(bookingRepo, patientRepo, roomRepo, serviceRepo, outboxRepo, auditUsecase, notificationUsecase)
 	bookingHandler := handlers.BookingNewHandler(bookingUsecase)
 
 	//Initialize Payment dependencies
 	paymentRepo := repository.PaymentNewRepository(db)
-	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, bookingRepo)
+	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, bookingRepo, userRepo, outboxRepo, auditUsecase, notificationUsecase)
 	paymentHandler := handlers.NewPaymentHandler(paymentUsecase)
 
+	// Initialize Lab dependencies
+	labRepo := repository.LabNewRepository(db)
+	labUsecase := usecase.LabNewUsecase(labRepo, userRepo, notificationUsecase, auditUsecase)
+	labHandler := handlers.LabNewHandler(labUsecase)
+
+	fileHandler := handlers.FileNewHandler(store, signer)
+
+	// Initialize orphaned file collection; scheduled runs are off unless STORAGE_GC_INTERVAL is set
+	storageGCRepo := repository.StorageGCNewRepository(db)
+	storageGCUsecase, err := usecase.StorageGCNewUsecase(storageGCRepo, store, auditUsecase)
+	if err != nil {
+		log.Fatalf("Invalid storage GC configuration: %v", err)
+	}
+	storageGCHandler := handlers.StorageGCNewHandler(storageGCUsecase)
+	go storageGCUsecase.Schedule(ctx)
+
+	// Internal subscribers to domain events, each on its own queue
+	eventRetryPolicy, err := queue.ParseRetryPolicy(config.ENV.EventMaxAttempts, config.ENV.EventRetryDelay)
+	if err != nil {
+		log.Fatalf("Invalid event retry configuration: %v", err)
+	}
+	reminderSubscriber := events.NewSubscriber("reminders", eventRetryPolicy)
+	reminderUsecase.Subscribe(reminderSubscriber)
+	reminderSubscriber.Run(ctx, mq)
+
+	// Publish committed outbox messages once subscribers have bound their
+	// queues, so no event is routed nowhere
+	go outboxRelay.Run(ctx)
+
 	// Register routes
-	RegisterUserRoutes(r, userHandler, userUsecase)
+	RegisterUserRoutes(r, userHandler, userUsecase, roleUsecase)
 	RegisterOtpRoutes(r, otpHandler, otpUsecase)
-	RegisterImageRoutes(r, imageHandler, userUsecase)
-	RegisterRoomRoutes(r, roomHandler, userUsecase)
-	RegisterAuthRoutes(r, authHandler, userUsecase)
-	RegisterServiceRoutes(r, serviceHandler, userUsecase)
-	RegisterBookingRoutes(r, bookingHandler, userUsecase)
-	RegisterPaymentRoutes(r, paymentHandler, userUsecase)
+	RegisterImageRoutes(r, imageHandler, userUsecase, roleUsecase)
+	RegisterDocumentRoutes(r, documentHandler, userUsecase, roleUsecase)
+	RegisterUploadRoutes(r, uploadHandler, userUsecase)
+	RegisterRoomRoutes(r, roomHandler, userUsecase, roleUsecase)
+	RegisterAuthRoutes(r, authHandler, twoFactorHandler, userUsecase, roleUsecase)
+	RegisterServiceRoutes(r, serviceHandler, userUsecase, roleUsecase)
+	RegisterBookingRoutes(r, bookingHandler, userUsecase, roleUsecase)
+	RegisterPaymentRoutes(r, paymentHandler, userUsecase, roleUsecase)
+	RegisterLabRoutes(r, labHandler, userUsecase, roleUsecase)
+	RegisterRoleRoutes(r, roleHandler, userUsecase, roleUsecase)
+	RegisterAuditRoutes(r, auditHandler, userUsecase, roleUsecase)
+	RegisterFileRoutes(r, fileHandler)
+	RegisterStorageGCRoutes(r, storageGCHandler, userUsecase, roleUsecase)
+	RegisterEmailTemplateRoutes(r, emailTemplateHandler, userUsecase, roleUsecase)
+	RegisterEmailRoutes(r, emailHandler, userUsecase, roleUsecase)
+	RegisterNotificationRoutes(r, notificationHandler, userUsecase)
 	// doctor.RegisterRoutes(r, doctorHandler, doctorUsecase)
 
 }
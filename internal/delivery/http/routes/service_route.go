@@ -18,7 +18,7 @@ const (
 	DeleteServiceRoute  = "/delete/{id}"
 )
 
-func RegisterServiceRoutes(r chi.Router, handler *handlers.ServiceHandler, userUC usecase.UserUsecase) {
+func RegisterServiceRoutes(r chi.Router, handler *handlers.ServiceHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const serviceRoutePrefix = "/services"
 
 	r.Route(serviceRoutePrefix, func(r chi.Router) {
@@ -26,11 +26,8 @@ func RegisterServiceRoutes(r chi.Router, handler *handlers.ServiceHandler, userU
 		r.Get(getAllServicesRoute, handler.GetAll)
 		r.Get(getServiceByIDRoute, handler.GetByID)
 
-		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{models.RoleAdmin}))
-			r.Post(CreateServiceRoute, handler.Create)
-			r.Patch(UpdateServiceRoute, handler.Update)
-			r.Delete(DeleteServiceRoute, handler.Delete)
-		})
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceServices, models.ActionCreate)).Post(CreateServiceRoute, handler.Create)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceServices, models.ActionUpdate)).Patch(UpdateServiceRoute, handler.Update)
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceServices, models.ActionDelete)).Delete(DeleteServiceRoute, handler.Delete)
 	})
 }
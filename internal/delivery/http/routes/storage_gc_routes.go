@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	runStorageGCRoute = "/gc"
+)
+
+func RegisterStorageGCRoutes(r chi.Router, handler *handlers.StorageGCHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
+	const prefix = "/storage"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.With(middlewares.RequirePermission(userUC, roleUC, models.ResourceStorage, models.ActionDelete)).Post(runStorageGCRoute, handler.Run)
+	})
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+	"hospital_management_system/internal/infra/middlewares"
+	"hospital_management_system/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	initUploadRoute     = "/init"
+	uploadStatusRoute   = "/{id}"
+	putChunkRoute       = "/{id}/chunks/{index}"
+	completeUploadRoute = "/{id}/complete"
+	abortUploadRoute    = "/{id}"
+)
+
+// RegisterUploadRoutes mounts the resumable upload API. The images/documents
+// create permission is checked when a session starts, depending on its target;
+// later calls only need the session's owner.
+func RegisterUploadRoutes(r chi.Router, handler *handlers.UploadHandler, userUC usecase.UserUsecase) {
+	const prefix = "/uploads"
+
+	r.Route(prefix, func(r chi.Router) {
+		r.Use(middlewares.Authenticated(userUC))
+
+		r.Post(initUploadRoute, handler.Init)
+		r.Get(uploadStatusRoute, handler.Status)
+		r.Put(putChunkRoute, handler.PutChunk)
+		r.Post(completeUploadRoute, handler.Complete)
+		r.Delete(abortUploadRoute, handler.Abort)
+	})
+}
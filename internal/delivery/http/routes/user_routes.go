@@ -14,28 +14,35 @@ const (
 	registerPatientRoute = registerRoute + "/patient"
 	registerAdminRoute = registerRoute + "/admin"
 	registerDoctorRoute = registerRoute + "/doctor"
+	registerStaffRoute = registerRoute + "/staff"
 	profileRoute  = "/profile"
 )
 
-func RegisterUserRoutes(r chi.Router, handler *handlers.UserHandler, userUC usecase.UserUsecase) {
+func RegisterUserRoutes(r chi.Router, handler *handlers.UserHandler, userUC usecase.UserUsecase, roleUC usecase.RoleUsecase) {
 	const userRoutePrefix = "/users"
 
 	r.Route(userRoutePrefix, func(r chi.Router) {
 		// Public routes
-		r.Post(registerPatientRoute, handler.Register) // patient registration and general
+		r.Post(registerPatientRoute, handler.RegisterPatient)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			// Any authenticated user can access profile
-			r.Use(middlewares.Auth(userUC, []string{models.RoleAdmin, models.RoleDoctor, models.RolePatient}))
+			r.Use(middlewares.Authenticated(userUC))
 			r.Get(profileRoute, handler.GetProfile)
 		})
 
-		// Admin-only routes for creating admin/doctor accounts
+		// Routes for creating doctor/staff accounts
 		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(userUC, []string{models.RoleAdmin}))
-			r.Post(registerAdminRoute, handler.Register)
-			r.Post(registerDoctorRoute, handler.Register)
+			r.Use(middlewares.RequirePermission(userUC, roleUC, models.ResourceUsers, models.ActionCreate))
+			r.Post(registerDoctorRoute, handler.RegisterDoctor)
+			r.Post(registerStaffRoute, handler.RegisterStaff)
 		})
+
+		// Creating an admin also needs the right to edit roles
+		r.With(
+			middlewares.RequirePermission(userUC, roleUC, models.ResourceUsers, models.ActionCreate),
+			middlewares.RequirePermission(userUC, roleUC, models.ResourceRoles, models.ActionUpdate),
+		).Post(registerAdminRoute, handler.RegisterAdmin)
 	})
 }
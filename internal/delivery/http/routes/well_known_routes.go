@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"hospital_management_system/internal/delivery/http/handlers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	jwksRoute = "/.well-known/jwks.json"
+)
+
+// RegisterWellKnownRoutes mounts discovery endpoints at the server root
+func RegisterWellKnownRoutes(r chi.Router, jwksHandler *handlers.JWKSHandler) {
+	r.Get(jwksRoute, jwksHandler.Get)
+}
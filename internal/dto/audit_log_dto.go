@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+// AuditLogFilter narrows an audit log search; zero values are ignored
+type AuditLogFilter struct {
+	ActorID      string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	From         *time.Time
+	To           *time.Time
+	Page         int
+	PageSize     int
+}
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentUploadRequest is sent as JSON in the "data" form field next to the file
+type DocumentUploadRequest struct {
+	PatientID   string   `json:"patient_id"` // defaults to the caller for patients
+	Title       string   `json:"title" validate:"required"`
+	Category    string   `json:"category" validate:"required"` // lab_report, radiology, discharge_summary, identity
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+type DocumentTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type DocumentAccessRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// DocumentFilter narrows a patient's document list. When VisibleTo is set only
+// documents shared with that user (or uploaded by them) are returned.
+type DocumentFilter struct {
+	PatientID uuid.UUID
+	Category  string
+	Tag       string
+	VisibleTo *uuid.UUID
+	Page      int
+	PageSize  int
+}
+
+type DocumentDownloadResponse struct {
+	URL       string    `json:"url"`
+	Version   int       `json:"version"`
+	FileName  string    `json:"file_name"`
+	FileType  string    `json:"file_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailRequeueResponse struct {
+	Requeued int         `json:"requeued"`
+	Failed   []uuid.UUID `json:"failed,omitempty"` // could not be requeued
+}
+
+// EmailFilter narrows the email log; zero values are ignored. From and To
+// bound the creation time.
+type EmailFilter struct {
+	UserID   string
+	Email    string
+	Type     string
+	Status   string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+// EmailSuppressionFilter narrows the suppression list; zero values are ignored
+type EmailSuppressionFilter struct {
+	Email    string
+	Reason   string
+	Page     int
+	PageSize int
+}
+
+// EmailProviderEvent is one entry of a provider's event webhook, in the
+// SendGrid event format. EmailID comes back from the custom arguments set
+// when the email was sent.
+type EmailProviderEvent struct {
+	Email   string `json:"email"`
+	Event   string `json:"event"` // bounce, dropped, spamreport, unsubscribe, open, ...
+	Type    string `json:"type"`  // for bounces: bounce (hard) or blocked (soft)
+	Reason  string `json:"reason"`
+	Status  string `json:"status"` // enhanced status code, e.g. 5.1.1
+	EmailID string `json:"email_id"`
+}
+
+// EmailTrackingResult sums up what a bounce report or webhook changed
+type EmailTrackingResult struct {
+	Bounced    int `json:"bounced"`
+	Suppressed int `json:"suppressed"`
+	Opened     int `json:"opened"`
+	Ignored    int `json:"ignored"` // soft bounces, unknown events and the like
+}
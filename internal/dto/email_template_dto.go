@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// EmailTemplateRequest saves a new version of an email type's template
+type EmailTemplateRequest struct {
+	Subject  string `json:"subject" validate:"required"`
+	HTMLBody string `json:"html_body" validate:"required"`
+	TextBody string `json:"text_body"`
+	Note     string `json:"note"`
+	Activate bool   `json:"activate"` // make the new version live immediately
+}
+
+// EmailTemplatePreviewRequest renders a template without sending it. The
+// template is taken from the request body when Subject or HTMLBody is set,
+// otherwise from Version, otherwise whatever is currently live. Data
+// overrides the sample values.
+type EmailTemplatePreviewRequest struct {
+	Subject  string            `json:"subject"`
+	HTMLBody string            `json:"html_body"`
+	TextBody string            `json:"text_body"`
+	Version  int               `json:"version"`
+	Data     map[string]string `json:"data"`
+}
+
+// EmailTemplateTestRequest sends a rendered preview to To, which defaults
+// to the caller's own address
+type EmailTemplateTestRequest struct {
+	EmailTemplatePreviewRequest
+	To string `json:"to"`
+}
+
+// RenderedEmail is a template filled in with data
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// EmailTemplateSummary describes what is live for one email type
+type EmailTemplateSummary struct {
+	Type          string     `json:"type"`
+	Source        string     `json:"source"`         // "database" or "default"
+	ActiveVersion int        `json:"active_version"` // 0 when the bundled default is used
+	LatestVersion int        `json:"latest_version"`
+	Subject       string     `json:"subject"`
+	Variables     []string   `json:"variables"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
@@ -1,10 +1,20 @@
 // internal/dto/image_dto.go
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // ImageUploadRequest represents image upload request
 type ImageUploadRequest struct {
 	UserID    uuid.UUID `json:"user_id" validate:"required"`
 	ImageType string    `json:"image_type"` // profile, document, general
-}
\ No newline at end of file
+}
+// ImageURLResponse is a short-lived link to an image file
+type ImageURLResponse struct {
+	URL       string    `json:"url"`
+	Variant   string    `json:"variant,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
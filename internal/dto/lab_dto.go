@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LabTestParameterRequest struct {
+	ID            *uuid.UUID `json:"id,omitempty"` // on update, the parameter being edited; omitted for a new one
+	Name          string     `json:"name" validate:"required"`
+	Unit          string     `json:"unit,omitempty"`
+	ValueType     string     `json:"value_type"` // numeric (default) or text
+	RefLow        *float64   `json:"ref_low,omitempty"`
+	RefHigh       *float64   `json:"ref_high,omitempty"`
+	CriticalLow   *float64   `json:"critical_low,omitempty"`
+	CriticalHigh  *float64   `json:"critical_high,omitempty"`
+	ReferenceText string     `json:"reference_text,omitempty"` // expected value of a text parameter
+}
+
+type CreateLabTestRequest struct {
+	Code        string                    `json:"code" validate:"required"`
+	Name        string                    `json:"name" validate:"required"`
+	SampleType  string                    `json:"sample_type" validate:"required"`
+	Price       float64                   `json:"price" validate:"gte=0"`
+	Description string                    `json:"description,omitempty"`
+	Parameters  []LabTestParameterRequest `json:"parameters" validate:"required"`
+}
+
+// UpdateLabTestRequest changes the fields that are set. Parameters, when
+// given, are the test's full list: entries with an id edit that parameter in
+// place, entries without one are added and parameters left out are removed.
+// They cannot change while the test has open orders.
+type UpdateLabTestRequest struct {
+	Name        *string                   `json:"name,omitempty"`
+	SampleType  *string                   `json:"sample_type,omitempty"`
+	Price       *float64                  `json:"price,omitempty"`
+	Description *string                   `json:"description,omitempty"`
+	IsActive    *bool                     `json:"is_active,omitempty"`
+	Parameters  []LabTestParameterRequest `json:"parameters,omitempty"`
+}
+
+// LabTestFilter narrows the catalogue; Search matches code or name
+type LabTestFilter struct {
+	Search     string
+	ActiveOnly bool
+	Page       int
+	PageSize   int
+}
+
+type CreateLabOrderRequest struct {
+	PatientID     string   `json:"patient_id" validate:"required"`
+	TestIDs       []string `json:"test_ids" validate:"required"`
+	Priority      string   `json:"priority"` // routine (default), urgent or stat
+	ClinicalNotes string   `json:"clinical_notes,omitempty"`
+}
+
+// LabOrderFilter narrows the order list; zero values are ignored. From and
+// To bound the order time. When VisibleTo is set only orders that user may
+// see are returned: those of patients they have ordered tests for, and
+// those shared with them.
+type LabOrderFilter struct {
+	PatientID string
+	OrderedBy string
+	Status    string
+	Priority  string
+	From      *time.Time
+	To        *time.Time
+	VisibleTo *uuid.UUID
+	Page      int
+	PageSize  int
+}
+
+type LabOrderAccessRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+type CancelLabOrderRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type CollectLabSampleRequest struct {
+	SampleType string `json:"sample_type" validate:"required"`
+	Barcode    string `json:"barcode,omitempty"` // generated when empty
+	Notes      string `json:"notes,omitempty"`
+}
+
+type LabResultEntry struct {
+	ParameterID uuid.UUID `json:"parameter_id" validate:"required"`
+	Value       string    `json:"value" validate:"required"`
+}
+
+// EnterLabResultsRequest records results for some or all parameters of one
+// test. Entering a parameter again corrects it, until the order is released.
+type EnterLabResultsRequest struct {
+	Results []LabResultEntry `json:"results" validate:"required"`
+	Notes   *string          `json:"notes,omitempty"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "github.com/google/uuid"
+
+// NotificationFilter pages through a user's inbox
+type NotificationFilter struct {
+	UserID     uuid.UUID
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+}
+
+type NotificationUnreadResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// NotificationPreferenceItem switches one channel for one event; use "*" as
+// the event to change the channel for every event
+type NotificationPreferenceItem struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"` // email, sms or in_app
+	Enabled bool   `json:"enabled"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceItem `json:"preferences"`
+}
+
+// NotificationChannelState is the effective setting of one channel
+type NotificationChannelState struct {
+	Channel  string `json:"channel"`
+	Enabled  bool   `json:"enabled"`
+	Required bool   `json:"required"` // cannot be switched off
+}
+
+type NotificationEventPreferences struct {
+	Event    string                     `json:"event"`
+	Channels []NotificationChannelState `json:"channels"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description,omitempty"`
+	Require2FA  bool     `json:"require_two_factor"`
+	Permissions []string `json:"permissions"` // "resource:action"
+}
+
+type UpdateRoleRequest struct {
+	Description *string   `json:"description,omitempty"`
+	Require2FA  *bool     `json:"require_two_factor,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"` // replaces the whole set when present
+}
+
+type CreatePermissionRequest struct {
+	Resource    string `json:"resource" validate:"required"`
+	Action      string `json:"action" validate:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+// StorageGCReport is the outcome of one orphaned-file sweep
+type StorageGCReport struct {
+	DryRun     bool              `json:"dry_run"`
+	MinAge     string            `json:"min_age"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Folders    []StorageGCFolder `json:"folders"`
+	// UnresolvedURLs are saved links that do not point into our storage
+	// (e.g. an external avatar or a different Cloudinary account)
+	UnresolvedURLs []string `json:"unresolved_urls,omitempty"`
+	// ForcedDryRun is set when deletion was asked for but refused because
+	// some URLs could not be resolved; fix or clear them and sweep again
+	ForcedDryRun bool `json:"forced_dry_run,omitempty"`
+}
+
+type StorageGCFolder struct {
+	Folder     string            `json:"folder"`
+	Scanned    int               `json:"scanned"`
+	Referenced int               `json:"referenced"`
+	TooRecent  int               `json:"too_recent"` // unreferenced but younger than min_age
+	Orphans    []StorageGCObject `json:"orphans"`
+	Deleted    int               `json:"deleted"`
+	Failed     []string          `json:"failed,omitempty"`
+}
+
+type StorageGCObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UploadInitRequest starts a resumable upload. Target picks what the file
+// becomes once complete; the matching fields describe it.
+type UploadInitRequest struct {
+	Target    string `json:"target" validate:"required"` // image, document
+	FileName  string `json:"file_name" validate:"required"`
+	FileSize  int64  `json:"file_size" validate:"required"`
+	Checksum  string `json:"checksum" validate:"required"` // sha256 of the whole file, hex
+	ChunkSize int64  `json:"chunk_size"`                   // optional, bytes
+
+	// image
+	ImageType string `json:"image_type,omitempty"`
+
+	// document: either a new document or a new version of DocumentID
+	Document   *DocumentUploadRequest `json:"document,omitempty"`
+	DocumentID string                 `json:"document_id,omitempty"`
+	Note       string                 `json:"note,omitempty"`
+}
+
+// UploadSessionResponse reports progress so a client can resume
+type UploadSessionResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	Target         string     `json:"target"`
+	FileName       string     `json:"file_name"`
+	FileSize       int64      `json:"file_size"`
+	ChunkSize      int64      `json:"chunk_size"`
+	TotalChunks    int        `json:"total_chunks"`
+	ReceivedChunks []int      `json:"received_chunks"`
+	Status         string     `json:"status"`
+	ResultID       *uuid.UUID `json:"result_id,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+}
@@ -15,7 +15,7 @@ type RegisterRequest struct {
 	Email    string                  `json:"email"`
 	Phone    string                  `json:"phone"`
 	Password string                  `json:"password"`
-	Role     string                  `json:"role"` // doctor, patient, admin
+	Role     string                  `json:"role"` // only read on staff registration; other routes set it
 	Doctor   *DoctorCreateRequest   `json:"doctor,omitempty"`
 	Patient  *PatientCreateRequest `json:"patient,omitempty"` // new field
 }
@@ -27,3 +27,11 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+
+// LoginResponse carries either an access token or a pending two-factor step
+type LoginResponse struct {
+	Token                  string `json:"token,omitempty"`
+	TwoFactorRequired      bool   `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+	ChallengeToken         string `json:"challenge_token,omitempty"`
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeBookingCreated       = "booking.created"
+	TypeBookingStatusChanged = "booking.status_changed"
+	TypeBookingDeleted       = "booking.deleted"
+)
+
+type BookingCreated struct {
+	BookingID    uuid.UUID  `json:"booking_id"`
+	PatientID    uuid.UUID  `json:"patient_id"` // the patient's user ID
+	BookingType  string     `json:"booking_type"`
+	Status       string     `json:"status"`
+	RoomID       *uuid.UUID `json:"room_id,omitempty"`
+	CheckInDate  *time.Time `json:"check_in_date,omitempty"`
+	CheckOutDate *time.Time `json:"check_out_date,omitempty"`
+	ServiceID    *uuid.UUID `json:"service_id,omitempty"`
+	ScheduledAt  *time.Time `json:"scheduled_at,omitempty"`
+	TotalPrice   *float64   `json:"total_price,omitempty"`
+}
+
+func (BookingCreated) EventType() string { return TypeBookingCreated }
+func (BookingCreated) EventVersion() int { return 1 }
+
+type BookingStatusChanged struct {
+	BookingID uuid.UUID `json:"booking_id"`
+	PatientID uuid.UUID `json:"patient_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	// Reason is what changed it, e.g. "payment" for a paid booking
+	Reason string `json:"reason,omitempty"`
+}
+
+func (BookingStatusChanged) EventType() string { return TypeBookingStatusChanged }
+func (BookingStatusChanged) EventVersion() int { return 1 }
+
+type BookingDeleted struct {
+	BookingID uuid.UUID `json:"booking_id"`
+}
+
+func (BookingDeleted) EventType() string { return TypeBookingDeleted }
+func (BookingDeleted) EventVersion() int { return 1 }
@@ -0,0 +1,84 @@
+// Package events defines the versioned domain events other parts of the
+// hospital can react to. Events are written to the outbox in the same
+// transaction as the change they describe and published on Exchange.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Exchange is the topic exchange domain events are published on. Routing
+// keys are "<type>.v<version>", e.g. "booking.created.v1", so a subscriber
+// can bind to one version of one event or to a pattern like "booking.#".
+const Exchange = "hms.events"
+
+// Event is the payload of a domain event. A breaking change to a payload
+// ships as a new version alongside the old one rather than in place.
+type Event interface {
+	EventType() string
+	EventVersion() int
+}
+
+// Envelope wraps every published event
+type Envelope struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// RoutingKey is the routing key events of typ and version are published with
+func RoutingKey(typ string, version int) string {
+	return fmt.Sprintf("%s.v%d", typ, version)
+}
+
+// NewOutboxMessage wraps e in an envelope ready to be added to the outbox
+func NewOutboxMessage(e Event) (*models.OutboxMessage, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	env := Envelope{
+		ID:         uuid.New(),
+		Type:       e.EventType(),
+		Version:    e.EventVersion(),
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+	msg, err := models.NewOutboxMessage(Exchange, RoutingKey(env.Type, env.Version), env.Type, env)
+	if err != nil {
+		return nil, err
+	}
+	msg.ID = env.ID // so the message ID consumers see matches the envelope
+	return msg, nil
+}
+
+// HandlerFunc handles one delivered event. Returning an error retries it;
+// wrap it with queue.Permanent to dead-letter the event instead.
+type HandlerFunc func(ctx context.Context, env Envelope) error
+
+// Registrar is implemented by subscribers that handlers register with
+type Registrar interface {
+	Handle(typ string, version int, h HandlerFunc)
+}
+
+// On registers a typed handler for E on r. The envelope's data is decoded
+// into E before handler runs; data that does not decode is not retried.
+func On[E Event](r Registrar, handler func(ctx context.Context, env Envelope, event E) error) {
+	var zero E
+	r.Handle(zero.EventType(), zero.EventVersion(), func(ctx context.Context, env Envelope) error {
+		var e E
+		if err := json.Unmarshal(env.Data, &e); err != nil {
+			return queue.Permanent(fmt.Errorf("decode %s v%d: %w", env.Type, env.Version, err))
+		}
+		return handler(ctx, env, e)
+	})
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypePaymentSucceeded = "payment.succeeded"
+	TypePaymentFailed    = "payment.failed"
+)
+
+type PaymentSucceeded struct {
+	PaymentID     uuid.UUID  `json:"payment_id"`
+	BookingID     uuid.UUID  `json:"booking_id"`
+	TranID        string     `json:"tran_id"`
+	Amount        float64    `json:"amount"`
+	Method        string     `json:"method,omitempty"`
+	TransactionAt *time.Time `json:"transaction_at,omitempty"`
+}
+
+func (PaymentSucceeded) EventType() string { return TypePaymentSucceeded }
+func (PaymentSucceeded) EventVersion() int { return 1 }
+
+type PaymentFailed struct {
+	PaymentID uuid.UUID `json:"payment_id"`
+	BookingID uuid.UUID `json:"booking_id"`
+	TranID    string    `json:"tran_id"`
+	Amount    float64   `json:"amount"`
+}
+
+func (PaymentFailed) EventType() string { return TypePaymentFailed }
+func (PaymentFailed) EventVersion() int { return 1 }
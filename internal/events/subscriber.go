@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"hospital_management_system/internal/infra/queue"
+)
+
+// Subscriber consumes domain events into its own queue, so every
+// subscriber gets a copy of each event it registered a handler for.
+// Failed handlers are retried with backoff, then dead-lettered.
+type Subscriber struct {
+	name     string
+	policy   queue.RetryPolicy
+	handlers map[string]HandlerFunc // by routing key
+}
+
+func NewSubscriber(name string, policy queue.RetryPolicy) *Subscriber {
+	return &Subscriber{name: name, policy: policy, handlers: make(map[string]HandlerFunc)}
+}
+
+// QueueName is the queue the subscriber's events are delivered to
+func (s *Subscriber) QueueName() string {
+	return Exchange + "." + s.name
+}
+
+// Handle registers h for one version of one event type. Register every
+// handler before Run.
+func (s *Subscriber) Handle(typ string, version int, h HandlerFunc) {
+	s.handlers[RoutingKey(typ, version)] = h
+}
+
+// Run binds the subscriber's queue, then handles events in the background
+// until ctx is done. The returned channel is closed once it has stopped.
+func (s *Subscriber) Run(ctx context.Context, q queue.Queue) <-chan struct{} {
+	cfg := queue.ConsumerConfig{Queue: s.QueueName(), Policy: s.policy}
+	for key := range s.handlers {
+		cfg.Bindings = append(cfg.Bindings, queue.Binding{Exchange: Exchange, Key: key})
+	}
+
+	log.Printf("Event subscriber %s running...", s.name)
+	return q.Consume(ctx, cfg, s.handle)
+}
+
+// handle dispatches by the envelope rather than the routing key, since
+// retried messages may come back under the queue's name
+func (s *Subscriber) handle(ctx context.Context, m queue.Message) error {
+	var env Envelope
+	if err := json.Unmarshal(m.Body, &env); err != nil {
+		return queue.Permanent(fmt.Errorf("decode envelope: %w", err))
+	}
+	h, ok := s.handlers[RoutingKey(env.Type, env.Version)]
+	if !ok {
+		return queue.Permanent(fmt.Errorf("no handler for %s v%d", env.Type, env.Version))
+	}
+	return h(ctx, env)
+}
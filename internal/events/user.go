@@ -0,0 +1,23 @@
+package events
+
+import "github.com/google/uuid"
+
+const (
+	TypeUserRegistered = "user.registered"
+	TypeUserVerified   = "user.verified"
+)
+
+type UserRegistered struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+}
+
+func (UserRegistered) EventType() string { return TypeUserRegistered }
+func (UserRegistered) EventVersion() int { return 1 }
+
+type UserVerified struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (UserVerified) EventType() string { return TypeUserVerified }
+func (UserVerified) EventVersion() int { return 1 }
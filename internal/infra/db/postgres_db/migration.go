@@ -23,11 +23,61 @@ func Migration(DB *gorm.DB) {
 		&models.OTP{},
 		&models.Email{},
 		&models.Image{},
+		&models.Permission{},
+		&models.Role{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.LoginThrottle{},
+		&models.AuditLog{},
+		&models.SigningKey{},
+		&models.PatientDocument{},
+		&models.DocumentVersion{},
+		&models.DocumentTag{},
+		&models.DocumentAccess{},
+		&models.UploadSession{},
+		&models.UploadChunk{},
+		&models.EmailTemplate{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.BookingReminder{},
+		&models.OutboxMessage{},
+		&models.EmailSuppression{},
+		&models.EmailAttachment{},
+		&models.LabTest{},
+		&models.LabTestParameter{},
+		&models.LabOrder{},
+		&models.LabOrderTest{},
+		&models.LabSample{},
+		&models.LabOrderAccess{},
+		&models.LabResult{},
 	)
 	if err != nil {
 		log.Fatalf("Auto migration failed: %v", err)
 		os.Exit(1)
 	}
 
+	if err := DB.Exec(auditLogAppendOnlySQL).Error; err != nil {
+		log.Fatalf("Failed to protect audit log: %v", err)
+	}
+
+	// Only one version of each email template may be live
+	if err := DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_email_template_active ON email_templates (type) WHERE is_active`).Error; err != nil {
+		log.Fatalf("Failed to index email templates: %v", err)
+	}
+
 	log.Println("Database migrated successfully")
 }
+
+// auditLogAppendOnlySQL rejects any UPDATE or DELETE on audit_logs at the database level
+const auditLogAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+`
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"hospital_management_system/internal/pkg/helpers"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File writes every email to an .eml file instead of sending it, for
+// local development. The files open in any mail client.
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+func (f *File) Send(ctx context.Context, job helpers.EmailJob) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000"), job.EmailID)
+	path := filepath.Join(f.dir, name)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := buildMessage(f.from, job).WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("Email to %s written to %s", job.To, path)
+	return nil
+}
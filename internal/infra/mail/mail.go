@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/pkg/helpers"
+	"io"
+	"strconv"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Drivers selectable through MAIL_DRIVER
+const (
+	DriverSMTP     = "smtp"
+	DriverSendGrid = "sendgrid"
+	DriverFile     = "file"
+)
+
+// Transport delivers an email through a mail server or provider
+type Transport interface {
+	Send(ctx context.Context, job helpers.EmailJob) error
+}
+
+// ErrRejected wraps failures the server or provider will repeat however
+// often the message is retried, such as an unknown mailbox
+var ErrRejected = errors.New("mail: message rejected")
+
+// NewFromConfig builds the transport selected by MAIL_DRIVER. Every
+// transport sends from the EMAIL address.
+func NewFromConfig() (Transport, error) {
+	switch config.ENV.MailDriver {
+	case DriverSMTP:
+		port, err := strconv.Atoi(config.ENV.EmailPort)
+		if err != nil || config.ENV.EmailHost == "" {
+			return nil, fmt.Errorf("the smtp mail driver needs EMAIL_HOST and a numeric EMAIL_PORT")
+		}
+		return NewSMTP(config.ENV.EmailHost, port, config.ENV.Email, config.ENV.EmailAppPassword, config.ENV.Email), nil
+	case DriverSendGrid:
+		if config.ENV.SendGridApiKey == "" {
+			return nil, fmt.Errorf("the sendgrid mail driver needs SENDGRID_API_KEY")
+		}
+		return NewSendGrid(config.ENV.SendGridApiKey, config.ENV.Email), nil
+	case DriverFile:
+		return NewFile(config.ENV.MailFileDir, config.ENV.Email)
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", config.ENV.MailDriver)
+	}
+}
+
+// buildMessage lays job out as a MIME message: the plain-text part first,
+// the HTML alternative, then any attachments
+func buildMessage(from string, job helpers.EmailJob) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", job.To)
+	m.SetHeader("Subject", job.Subject)
+	m.SetHeader(helpers.EmailIDHeader, job.EmailID.String())
+	for name, value := range job.Headers {
+		m.SetHeader(name, value)
+	}
+	m.SetBody("text/plain", PlainText(job))
+	m.AddAlternative("text/html", job.Body)
+
+	for _, a := range job.Attachments {
+		content := a.Content
+		m.Attach(a.FileName,
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(content)
+				return err
+			}),
+		)
+	}
+	return m
+}
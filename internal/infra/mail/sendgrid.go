@@ -0,0 +1,115 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hospital_management_system/internal/pkg/helpers"
+	"io"
+	"net/http"
+	"time"
+)
+
+const sendGridURL = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGrid sends through the SendGrid v3 HTTP API
+type SendGrid struct {
+	apiKey string
+	from   string
+	client *http.Client
+}
+
+func NewSendGrid(apiKey, from string) *SendGrid {
+	return &SendGrid{apiKey: apiKey, from: from, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"` // base64
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+}
+
+type sendGridPersonalization struct {
+	To         []sendGridAddress `json:"to"`
+	CustomArgs map[string]string `json:"custom_args,omitempty"`
+}
+
+type sendGridMessage struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+func (s *SendGrid) Send(ctx context.Context, job helpers.EmailJob) error {
+	headers := map[string]string{helpers.EmailIDHeader: job.EmailID.String()}
+	for name, value := range job.Headers {
+		headers[name] = value
+	}
+	msg := sendGridMessage{
+		Personalizations: []sendGridPersonalization{{
+			To: []sendGridAddress{{Email: job.To}},
+			// Echoed back in webhook events, which is how they find the email
+			CustomArgs: map[string]string{"email_id": job.EmailID.String()},
+		}},
+		From:    sendGridAddress{Email: s.from},
+		Subject: job.Subject,
+		// SendGrid requires text/plain before text/html
+		Content: []sendGridContent{
+			{Type: "text/plain", Value: PlainText(job)},
+			{Type: "text/html", Value: job.Body},
+		},
+		Headers: headers,
+	}
+	for _, a := range job.Attachments {
+		msg.Attachments = append(msg.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(a.Content),
+			Type:        a.ContentType,
+			Filename:    a.FileName,
+			Disposition: "attachment",
+		})
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendGridURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	err = fmt.Errorf("sendgrid: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	// A malformed or oversized message fails the same way every time;
+	// auth errors, throttling and outages may clear up
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return err
+}
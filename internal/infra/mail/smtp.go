@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/pkg/helpers"
+	"net/textproto"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTP sends through a mail server, one connection per email
+type SMTP struct {
+	dialer *gomail.Dialer
+	from   string
+}
+
+func NewSMTP(host string, port int, user, password, from string) *SMTP {
+	return &SMTP{dialer: gomail.NewDialer(host, port, user, password), from: from}
+}
+
+func (s *SMTP) Send(ctx context.Context, job helpers.EmailJob) error {
+	err := s.dialer.DialAndSend(buildMessage(s.from, job))
+	// 5xx replies are permanent; 4xx ones ask to try again later
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code < 600 {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return err
+}
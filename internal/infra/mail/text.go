@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"hospital_management_system/internal/pkg/helpers"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	invisibleElements = regexp.MustCompile(`(?is)<(head|style|script)\b.*?</(head|style|script)>`)
+	links             = regexp.MustCompile(`(?is)<a\b[^>]*\bhref\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+	lineBreaks        = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|tr|table|blockquote|ul|ol)>`)
+	listItems         = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	tags              = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaces            = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+	blankLines        = regexp.MustCompile(`\n{3,}`)
+)
+
+// PlainText is the plain-text alternative of job: its TextBody, or one
+// derived from the HTML body so every email has a text part
+func PlainText(job helpers.EmailJob) string {
+	if job.TextBody != "" {
+		return job.TextBody
+	}
+	return htmlToText(job.Body)
+}
+
+// htmlToText keeps the readable text of an HTML email, writing links out
+// as "text (url)"
+func htmlToText(s string) string {
+	s = invisibleElements.ReplaceAllString(s, "")
+	s = links.ReplaceAllStringFunc(s, func(a string) string {
+		m := links.FindStringSubmatch(a)
+		text := strings.TrimSpace(tags.ReplaceAllString(m[2], ""))
+		if text == "" || text == m[1] {
+			return m[1]
+		}
+		return text + " (" + m[1] + ")"
+	})
+	s = lineBreaks.ReplaceAllString(s, "\n")
+	s = listItems.ReplaceAllString(s, "\n- ")
+	s = tags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaces.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
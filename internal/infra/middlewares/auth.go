@@ -2,16 +2,16 @@ package middlewares
 
 import (
 	"context"
+	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
 	"hospital_management_system/internal/pkg/utils/jwt"
+	"hospital_management_system/internal/shared/constants"
 	"hospital_management_system/internal/usecase"
 	"net/http"
 	"time"
 )
 
-type contextKey string
-
-const UserContextKey = contextKey("user")
+const UserContextKey = constants.UserContextKey
 
 func Auth(userUC usecase.UserUsecase, roles []string) func(http.Handler) http.Handler {
 
@@ -22,49 +22,103 @@ func Auth(userUC usecase.UserUsecase, roles []string) func(http.Handler) http.Ha
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
-			jwtUser, err := jwt.GetUserDataFromReqJWT(r)
-			if err != nil {
-				helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("Unauthorized: insufficient role")))
+			user, ok := authenticate(w, r, userUC)
+			if !ok {
 				return
 			}
 
-			if time.Now().Unix() > jwtUser.Exp {
-						helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, ("UToken has expired")))
+			// Role check
+			if !allowedRoles[user.Role] {
+				helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("Unauthorized: insufficient role")))
 				return
 			}
 
-			user, err := userUC.FindByID(jwtUser.UserID)
-			if err != nil || user == nil {
-				helpers.Error(w, helpers.NewAppError(http.StatusNotFound, ("User not found")))
-				return
-			}
+			// Add user to context
+			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-			if user.IsBlocked {
-					helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("User is blocked")))
+// Authenticated only requires a valid, active user without any role or permission check
+func Authenticated(userUC usecase.UserUsecase) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := authenticate(w, r, userUC)
+			if !ok {
 				return
 			}
 
-			if !user.IsVerified {
-					helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("User is not verified")))
-			
+			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePermission allows the request only if the user's role grants action on resource
+func RequirePermission(userUC usecase.UserUsecase, roleUC usecase.RoleUsecase, resource, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := authenticate(w, r, userUC)
+			if !ok {
 				return
 			}
 
-			if user.IsDeleted {
-					helpers.Error(w, helpers.NewAppError(http.StatusNotFound, ("User not found")))
+			allowed, err := roleUC.HasPermission(user.Role, resource, action)
+			if err != nil {
+				helpers.Error(w, helpers.NewAppError(http.StatusInternalServerError, "Failed to check permissions"))
 				return
 			}
-
-			// Role check
-			if !allowedRoles[user.Role] {
-					helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("Unauthorized: insufficient role")))
+			if !allowed {
+				helpers.Error(w, helpers.NewAppError(http.StatusForbidden, "Unauthorized: missing permission "+resource+":"+action))
 				return
 			}
 
-			// Add user to context
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// authenticate validates the JWT and the account state, writing the error response on failure
+func authenticate(w http.ResponseWriter, r *http.Request, userUC usecase.UserUsecase) (*models.User, bool) {
+	jwtUser, err := jwt.GetUserDataFromReqJWT(r)
+	if err != nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("Unauthorized: insufficient role")))
+		return nil, false
+	}
+
+	if time.Now().Unix() > jwtUser.Exp {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, ("UToken has expired")))
+		return nil, false
+	}
+
+	// Scoped tokens (e.g. pending two-factor) are not access tokens
+	if jwtUser.Purpose != "" {
+		helpers.Error(w, helpers.NewAppError(http.StatusUnauthorized, "Two-factor authentication is not completed"))
+		return nil, false
+	}
+
+	user, err := userUC.FindByID(jwtUser.UserID)
+	if err != nil || user == nil {
+		helpers.Error(w, helpers.NewAppError(http.StatusNotFound, ("User not found")))
+		return nil, false
+	}
+
+	if user.IsBlocked {
+		helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("User is blocked")))
+		return nil, false
+	}
+
+	if !user.IsVerified {
+		helpers.Error(w, helpers.NewAppError(http.StatusForbidden, ("User is not verified")))
+		return nil, false
+	}
+
+	if user.IsDeleted {
+		helpers.Error(w, helpers.NewAppError(http.StatusNotFound, ("User not found")))
+		return nil, false
+	}
+
+	return user, true
+}
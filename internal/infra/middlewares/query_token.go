@@ -0,0 +1,20 @@
+package middlewares
+
+import "net/http"
+
+// AccessTokenParam is read by TokenFromQuery
+const AccessTokenParam = "access_token"
+
+// TokenFromQuery lets clients that cannot set headers, such as the browser
+// EventSource API, pass their JWT as ?access_token=. Only mount it on
+// streaming routes; query strings end up in proxy logs.
+func TokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if token := r.URL.Query().Get(AccessTokenParam); token != "" {
+				r.Header.Set("Authorization", token)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
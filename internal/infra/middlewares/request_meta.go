@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"hospital_management_system/internal/pkg/utils"
+	"net"
+	"net/http"
+)
+
+// RequestMeta records the client IP and user agent on the request context.
+// Forwarding headers are only believed from the trusted proxies.
+func RequestMeta(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := utils.WithRequestMeta(r.Context(), utils.RequestMeta{
+				IP:        utils.ClientIP(r, trustedProxies),
+				UserAgent: r.UserAgent(),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
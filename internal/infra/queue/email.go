@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"hospital_management_system/internal/infra/mail"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+)
+
+// EmailQueue carries EmailJobs from the API to the email worker
+const EmailQueue = "email_queue"
+
+// EmailJobType is the message type of an EmailJob
+const EmailJobType = "email.send"
+
+// emailPrefetch bounds the unacknowledged emails held by one consumer
+const emailPrefetch = 10
+
+// StartConsumer sends the emails on queueName until ctx is done. Failed
+// sends are retried with backoff per policy, then dead-lettered.
+// Deployments that need more throughput run the worker command instead.
+func StartConsumer(ctx context.Context, q Queue, queueName string, transport mail.Transport, emailRepo repository.EmailRepository, policy RetryPolicy) {
+	send := func(job helpers.EmailJob) error {
+		// Not ctx: an email already being sent is finished on shutdown
+		return transport.Send(context.Background(), job)
+	}
+
+	log.Println("Email worker running...")
+	NewEmailWorker(q, EmailWorkerConfig{
+		Queue:       queueName,
+		Concurrency: 1,
+		Prefetch:    emailPrefetch,
+		Policy:      policy,
+	}, send, emailRepo).Run(ctx)
+}
+
+// handleEmail sends the email in m and records the attempt. The returned
+// error tells the queue whether to retry it or dead-letter it.
+func handleEmail(m Message, policy RetryPolicy, send func(helpers.EmailJob) error, emailRepo repository.EmailRepository, metrics *emailMetrics) error {
+	var job helpers.EmailJob
+	if err := json.Unmarshal(m.Body, &job); err != nil {
+		// Retrying cannot fix a malformed message
+		metrics.deadLettered.Add(1)
+		return Permanent(err)
+	}
+
+	// A redelivery after a crash between sending and acking must not send twice
+	if email, err := emailRepo.FindByID(job.EmailID); err == nil && email.Status == models.EmailStatusSent {
+		metrics.duplicates.Add(1)
+		return nil
+	}
+
+	attempt := job.PriorAttempts + m.Attempt
+	sendErr := send(job)
+	if errors.Is(sendErr, ErrRequeue) {
+		return sendErr
+	}
+	if sendErr == nil {
+		if err := emailRepo.RecordAttempt(job.EmailID, attempt, models.EmailStatusSent, nil); err != nil {
+			log.Println("Failed to update email status:", err)
+		}
+		metrics.sent.Add(1)
+		return nil
+	}
+
+	errMsg := sendErr.Error()
+	status := models.EmailStatusRetrying
+	if m.Attempt >= policy.MaxAttempts || errors.Is(sendErr, mail.ErrRejected) {
+		status = models.EmailStatusFailed
+		sendErr = Permanent(sendErr)
+		metrics.deadLettered.Add(1)
+	} else {
+		metrics.retried.Add(1)
+	}
+	if err := emailRepo.RecordAttempt(job.EmailID, attempt, status, &errMsg); err != nil {
+		log.Println("Failed to update email status:", err)
+	}
+	return sendErr
+}
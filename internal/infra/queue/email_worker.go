@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/pkg/helpers"
+
+	"golang.org/x/time/rate"
+)
+
+// EmailWorkerConfig tunes an EmailWorker
+type EmailWorkerConfig struct {
+	Queue        string
+	Concurrency  int // consumers running at once
+	Prefetch     int // unacknowledged emails held by each consumer
+	Policy       RetryPolicy
+	DomainLimit  int // sends per DomainPeriod to one recipient domain; 0 is unlimited
+	DomainPeriod time.Duration
+}
+
+// EmailWorker sends the emails on a queue with several consumers at once,
+// keeping each recipient domain under its send rate
+type EmailWorker struct {
+	q         Queue
+	cfg       EmailWorkerConfig
+	send      func(helpers.EmailJob) error
+	emailRepo repository.EmailRepository
+	limiter   *domainLimiter
+	metrics   emailMetrics
+}
+
+func NewEmailWorker(q Queue, cfg EmailWorkerConfig, send func(helpers.EmailJob) error, emailRepo repository.EmailRepository) *EmailWorker {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	w := &EmailWorker{q: q, cfg: cfg, send: send, emailRepo: emailRepo}
+	if cfg.DomainLimit > 0 {
+		w.limiter = newDomainLimiter(cfg.DomainLimit, cfg.DomainPeriod)
+	}
+	return w
+}
+
+// Run consumes until ctx is done, then returns once every email being sent
+// has been settled. Emails not yet started stay on the queue.
+func (w *EmailWorker) Run(ctx context.Context) {
+	<-w.q.Consume(ctx, ConsumerConfig{
+		Queue:       w.cfg.Queue,
+		Concurrency: w.cfg.Concurrency,
+		Prefetch:    w.cfg.Prefetch,
+		Policy:      w.cfg.Policy,
+	}, func(ctx context.Context, m Message) error {
+		w.metrics.inFlight.Add(1)
+		defer w.metrics.inFlight.Add(-1)
+		return handleEmail(m, w.cfg.Policy, w.sendFunc(ctx), w.emailRepo, &w.metrics)
+	})
+}
+
+// sendFunc waits for the recipient domain's rate limit before sending
+func (w *EmailWorker) sendFunc(ctx context.Context) func(helpers.EmailJob) error {
+	if w.limiter == nil {
+		return w.send
+	}
+	return func(job helpers.EmailJob) error {
+		waited, err := w.limiter.wait(ctx, job.To)
+		if waited {
+			w.metrics.rateLimited.Add(1)
+		}
+		if err != nil {
+			return ErrRequeue // draining; not an attempt
+		}
+		return w.send(job)
+	}
+}
+
+// WriteMetrics writes the worker's counters in the Prometheus text format
+func (w *EmailWorker) WriteMetrics(out io.Writer) {
+	m := &w.metrics
+	for _, c := range []struct {
+		name, kind, help string
+		value            int64
+	}{
+		{"email_worker_sent_total", "counter", "Emails sent.", m.sent.Load()},
+		{"email_worker_retried_total", "counter", "Failed sends scheduled for a retry.", m.retried.Load()},
+		{"email_worker_dead_lettered_total", "counter", "Emails moved to the dead-letter queue.", m.deadLettered.Load()},
+		{"email_worker_duplicates_total", "counter", "Redelivered emails that had already been sent.", m.duplicates.Load()},
+		{"email_worker_rate_limited_total", "counter", "Sends held back by a recipient domain's rate limit.", m.rateLimited.Load()},
+		{"email_worker_in_flight", "gauge", "Emails being handled right now.", m.inFlight.Load()},
+		{"email_worker_consumers", "gauge", "Configured concurrent consumers.", int64(w.cfg.Concurrency)},
+	} {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", c.name, c.help, c.name, c.kind, c.name, c.value)
+	}
+}
+
+type emailMetrics struct {
+	sent, retried, deadLettered, duplicates, rateLimited, inFlight atomic.Int64
+}
+
+// ParseDomainRate reads a per-domain send rate such as "100/1m". "0" or
+// an empty string means no limit.
+func ParseDomainRate(s string) (int, time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, 0, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n < 1 {
+		return 0, 0, fmt.Errorf("domain rate must look like 100/1m, got %q", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("domain rate must look like 100/1m, got %q", s)
+	}
+	return n, d, nil
+}
+
+// domainLimiter keeps one token bucket per recipient domain, so a burst to
+// one provider cannot get the sender throttled or blocked by it
+type domainLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newDomainLimiter(n int, per time.Duration) *domainLimiter {
+	return &domainLimiter{
+		limit:    rate.Every(per / time.Duration(n)),
+		burst:    n,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// wait blocks until an email to addr may be sent. waited reports whether
+// the limit held it back at all.
+func (l *domainLimiter) wait(ctx context.Context, addr string) (waited bool, err error) {
+	domain := strings.ToLower(addr[strings.LastIndex(addr, "@")+1:])
+
+	l.mu.Lock()
+	lim, ok := l.limiters[domain]
+	if !ok {
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[domain] = lim
+	}
+	l.mu.Unlock()
+
+	if lim.Allow() {
+		return false, nil
+	}
+	return true, lim.Wait(ctx)
+}
@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// ImageQueue carries ImageJobs from the API to the image worker
+const ImageQueue = "image_queue"
+
+// ImageJobType is the message type of an ImageJob
+const ImageJobType = "image.process"
+
+// ImageJob asks the image worker to process a freshly uploaded image
+type ImageJob struct {
+	ImageID uuid.UUID `json:"image_id"`
+}
+
+// StartImageConsumer runs process for every ImageJob on queueName until
+// ctx is done. Failures are only logged; the original upload stays usable.
+func StartImageConsumer(ctx context.Context, q Queue, queueName string, process func(ctx context.Context, imageID uuid.UUID) error) {
+	log.Println("Image worker running...")
+	<-q.Consume(ctx, ConsumerConfig{Queue: queueName}, func(ctx context.Context, m Message) error {
+		var job ImageJob
+		if err := json.Unmarshal(m.Body, &job); err != nil {
+			log.Println("Failed to decode image job:", err)
+		} else if err := process(ctx, job.ImageID); err != nil {
+			log.Printf("Failed to process image %s: %v", job.ImageID, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is the in-process driver. Messages live in memory only, so
+// anything unconsumed is lost on restart; it is meant for development and
+// tests, where it replaces a broker entirely.
+type Memory struct {
+	mu       sync.Mutex
+	queues   map[string]*memoryQueue
+	bindings map[string][]memoryBinding // by exchange
+	closed   bool
+	done     chan struct{}
+}
+
+type memoryBinding struct {
+	key   string
+	queue *memoryQueue
+}
+
+type memoryQueue struct {
+	mu       sync.Mutex
+	messages []Message
+	ready    chan struct{} // signalled when messages are added
+}
+
+var _ Queue = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		queues:   make(map[string]*memoryQueue),
+		bindings: make(map[string][]memoryBinding),
+		done:     make(chan struct{}),
+	}
+}
+
+func (m *Memory) DeclareQueue(name string) error {
+	m.queue(name)
+	return nil
+}
+
+// DeclareExchange is a no-op: exchanges only exist through their bindings
+func (m *Memory) DeclareExchange(name string) error {
+	return nil
+}
+
+func (m *Memory) PublishBatch(msgs []Publishing) []error {
+	errs := make([]error, len(msgs))
+	for i, p := range msgs {
+		errs[i] = m.PublishMessage(p.Exchange, p.RoutingKey, p.MessageID, p.Type, p.Body)
+	}
+	return errs
+}
+
+func (m *Memory) PublishMessage(exchange, routingKey, messageID, msgType string, body []byte) error {
+	msg := Message{ID: messageID, Type: msgType, Body: body, Attempt: 1}
+
+	if exchange == "" {
+		m.queue(routingKey).push(msg, false)
+		return nil
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return errors.New("queue: closed")
+	}
+	var targets []*memoryQueue
+	for _, b := range m.bindings[exchange] {
+		if topicMatch(b.key, routingKey) {
+			targets = append(targets, b.queue)
+		}
+	}
+	m.mu.Unlock()
+
+	// Unrouted messages are dropped, as a broker would
+	for _, q := range targets {
+		q.push(msg, false)
+	}
+	return nil
+}
+
+func (m *Memory) Consume(ctx context.Context, cfg ConsumerConfig, handle Handler) <-chan struct{} {
+	q := m.queue(cfg.Queue)
+	m.mu.Lock()
+	for _, b := range cfg.Bindings {
+		m.bindings[b.Exchange] = append(m.bindings[b.Exchange], memoryBinding{key: b.Key, queue: q})
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < max(cfg.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				msg, ok := q.pop(ctx, m.done)
+				if !ok {
+					return
+				}
+				m.settle(q, cfg, msg, handle(ctx, msg))
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// settle mirrors the RabbitMQ driver: retries wait out their backoff and
+// dead-lettered messages are logged and dropped
+func (m *Memory) settle(q *memoryQueue, cfg ConsumerConfig, msg Message, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrRequeue):
+		q.push(msg, true)
+	case cfg.Policy.MaxAttempts == 0 || cfg.Transient:
+		log.Printf("Failed to handle message %s on %s: %v", msg.ID, cfg.Queue, err)
+	case IsPermanent(err) || msg.Attempt >= cfg.Policy.MaxAttempts:
+		log.Printf("Dead-lettering message %s on %s: %v", msg.ID, cfg.Queue, err)
+	default:
+		delay := cfg.Policy.Delay(msg.Attempt)
+		msg.Attempt++
+		time.AfterFunc(delay, func() { q.push(msg, false) })
+	}
+}
+
+// Connected is true until Close
+func (m *Memory) Connected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.closed
+}
+
+// Close stops every consumer; queued messages are discarded
+func (m *Memory) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.done)
+	}
+}
+
+// queue returns the named queue, creating it on first use
+func (m *Memory) queue(name string) *memoryQueue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.queues[name]
+	if !ok {
+		q = &memoryQueue{ready: make(chan struct{}, 1)}
+		m.queues[name] = q
+	}
+	return q
+}
+
+func (q *memoryQueue) push(msg Message, front bool) {
+	q.mu.Lock()
+	if front {
+		q.messages = append([]Message{msg}, q.messages...)
+	} else {
+		q.messages = append(q.messages, msg)
+	}
+	q.mu.Unlock()
+	q.signal()
+}
+
+// pop waits for the next message until ctx or closed is done
+func (q *memoryQueue) pop(ctx context.Context, closed <-chan struct{}) (Message, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.messages) > 0 {
+			msg := q.messages[0]
+			q.messages = q.messages[1:]
+			more := len(q.messages) > 0
+			q.mu.Unlock()
+			if more {
+				q.signal() // wake another consumer
+			}
+			return msg, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return Message{}, false
+		case <-closed:
+			return Message{}, false
+		}
+	}
+}
+
+func (q *memoryQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// topicMatch matches an AMQP topic binding key, where * stands for one
+// word and # for zero or more
+func topicMatch(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(key) == 0 || (pattern[0] != "*" && pattern[0] != key[0]) {
+		return false
+	}
+	return matchWords(pattern[1:], key[1:])
+}
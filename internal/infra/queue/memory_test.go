@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// settleWait is how long a test waits to be sure no further delivery comes
+const settleWait = 50 * time.Millisecond
+
+// consumeAttempts publishes one message to a fresh queue and records the
+// attempt number of every delivery, answering each with handle
+func consumeAttempts(t *testing.T, policy RetryPolicy, handle func(attempt int) error) <-chan int {
+	t.Helper()
+	m := NewMemory()
+	t.Cleanup(m.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	attempts := make(chan int, 16)
+	m.Consume(ctx, ConsumerConfig{Queue: "test", Concurrency: 1, Policy: policy}, func(ctx context.Context, msg Message) error {
+		attempts <- msg.Attempt
+		return handle(msg.Attempt)
+	})
+	if err := m.PublishMessage("", "test", "m1", "test", []byte("{}")); err != nil {
+		t.Fatalf("PublishMessage: %v", err)
+	}
+	return attempts
+}
+
+// collect reads deliveries until none arrives within settleWait
+func collect(attempts <-chan int) []int {
+	var got []int
+	for {
+		select {
+		case a := <-attempts:
+			got = append(got, a)
+		case <-time.After(settleWait):
+			return got
+		}
+	}
+}
+
+func TestMemorySettle(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	failure := errors.New("smtp down")
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		handle func(attempt int) error
+		want   []int
+	}{
+		{
+			name:   "success",
+			policy: policy,
+			handle: func(int) error { return nil },
+			want:   []int{1},
+		},
+		{
+			name:   "retried until it succeeds",
+			policy: policy,
+			handle: func(attempt int) error {
+				if attempt < 2 {
+					return failure
+				}
+				return nil
+			},
+			want: []int{1, 2},
+		},
+		{
+			name:   "dead-lettered after max attempts",
+			policy: policy,
+			handle: func(int) error { return failure },
+			want:   []int{1, 2, 3},
+		},
+		{
+			name:   "permanent dead-lettered at once",
+			policy: policy,
+			handle: func(int) error { return Permanent(failure) },
+			want:   []int{1},
+		},
+		{
+			name:   "wrapped permanent dead-lettered at once",
+			policy: policy,
+			handle: func(int) error { return errors.Join(errors.New("render"), Permanent(failure)) },
+			want:   []int{1},
+		},
+		{
+			name:   "no retry policy",
+			handle: func(int) error { return failure },
+			want:   []int{1},
+		},
+		{
+			name:   "requeue keeps the attempt",
+			policy: policy,
+			handle: func() func(int) error {
+				requeued := false
+				return func(int) error {
+					if !requeued {
+						requeued = true
+						return ErrRequeue
+					}
+					return nil
+				}
+			}(),
+			want: []int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collect(consumeAttempts(t, tt.policy, tt.handle))
+			if len(got) != len(tt.want) {
+				t.Fatalf("attempts %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("attempts %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryTopicRouting(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := make(chan string, 8)
+	m.Consume(ctx, ConsumerConfig{
+		Queue:    "appointments",
+		Bindings: []Binding{{Exchange: "events", Key: "appointment.*"}},
+	}, func(ctx context.Context, msg Message) error {
+		got <- msg.ID
+		return nil
+	})
+
+	errs := m.PublishBatch([]Publishing{
+		{Exchange: "events", RoutingKey: "appointment.booked", MessageID: "booked"},
+		{Exchange: "events", RoutingKey: "invoice.paid", MessageID: "paid"},
+		{Exchange: "events", RoutingKey: "appointment.cancelled", MessageID: "cancelled"},
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+	}
+
+	var ids []string
+	for {
+		select {
+		case id := <-got:
+			ids = append(ids, id)
+			continue
+		case <-time.After(settleWait):
+		}
+		break
+	}
+	if len(ids) != 2 || ids[0] != "booked" || ids[1] != "cancelled" {
+		t.Errorf("delivered %v, want [booked cancelled]", ids)
+	}
+}
+
+func TestTopicMatch(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"appointment.booked", "appointment.booked", true},
+		{"appointment.*", "appointment.booked", true},
+		{"appointment.*", "appointment.booked.late", false},
+		{"appointment.#", "appointment", true},
+		{"appointment.#", "appointment.booked.late", true},
+		{"#", "anything.at.all", true},
+		{"*.paid", "invoice.paid", true},
+		{"*.paid", "invoice.refunded", false},
+	}
+	for _, tt := range tests {
+		if got := topicMatch(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("topicMatch(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 20, BaseDelay: time.Minute}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{10, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := p.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
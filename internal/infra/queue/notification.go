@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// NotificationQueue carries NotificationJobs written through the outbox
+const NotificationQueue = "notification_queue"
+
+// NotificationJobType is the message type of a NotificationJob
+const NotificationJobType = "notification.send"
+
+// NotificationJob asks for a user to be notified of an event on their channels
+type NotificationJob struct {
+	// NotificationID is the row tracking delivery per channel; empty on
+	// jobs queued before deliveries were tracked
+	NotificationID uuid.UUID         `json:"notification_id,omitempty"`
+	UserID         uuid.UUID         `json:"user_id"`
+	Event          string            `json:"event"`
+	Data           map[string]string `json:"data"`
+}
+
+// StartNotificationConsumer runs deliver for every NotificationJob on
+// queueName until ctx is done. The notification row remembers which
+// channels have delivered, so failed jobs are retried under policy and only
+// repeat the channels that failed.
+func StartNotificationConsumer(ctx context.Context, q Queue, queueName string, policy RetryPolicy, deliver func(ctx context.Context, job NotificationJob) error) {
+	log.Println("Notification worker running...")
+	<-q.Consume(ctx, ConsumerConfig{Queue: queueName, Policy: policy}, func(ctx context.Context, m Message) error {
+		var job NotificationJob
+		if err := json.Unmarshal(m.Body, &job); err != nil {
+			return Permanent(fmt.Errorf("decode notification job: %w", err))
+		}
+		if err := deliver(ctx, job); err != nil {
+			log.Printf("Failed to deliver %s notification to %s: %v", job.Event, job.UserID, err)
+			return err
+		}
+		return nil
+	})
+}
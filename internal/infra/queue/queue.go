@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Drivers selectable through QUEUE_DRIVER
+const (
+	DriverRabbitMQ = "rabbitmq"
+	DriverMemory   = "memory"
+)
+
+// Queue carries messages from publishers to consumers. RabbitMQ is used in
+// deployments; the in-memory driver lets the app run in one process with
+// no broker.
+type Queue interface {
+	// DeclareQueue makes sure a queue exists, so messages sent to it are
+	// kept until its consumer starts
+	DeclareQueue(name string) error
+	// DeclareExchange makes sure a topic exchange exists
+	DeclareExchange(name string) error
+	// PublishMessage sends body to exchange with routingKey. The empty
+	// exchange delivers straight to the queue named routingKey.
+	PublishMessage(exchange, routingKey, messageID, msgType string, body []byte) error
+	// PublishBatch sends msgs in order and reports an error, or nil, for
+	// each. Drivers may have the whole batch in flight at once.
+	PublishBatch(msgs []Publishing) []error
+	// Consume declares cfg's queue and bindings, then runs handle for every
+	// message in the background until ctx is done. The returned channel is
+	// closed once every consumer has stopped.
+	Consume(ctx context.Context, cfg ConsumerConfig, handle Handler) <-chan struct{}
+	// Connected reports whether messages can flow right now
+	Connected() bool
+	Close()
+}
+
+// Publishing is one message of a batch, as given to PublishMessage
+type Publishing struct {
+	Exchange   string
+	RoutingKey string
+	MessageID  string
+	Type       string
+	Body       []byte
+}
+
+// ConsumerConfig describes one consumed queue
+type ConsumerConfig struct {
+	Queue       string
+	Concurrency int // consumers running at once; at least one
+	Prefetch    int // unacknowledged messages held by each consumer; 0 is unlimited
+	Policy      RetryPolicy
+	Bindings    []Binding
+	// Transient queues belong to this process: the broker removes them
+	// once it stops consuming, and failed messages are dropped rather than
+	// retried or dead-lettered
+	Transient bool
+}
+
+// Binding routes messages published to Exchange with a matching Key into
+// the consumed queue. Keys may use the topic wildcards * and #.
+type Binding struct {
+	Exchange string
+	Key      string
+}
+
+// Message is one delivery of a published message
+type Message struct {
+	ID      string
+	Type    string
+	Body    []byte
+	Attempt int // 1 on the first delivery, counting retries
+}
+
+// Handler processes a message. Returning nil acknowledges it, ErrRequeue
+// puts it back without counting an attempt, and any other error retries it
+// per the consumer's Policy. Errors wrapped with Permanent, or failing the
+// last attempt, move it to the dead-letter queue. With a zero Policy
+// failures are only logged.
+type Handler func(ctx context.Context, m Message) error
+
+// ErrRequeue returns a message to its queue untouched, e.g. while draining
+var ErrRequeue = errors.New("queue: requeue message")
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct{ err error }
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so the message is dead-lettered instead of retried
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// maxRetryDelay caps the backoff so a long outage does not park mail for days
+const maxRetryDelay = time.Hour
+
+// RetryPolicy decides how often a failed message is retried and how long it
+// waits in between. The delay doubles after every attempt.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+}
+
+// ParseRetryPolicy reads a policy from its config strings
+func ParseRetryPolicy(maxAttempts, baseDelay string) (RetryPolicy, error) {
+	attempts, err := strconv.Atoi(maxAttempts)
+	if err != nil || attempts < 1 {
+		return RetryPolicy{}, fmt.Errorf("max attempts must be a positive number, got %q", maxAttempts)
+	}
+	delay, err := time.ParseDuration(baseDelay)
+	if err != nil || delay < time.Second {
+		return RetryPolicy{}, fmt.Errorf("retry delay must be a duration of at least 1s, got %q", baseDelay)
+	}
+	return RetryPolicy{MaxAttempts: attempts, BaseDelay: delay}, nil
+}
+
+// Delay is how long to wait after the given failed attempt
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
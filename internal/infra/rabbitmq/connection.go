@@ -0,0 +1,265 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// ErrConnectionClosed is returned once Close has been called
+var ErrConnectionClosed = errors.New("rabbitmq: connection closed")
+
+// Connection is one broker connection shared by every publisher and
+// consumer. It reconnects with backoff whenever the broker drops it and
+// re-declares the registered topology before anyone uses it again.
+type Connection struct {
+	url string
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ready    chan struct{} // closed while conn is usable
+	topology []func(ch *amqp.Channel) error
+	closed   bool
+	done     chan struct{}
+
+	publisher *Publisher // backs PublishMessage
+}
+
+// Dial connects to url in the background. It does not fail when the broker
+// is down; users of the connection wait until it comes up.
+func Dial(url string) *Connection {
+	c := &Connection{
+		url:   url,
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	c.publisher = &Publisher{conn: c}
+	go c.run()
+	return c
+}
+
+// Declare registers topology to declare now and after every reconnect
+func (c *Connection) Declare(fn func(ch *amqp.Channel) error) error {
+	c.mu.Lock()
+	c.topology = append(c.topology, fn)
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil // declared when the connection comes up
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	return fn(ch)
+}
+
+// Channel opens a channel, waiting for the connection if it is down
+func (c *Connection) Channel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		c.mu.Lock()
+		conn, ready, closed := c.conn, c.ready, c.closed
+		c.mu.Unlock()
+		if closed {
+			return nil, ErrConnectionClosed
+		}
+
+		if conn != nil {
+			ch, err := conn.Channel()
+			if err == nil {
+				return ch, nil
+			}
+			if !conn.IsClosed() {
+				return nil, err
+			}
+			// Dropped, but the reconnect loop has not noticed yet
+			select {
+			case <-time.After(100 * time.Millisecond):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		select {
+		case <-ready:
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Connected reports whether the broker is reachable right now
+func (c *Connection) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil && !c.conn.IsClosed()
+}
+
+// Close shuts the connection down for good
+func (c *Connection) Close() {
+	c.publisher.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.done)
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+func (c *Connection) run() {
+	delay := reconnectMinDelay
+	for {
+		conn, err := c.connect()
+		if err != nil {
+			log.Printf("RabbitMQ unavailable, retrying in %s: %v", delay, err)
+			select {
+			case <-time.After(delay):
+			case <-c.done:
+				return
+			}
+			if delay *= 2; delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+			continue
+		}
+		delay = reconnectMinDelay
+
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.conn = conn
+		close(c.ready)
+		c.mu.Unlock()
+		log.Println("RabbitMQ connected")
+
+		select {
+		case err := <-closed:
+			log.Println("RabbitMQ connection lost:", err)
+		case <-c.done:
+			return
+		}
+
+		c.mu.Lock()
+		c.conn = nil
+		c.ready = make(chan struct{})
+		c.mu.Unlock()
+	}
+}
+
+// connect dials and declares the registered topology
+func (c *Connection) connect() (*amqp.Connection, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	topology := append([]func(*amqp.Channel) error(nil), c.topology...)
+	c.mu.Unlock()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer ch.Close()
+	for _, declare := range topology {
+		if err := declare(ch); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// consume delivers the messages of queue to handle, reopening the channel
+// and consuming again whenever the connection drops, until ctx is done.
+// setup runs on every new channel before consuming, e.g. to declare and
+// bind the queue. handle must ack or nack each delivery.
+func consume(ctx context.Context, c *Connection, queue string, prefetch int, setup func(ch *amqp.Channel) error, handle func(ch *confirmedChannel, d amqp.Delivery)) {
+	for {
+		ch, err := c.Channel(ctx)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, ErrConnectionClosed) {
+				log.Printf("Failed to open channel for %s: %v", queue, err)
+			}
+			return
+		}
+
+		confirmed, msgs, err := startConsuming(ch, queue, prefetch, setup)
+		if err != nil {
+			log.Printf("Failed to consume %s, retrying: %v", queue, err)
+			ch.Close()
+			select {
+			case <-time.After(reconnectMinDelay):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		func() {
+			defer ch.Close()
+			for {
+				select {
+				case d, ok := <-msgs:
+					if !ok {
+						return // channel or connection closed; start over
+					}
+					handle(confirmed, d)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func startConsuming(ch *amqp.Channel, queue string, prefetch int, setup func(ch *amqp.Channel) error) (*confirmedChannel, <-chan amqp.Delivery, error) {
+	if setup != nil {
+		if err := setup(ch); err != nil {
+			return nil, nil, err
+		}
+	}
+	if prefetch > 0 {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Retries and dead letters are published on this channel
+	if err := ch.Confirm(false); err != nil {
+		return nil, nil, err
+	}
+	confirmed := &confirmedChannel{Channel: ch, confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1))}
+
+	msgs, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return confirmed, msgs, nil
+}
@@ -0,0 +1,135 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"hospital_management_system/internal/infra/queue"
+
+	"github.com/streadway/amqp"
+)
+
+// Connection is the RabbitMQ queue driver
+var _ queue.Queue = (*Connection)(nil)
+
+// DeclareQueue makes sure a durable queue exists, now and after reconnects
+func (c *Connection) DeclareQueue(name string) error {
+	return c.Declare(queueTopology(name))
+}
+
+// DeclareExchange makes sure a durable topic exchange exists
+func (c *Connection) DeclareExchange(name string) error {
+	return c.Declare(exchangeTopology(name, "topic"))
+}
+
+// PublishMessage publishes over the connection's own confirmed channel
+func (c *Connection) PublishMessage(exchange, routingKey, messageID, msgType string, body []byte) error {
+	return c.publisher.PublishMessage(exchange, routingKey, messageID, msgType, body)
+}
+
+// PublishBatch keeps the whole batch in flight, waiting for its confirms together
+func (c *Connection) PublishBatch(msgs []queue.Publishing) []error {
+	return c.publisher.PublishBatch(msgs)
+}
+
+// Consume runs cfg.Concurrency consumers, each on its own channel, that
+// resume by themselves after a reconnect. Messages are acknowledged only
+// once handled, so a crash mid-handling redelivers them. Failures wait in
+// a retry queue per backoff step, then go to the dead-letter queue.
+func (c *Connection) Consume(ctx context.Context, cfg queue.ConsumerConfig, handle queue.Handler) <-chan struct{} {
+	setup := func(ch *amqp.Channel) error {
+		if cfg.Transient {
+			if err := transientQueueTopology(cfg.Queue)(ch); err != nil {
+				return err
+			}
+		} else if cfg.Policy.MaxAttempts > 0 {
+			if err := DeclareRetryTopology(ch, cfg.Queue, cfg.Policy); err != nil {
+				return err
+			}
+		} else if err := queueTopology(cfg.Queue)(ch); err != nil {
+			return err
+		}
+		for _, b := range cfg.Bindings {
+			if err := exchangeTopology(b.Exchange, "topic")(ch); err != nil {
+				return err
+			}
+			if err := ch.QueueBind(cfg.Queue, b.Key, b.Exchange, false, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// Keep messages published before the first consumer is up
+	if err := c.Declare(setup); err != nil {
+		log.Printf("Failed to declare %s, retrying when consuming: %v", cfg.Queue, err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < max(cfg.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			consume(ctx, c, cfg.Queue, cfg.Prefetch, setup, func(ch *confirmedChannel, d amqp.Delivery) {
+				deliver(ctx, ch, cfg, d, handle)
+			})
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// deliver runs handle for d and settles d by its outcome
+func deliver(ctx context.Context, ch *confirmedChannel, cfg queue.ConsumerConfig, d amqp.Delivery, handle queue.Handler) {
+	attempt := attemptOf(d) + 1
+	err := handle(ctx, queue.Message{ID: d.MessageId, Type: d.Type, Body: d.Body, Attempt: attempt})
+	switch {
+	case err == nil:
+		d.Ack(false)
+	case errors.Is(err, queue.ErrRequeue):
+		d.Nack(false, true)
+	case cfg.Policy.MaxAttempts == 0 || cfg.Transient:
+		log.Printf("Failed to handle message %s on %s: %v", d.MessageId, cfg.Queue, err)
+		d.Ack(false)
+	case queue.IsPermanent(err) || attempt >= cfg.Policy.MaxAttempts:
+		log.Printf("Dead-lettering message %s on %s: %v", d.MessageId, cfg.Queue, err)
+		settle(ch, d, DeadLetterQueueName(cfg.Queue), attempt, err.Error())
+	default:
+		settle(ch, d, RetryQueueName(cfg.Queue, cfg.Policy.Delay(attempt)), attempt, err.Error())
+	}
+}
+
+// settle moves d onto queue and acknowledges it. When the move fails the
+// message is requeued instead, so it is never dropped.
+func settle(ch *confirmedChannel, d amqp.Delivery, queue string, attempt int, errMsg string) bool {
+	if err := republish(ch, queue, d, attempt, errMsg); err != nil {
+		log.Printf("Failed to move message to %s: %v", queue, err)
+		d.Nack(false, true)
+		return false
+	}
+	d.Ack(false)
+	return true
+}
+
+// transientQueueTopology declares a queue that is deleted when its last
+// consumer goes away, or after a minute without one if it never had any
+func transientQueueTopology(name string) func(ch *amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(name, false, true, false, false, amqp.Table{
+			"x-expires": int32(time.Minute / time.Millisecond),
+		})
+		return err
+	}
+}
+
+func exchangeTopology(name, kind string) func(ch *amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclare(name, kind, true, false, false, false, nil)
+	}
+}
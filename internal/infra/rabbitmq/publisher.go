@@ -1,56 +1,232 @@
 package rabbitmq
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"hospital_management_system/internal/infra/queue"
 
 	"github.com/streadway/amqp"
 )
 
+// publishTimeout bounds how long a publish waits for the connection and
+// for the broker to confirm the message
+const publishTimeout = 5 * time.Second
+
+// confirmBuffer lets the broker's confirms queue up while waiters are matched
+const confirmBuffer = 256
+
+// Publisher sends messages over a shared Connection. Every publish waits
+// for the broker's confirmation, so a nil error means the message was
+// accepted rather than merely written to the socket. Confirms are matched
+// to publishes by delivery tag, so many can be outstanding at once.
 type Publisher struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   amqp.Queue
+	conn *Connection
+
+	mu       sync.Mutex // held while publishing, not while awaiting confirms
+	channel  *amqp.Channel
+	confirms *confirmTracker // of channel
 }
 
-func NewPublisher(amqpURL, queueName string) (*Publisher, error) {
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
-		return nil, err
+// Close releases the publisher's channel; the connection stays open
+func (p *Publisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset(ErrConnectionClosed)
+}
+
+// PublishMessage sends an already encoded message to exchange with
+// routingKey. messageID lets consumers drop redeliveries.
+func (p *Publisher) PublishMessage(exchange, routingKey, messageID, msgType string, body []byte) error {
+	return p.publish(exchange, routingKey, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageID,
+		Type:         msgType,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+}
+
+// PublishBatch publishes msgs in order, then waits for all their confirms
+func (p *Publisher) PublishBatch(msgs []queue.Publishing) []error {
+	errs := make([]error, len(msgs))
+	waits := make([]func() error, len(msgs))
+	for i, m := range msgs {
+		waits[i], errs[i] = p.start(m.Exchange, m.RoutingKey, amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    m.MessageID,
+			Type:         m.Type,
+			Timestamp:    time.Now(),
+			Body:         m.Body,
+		})
+	}
+	for i, wait := range waits {
+		if wait != nil {
+			errs[i] = wait()
+		}
 	}
+	return errs
+}
 
-	ch, err := conn.Channel()
+func (p *Publisher) publish(exchange, routingKey string, msg amqp.Publishing) error {
+	wait, err := p.start(exchange, routingKey, msg)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return wait()
+}
 
-	q, err := ch.QueueDeclare(
-		queueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
+// start writes msg to the channel and returns a func that waits for the
+// broker's confirmation of it
+func (p *Publisher) start(exchange, routingKey string, msg amqp.Publishing) (func() error, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.open(); err != nil {
+		return nil, err
+	}
+	// Registered first, so a fast confirm always finds its waiter
+	confirms := p.confirms
+	tag, done := confirms.add()
+	if err := p.channel.Publish(exchange, routingKey, false, false, msg); err != nil {
+		p.reset(err)
 		return nil, err
 	}
 
-	return &Publisher{conn: conn, channel: ch, queue: q}, nil
+	return func() error {
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(publishTimeout):
+			// A late confirm finds no waiter and is dropped
+			confirms.remove(tag)
+			return errors.New("rabbitmq: message not confirmed in time")
+		}
+	}, nil
 }
 
-func (p *Publisher) Publish(job interface{}) error {
-	body, err := json.Marshal(job)
+// open gets a channel in confirm mode, reconnecting if needed
+func (p *Publisher) open() error {
+	if p.channel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	ch, err := p.conn.Channel(ctx)
 	if err != nil {
 		return err
 	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return err
+	}
+	p.channel = ch
+	p.confirms = newConfirmTracker()
+	go p.awaitConfirms(p.confirms, ch.NotifyPublish(make(chan amqp.Confirmation, confirmBuffer)))
+	return nil
+}
 
-	return p.channel.Publish("", p.queue.Name, false, false, amqp.Publishing{
-		ContentType: "application/json",
-		Body:        body,
-	})
+// awaitConfirms hands each confirm to its waiter until the channel closes.
+// It never takes p.mu while confirms are flowing, since closing the channel
+// under p.mu waits for them to be delivered.
+func (p *Publisher) awaitConfirms(t *confirmTracker, confirms <-chan amqp.Confirmation) {
+	for c := range confirms {
+		t.resolve(c)
+	}
+	t.fail(errors.New("rabbitmq: channel closed before the message was confirmed"))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.confirms == t {
+		p.channel = nil
+		p.confirms = nil
+	}
 }
 
-func (p *Publisher) Close() {
-	p.channel.Close()
-	p.conn.Close()
+// reset drops the channel, failing every publish still awaiting a confirm
+func (p *Publisher) reset(err error) {
+	if p.confirms != nil {
+		p.confirms.fail(err)
+	}
+	if p.channel != nil {
+		p.channel.Close()
+	}
+	p.channel = nil
+	p.confirms = nil
+}
+
+// confirmTracker matches the confirms of one channel to waiting publishes.
+// Delivery tags count up from 1 per channel in publish order.
+type confirmTracker struct {
+	mu      sync.Mutex
+	nextTag uint64
+	pending map[uint64]chan error
+	err     error // set once the channel is gone
+}
+
+func newConfirmTracker() *confirmTracker {
+	return &confirmTracker{nextTag: 1, pending: make(map[uint64]chan error)}
+}
+
+// add registers the next publish, returning its tag and where its outcome
+// is sent
+func (t *confirmTracker) add() (uint64, chan error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	done := make(chan error, 1)
+	tag := t.nextTag
+	t.nextTag++
+	if t.err != nil {
+		done <- t.err
+		return tag, done
+	}
+	t.pending[tag] = done
+	return tag, done
+}
+
+func (t *confirmTracker) remove(tag uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, tag)
+}
+
+func (t *confirmTracker) resolve(c amqp.Confirmation) {
+	t.mu.Lock()
+	done, ok := t.pending[c.DeliveryTag]
+	delete(t.pending, c.DeliveryTag)
+	t.mu.Unlock()
+
+	switch {
+	case !ok:
+	case c.Ack:
+		done <- nil
+	default:
+		done <- errors.New("rabbitmq: message rejected by the broker")
+	}
+}
+
+// fail ends every pending publish with err
+func (t *confirmTracker) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = err
+	}
+	for tag, done := range t.pending {
+		done <- t.err
+		delete(t.pending, tag)
+	}
+}
+
+// queueTopology declares a plain durable queue
+func queueTopology(name string) func(ch *amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(name, true, false, false, false, nil)
+		return err
+	}
 }
@@ -0,0 +1,111 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"hospital_management_system/internal/infra/queue"
+
+	"github.com/streadway/amqp"
+)
+
+// attemptHeader counts how many times a message has been handled
+const attemptHeader = "x-attempt"
+
+// RetryQueueName is the queue a message waits in before going back to queueName
+func RetryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, delay)
+}
+
+// DeadLetterQueueName holds the messages of queueName that will not be retried
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dead"
+}
+
+// DeclareRetryTopology declares queueName, its dead-letter queue, and one
+// retry queue per backoff step. A retry queue has no consumers: messages
+// expire after its TTL and RabbitMQ dead-letters them back onto queueName.
+// Using a queue per delay avoids short delays waiting behind long ones.
+func DeclareRetryTopology(ch *amqp.Channel, queueName string, policy queue.RetryPolicy) error {
+	if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(DeadLetterQueueName(queueName), true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	declared := make(map[time.Duration]bool)
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		delay := policy.Delay(attempt)
+		if declared[delay] {
+			continue
+		}
+		declared[delay] = true
+		if _, err := ch.QueueDeclare(RetryQueueName(queueName, delay), true, false, false, false, amqp.Table{
+			"x-message-ttl":             int64(delay / time.Millisecond),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attemptOf returns how many times d has already been handled
+func attemptOf(d amqp.Delivery) int {
+	switch v := d.Headers[attemptHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// confirmedChannel is a consumer's channel in confirm mode, so a message
+// moved to another queue is known to be stored before the original is acked
+type confirmedChannel struct {
+	*amqp.Channel
+	confirms <-chan amqp.Confirmation
+}
+
+// republish copies d onto queue, recording the attempt it has been through,
+// and waits for the broker to confirm the copy
+func republish(ch *confirmedChannel, queue string, d amqp.Delivery, attempt int, errMsg string) error {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[attemptHeader] = int32(attempt)
+	if errMsg != "" {
+		headers["x-last-error"] = errMsg
+	}
+
+	err := ch.Publish("", queue, false, false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Type:         d.Type,
+		Timestamp:    d.Timestamp,
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only this consumer publishes on the channel, one message at a time,
+	// so the next confirm is this message's
+	confirm, ok := <-ch.confirms
+	if !ok {
+		return errors.New("channel closed before the broker confirmed")
+	}
+	if !confirm.Ack {
+		return fmt.Errorf("broker did not accept message %s", d.MessageId)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hospital_management_system/internal/infra/queue"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Per-user limits; a slow client drops events instead of blocking senders
+const (
+	maxSubscriptionsPerUser = 5
+	subscriberBuffer        = 16
+)
+
+var ErrTooManySubscriptions = errors.New("too many open streams for this user")
+
+// Event is pushed to every open stream of a user
+type Event struct {
+	ID   string      // optional, sent as the SSE id
+	Name string      // SSE event name
+	Data interface{} // marshalled as JSON
+}
+
+// Exchange carries hub events between server instances. Each instance
+// consumes it through a transient queue of its own, so every event reaches
+// all of them.
+const Exchange = "realtime"
+
+// wireEvent is an Event on its way between instances
+type wireEvent struct {
+	UserID uuid.UUID       `json:"user_id"`
+	ID     string          `json:"id,omitempty"`
+	Name   string          `json:"name"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Hub fans events out to the streams each user has open. Until Connect is
+// called it only reaches streams on this server.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan Event]struct{}
+	mq   queue.Queue
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// Subscribe opens a stream for userID. Call the returned func when done.
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Event, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subs[userID]) >= maxSubscriptionsPerUser {
+		return nil, nil, ErrTooManySubscriptions
+	}
+	ch := make(chan Event, subscriberBuffer)
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan Event]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe, nil
+}
+
+// Connect routes published events through mq, so they reach the streams
+// open on every server instance behind the load balancer. Events are taken
+// off this instance's queue until ctx is done; consuming declares the
+// exchange before anything is published to it.
+func (h *Hub) Connect(ctx context.Context, mq queue.Queue) {
+	mq.Consume(ctx, queue.ConsumerConfig{
+		Queue:     Exchange + "." + uuid.NewString(),
+		Bindings:  []queue.Binding{{Exchange: Exchange, Key: "#"}},
+		Transient: true,
+	}, func(ctx context.Context, m queue.Message) error {
+		var ev wireEvent
+		if err := json.Unmarshal(m.Body, &ev); err != nil {
+			return queue.Permanent(err)
+		}
+		h.deliver(ev.UserID, Event{ID: ev.ID, Name: ev.Name, Data: ev.Data})
+		return nil
+	})
+
+	h.mu.Lock()
+	h.mq = mq
+	h.mu.Unlock()
+}
+
+// Publish sends ev to userID's open streams on every connected instance
+// without blocking on slow clients
+func (h *Hub) Publish(userID uuid.UUID, ev Event) {
+	h.mu.Lock()
+	mq := h.mq
+	h.mu.Unlock()
+	if mq == nil {
+		h.deliver(userID, ev)
+		return
+	}
+
+	data, err := json.Marshal(ev.Data)
+	if err == nil {
+		var body []byte
+		body, err = json.Marshal(wireEvent{UserID: userID, ID: ev.ID, Name: ev.Name, Data: data})
+		if err == nil {
+			err = mq.PublishMessage(Exchange, ev.Name, uuid.NewString(), "realtime.event", body)
+		}
+	}
+	if err != nil {
+		// Streams on this instance at least still get it
+		log.Println("Failed to fan out stream event:", err)
+		h.deliver(userID, ev)
+	}
+}
+
+// deliver hands ev to userID's streams open on this instance
+func (h *Hub) deliver(userID uuid.UUID, ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- ev:
+		default: // subscriber is not keeping up
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"hospital_management_system/internal/infra/queue"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func receive(t *testing.T, events <-chan Event) (Event, bool) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev, true
+	case <-time.After(200 * time.Millisecond):
+		return Event{}, false
+	}
+}
+
+// TestHubFanOut runs two hubs on one broker, as two server instances would
+func TestHubFanOut(t *testing.T) {
+	mq := queue.NewMemory()
+	defer mq.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := NewHub(), NewHub()
+	a.Connect(ctx, mq)
+	b.Connect(ctx, mq)
+
+	user, other := uuid.New(), uuid.New()
+	onA, closeA, _ := a.Subscribe(user)
+	defer closeA()
+	onB, closeB, _ := b.Subscribe(user)
+	defer closeB()
+	otherOnB, closeOther, _ := b.Subscribe(other)
+	defer closeOther()
+
+	a.Publish(user, Event{ID: "n1", Name: "notification", Data: map[string]string{"title": "Results ready"}})
+
+	for name, events := range map[string]<-chan Event{"publishing instance": onA, "other instance": onB} {
+		ev, ok := receive(t, events)
+		if !ok {
+			t.Fatalf("%s: event not delivered", name)
+		}
+		var data map[string]string
+		raw, _ := json.Marshal(ev.Data)
+		if err := json.Unmarshal(raw, &data); err != nil || ev.ID != "n1" || ev.Name != "notification" || data["title"] != "Results ready" {
+			t.Errorf("%s: got %+v (%s), want the published event", name, ev, raw)
+		}
+	}
+	if ev, ok := receive(t, otherOnB); ok {
+		t.Errorf("another user's stream got %+v", ev)
+	}
+}
+
+func TestHubLocalOnly(t *testing.T) {
+	h := NewHub()
+	user := uuid.New()
+	events, unsubscribe, _ := h.Subscribe(user)
+	defer unsubscribe()
+
+	h.Publish(user, Event{Name: "unread_count", Data: 3})
+	if ev, ok := receive(t, events); !ok || ev.Data != 3 {
+		t.Errorf("got %+v, %v; want the event delivered in process", ev, ok)
+	}
+}
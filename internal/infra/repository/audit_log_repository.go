@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository is append-only: there is deliberately no update or delete
+type AuditLogRepository interface {
+	Create(entry *models.AuditLog) error
+	Search(filter *dto.AuditLogFilter) ([]models.AuditLog, int64, error)
+}
+
+type auditLogRepo struct {
+	db *gorm.DB
+}
+
+func AuditLogNewRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepo{db: db}
+}
+
+func (r *auditLogRepo) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// Search returns a page of entries matching the filter, newest first
+func (r *auditLogRepo) Search(filter *dto.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	var entries []models.AuditLog
+	var total int64
+
+	query := r.db.Model(&models.AuditLog{})
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Find(&entries).Error
+	return entries, total, err
+}
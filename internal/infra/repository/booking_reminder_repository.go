@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookingReminderRepository interface {
+	// CreateMany inserts reminders, skipping any the booking already has
+	CreateMany(reminders []models.BookingReminder) error
+	// CancelForBooking cancels the booking's reminders that have not gone out
+	CancelForBooking(bookingID uuid.UUID, reason string) (int64, error)
+	// ClaimDue locks up to limit due reminders for lease. Rows locked by
+	// another scheduler are skipped; expired leases are reclaimed.
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.BookingReminder, error)
+	MarkSent(id uuid.UUID) error
+	Retry(id uuid.UUID, at time.Time, errMsg string) error
+	Finish(id uuid.UUID, status, errMsg string) error
+}
+
+type bookingReminderRepo struct {
+	db *gorm.DB
+}
+
+func BookingReminderNewRepository(db *gorm.DB) BookingReminderRepository {
+	return &bookingReminderRepo{db: db}
+}
+
+func (r *bookingReminderRepo) CreateMany(reminders []models.BookingReminder) error {
+	if len(reminders) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reminders).Error
+}
+
+func (r *bookingReminderRepo) CancelForBooking(bookingID uuid.UUID, reason string) (int64, error) {
+	result := r.db.Model(&models.BookingReminder{}).
+		Where("booking_id = ? AND status IN ?", bookingID, []string{models.ReminderPending, models.ReminderProcessing}).
+		Updates(map[string]interface{}{
+			"status":       models.ReminderCanceled,
+			"last_error":   reason,
+			"locked_until": nil,
+			"updated_at":   time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *bookingReminderRepo) ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.BookingReminder, error) {
+	var claimed []models.BookingReminder
+	err := r.db.Raw(`
+		UPDATE booking_reminders
+		SET status = ?, locked_until = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM booking_reminders
+			WHERE remind_at <= ?
+			  AND (status = ? OR (status = ? AND locked_until < ?))
+			ORDER BY remind_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.ReminderProcessing, now.Add(lease), now,
+		now, models.ReminderPending, models.ReminderProcessing, now,
+		limit,
+	).Scan(&claimed).Error
+	return claimed, err
+}
+
+func (r *bookingReminderRepo) MarkSent(id uuid.UUID) error {
+	now := time.Now()
+	return r.db.Model(&models.BookingReminder{}).
+		Where("id = ? AND status = ?", id, models.ReminderProcessing).
+		Updates(map[string]interface{}{
+			"status":       models.ReminderSent,
+			"sent_at":      now,
+			"locked_until": nil,
+			"last_error":   "",
+			"updated_at":   now,
+		}).Error
+}
+
+// Retry puts a claimed reminder back in the queue for another attempt
+func (r *bookingReminderRepo) Retry(id uuid.UUID, at time.Time, errMsg string) error {
+	return r.db.Model(&models.BookingReminder{}).
+		Where("id = ? AND status = ?", id, models.ReminderProcessing).
+		Updates(map[string]interface{}{
+			"status":       models.ReminderPending,
+			"remind_at":    at,
+			"locked_until": nil,
+			"last_error":   errMsg,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+// Finish closes a claimed reminder without sending it
+func (r *bookingReminderRepo) Finish(id uuid.UUID, status, errMsg string) error {
+	return r.db.Model(&models.BookingReminder{}).
+		Where("id = ? AND status = ?", id, models.ReminderProcessing).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"locked_until": nil,
+			"last_error":   errMsg,
+			"updated_at":   time.Now(),
+		}).Error
+}
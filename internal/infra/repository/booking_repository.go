@@ -16,8 +16,14 @@ type BookingRepository interface {
 	Delete(id string) error
 	CheckRoomBookingConflict(roomID uuid.UUID, checkIn, checkOut time.Time) (bool, error)
 	UpdateStatus(id string, status models.BookingStatus) error
+	// Variants that run as part of tx
+	CreateTx(tx *gorm.DB, b *models.Booking) error
+	UpdateStatusTx(tx *gorm.DB, id string, status models.BookingStatus) error
+	DeleteTx(tx *gorm.DB, id string) error
 
 	CountServiceBookingsForDay(serviceID string, day string) (int64, error)
+	// ListUpcoming returns live bookings whose appointment or check-in is after t
+	ListUpcoming(t time.Time) ([]models.Booking, error)
 }
 
 type bookingRepo struct {
@@ -29,7 +35,11 @@ func BookingNewRepository(db *gorm.DB) BookingRepository {
 }
 
 func (r *bookingRepo) Create(b *models.Booking) (*models.Booking, error) {
-	return b, r.db.Create(b).Error
+	return b, r.CreateTx(r.db, b)
+}
+
+func (r *bookingRepo) CreateTx(tx *gorm.DB, b *models.Booking) error {
+	return tx.Create(b).Error
 }
 
 func (r *bookingRepo) CheckRoomBookingConflict(roomID uuid.UUID, checkIn, checkOut time.Time) (bool, error) {
@@ -73,7 +83,11 @@ func (r *bookingRepo) Update(b *models.Booking) (*models.Booking, error) {
 }
 
 func (r *bookingRepo) Delete(id string) error {
-	return r.db.Model(&models.Booking{}).
+	return r.DeleteTx(r.db, id)
+}
+
+func (r *bookingRepo) DeleteTx(tx *gorm.DB, id string) error {
+	return tx.Model(&models.Booking{}).
 		Where("id = ?", id).
 		Update("is_deleted", true).Error
 }
@@ -88,7 +102,20 @@ func (r *bookingRepo) CountServiceBookingsForDay(serviceID string, day string) (
 
 
 func (r *bookingRepo) UpdateStatus(id string, status models.BookingStatus) error {
-	return r.db.Model(&models.Booking{}).
+	return r.UpdateStatusTx(r.db, id, status)
+}
+
+func (r *bookingRepo) UpdateStatusTx(tx *gorm.DB, id string, status models.BookingStatus) error {
+	return tx.Model(&models.Booking{}).
 		Where("id = ?", id).
 		Update("status", status).Error
-}
\ No newline at end of file
+}
+
+func (r *bookingRepo) ListUpcoming(t time.Time) ([]models.Booking, error) {
+	var list []models.Booking
+	err := r.db.
+		Where("is_deleted = FALSE AND status IN ?", []models.BookingStatus{models.BookingPending, models.BookingConfirmed}).
+		Where("(scheduled_at > ? OR check_in_date > ?)", t, t).
+		Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"errors"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DocumentRepository interface {
+	Create(doc *models.PatientDocument, version *models.DocumentVersion) error
+	FindByID(id uuid.UUID) (*models.PatientDocument, error)
+	List(filter *dto.DocumentFilter) ([]models.PatientDocument, int64, error)
+	AddVersion(docID uuid.UUID, version *models.DocumentVersion) error
+	FindVersion(docID uuid.UUID, version int) (*models.DocumentVersion, error)
+	ReplaceTags(docID uuid.UUID, tags []string) error
+	Grant(access *models.DocumentAccess) error
+	Revoke(docID, userID uuid.UUID) error
+	HasGrant(docID, userID uuid.UUID) (bool, error)
+	SoftDelete(id uuid.UUID) error
+}
+
+type documentRepo struct {
+	db *gorm.DB
+}
+
+func DocumentNewRepository(db *gorm.DB) DocumentRepository {
+	return &documentRepo{db: db}
+}
+
+// Create inserts the document together with its first version and tags
+func (r *documentRepo) Create(doc *models.PatientDocument, version *models.DocumentVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tags, grants := doc.Tags, doc.Grants
+		doc.Tags, doc.Grants = nil, nil
+		if err := tx.Omit(clause.Associations).Create(doc).Error; err != nil {
+			return err
+		}
+
+		version.DocumentID = doc.ID
+		version.Version = 1
+		if err := tx.Omit(clause.Associations).Create(version).Error; err != nil {
+			return err
+		}
+
+		for i := range tags {
+			tags[i].DocumentID = doc.ID
+		}
+		if len(tags) > 0 {
+			if err := tx.Create(&tags).Error; err != nil {
+				return err
+			}
+		}
+		for i := range grants {
+			grants[i].DocumentID = doc.ID
+		}
+		if len(grants) > 0 {
+			if err := tx.Create(&grants).Error; err != nil {
+				return err
+			}
+		}
+
+		doc.Tags, doc.Grants = tags, grants
+		doc.Versions = []models.DocumentVersion{*version}
+		return nil
+	})
+}
+
+// FindByID loads an active document with its versions (newest first), tags and grants
+func (r *documentRepo) FindByID(id uuid.UUID) (*models.PatientDocument, error) {
+	var doc models.PatientDocument
+	err := r.db.
+		Preload("Versions", func(db *gorm.DB) *gorm.DB { return db.Order("version DESC") }).
+		Preload("Versions.Image").
+		Preload("Tags").
+		Preload("Grants").
+		Where("id = ? AND is_deleted = FALSE", id).
+		First(&doc).Error
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *documentRepo) List(filter *dto.DocumentFilter) ([]models.PatientDocument, int64, error) {
+	var docs []models.PatientDocument
+	var total int64
+
+	query := r.db.Model(&models.PatientDocument{}).
+		Where("patient_id = ? AND is_deleted = FALSE", filter.PatientID)
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.DocumentTag{}).Select("document_id").Where("tag = ?", filter.Tag))
+	}
+	if filter.VisibleTo != nil {
+		query = query.Where("(uploaded_by = ? OR id IN (?))", *filter.VisibleTo,
+			r.db.Model(&models.DocumentAccess{}).Select("document_id").Where("user_id = ?", *filter.VisibleTo))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Tags").
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&docs).Error
+	return docs, total, err
+}
+
+// AddVersion appends a version, numbering it under a row lock so concurrent uploads don't collide
+func (r *documentRepo) AddVersion(docID uuid.UUID, version *models.DocumentVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var doc models.PatientDocument
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_deleted = FALSE", docID).
+			First(&doc).Error; err != nil {
+			return err
+		}
+
+		version.DocumentID = docID
+		version.Version = doc.CurrentVersion + 1
+		if err := tx.Omit(clause.Associations).Create(version).Error; err != nil {
+			return err
+		}
+		return tx.Model(&doc).Update("current_version", version.Version).Error
+	})
+}
+
+func (r *documentRepo) FindVersion(docID uuid.UUID, version int) (*models.DocumentVersion, error) {
+	var v models.DocumentVersion
+	err := r.db.Preload("Image").
+		Where("document_id = ? AND version = ?", docID, version).
+		First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *documentRepo) ReplaceTags(docID uuid.UUID, tags []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", docID).Delete(&models.DocumentTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		rows := make([]models.DocumentTag, len(tags))
+		for i, t := range tags {
+			rows[i] = models.DocumentTag{DocumentID: docID, Tag: t}
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// Grant is idempotent: granting twice keeps the original grant
+func (r *documentRepo) Grant(access *models.DocumentAccess) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(access).Error
+}
+
+func (r *documentRepo) Revoke(docID, userID uuid.UUID) error {
+	return r.db.Where("document_id = ? AND user_id = ?", docID, userID).Delete(&models.DocumentAccess{}).Error
+}
+
+func (r *documentRepo) HasGrant(docID, userID uuid.UUID) (bool, error) {
+	var access models.DocumentAccess
+	err := r.db.Where("document_id = ? AND user_id = ?", docID, userID).First(&access).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *documentRepo) SoftDelete(id uuid.UUID) error {
+	return r.db.Model(&models.PatientDocument{}).
+		Where("id = ?", id).
+		Update("is_deleted", true).Error
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"hospital_management_system/internal/dto"
 	"hospital_management_system/internal/models"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,7 +12,32 @@ import (
 
 type EmailRepository interface {
 	CreateEmail(email *models.Email) error
-	UpdateEmailStatus(id uuid.UUID, status models.EmailStatus, errMsg *string) error
+	// CreateEmailTx inserts the email as part of tx
+	CreateEmailTx(tx *gorm.DB, email *models.Email) error
+	FindByID(id uuid.UUID) (*models.Email, error)
+	// FindWithAttachments loads the email and its attachments, without
+	// their content
+	FindWithAttachments(id uuid.UUID) (*models.Email, error)
+	// Search returns a page of emails, newest first, without their bodies
+	Search(filter *dto.EmailFilter) ([]models.Email, int64, error)
+	// RecordAttempt stores the outcome of a delivery attempt
+	RecordAttempt(id uuid.UUID, attempt int, status models.EmailStatus, errMsg *string) error
+	// ListIDsByStatus lists the oldest emails in status, leaving out those
+	// of the skip types
+	ListIDsByStatus(status models.EmailStatus, limit int, skip ...models.EmailType) ([]uuid.UUID, error)
+	// ResetFailed puts a failed email back to pending with a fresh attempt
+	// count, reporting false when it is not in the failed state
+	ResetFailed(tx *gorm.DB, id uuid.UUID) (bool, error)
+	// ResetForResend puts a failed or pending email back to pending, keeping
+	// its attempt count. It reports false when the email is in neither state
+	// or was updated since updatedAt.
+	ResetForResend(tx *gorm.DB, id uuid.UUID, updatedAt time.Time) (bool, error)
+	// MarkBounced records that a sent email came back
+	MarkBounced(id uuid.UUID, reason string) error
+	// RecordOpen counts an open, keeping the time of the first
+	RecordOpen(id uuid.UUID) error
+	// ListAttachments loads the files sent with an email, content included
+	ListAttachments(emailID uuid.UUID) ([]models.EmailAttachment, error)
 }
 
 type emailRepo struct {
@@ -24,12 +52,135 @@ func (r *emailRepo) CreateEmail(email *models.Email) error {
 	return r.db.Create(email).Error
 }
 
-func (r *emailRepo) UpdateEmailStatus(id uuid.UUID, status models.EmailStatus, errMsg *string) error {
-	return r.db.Model(&models.Email{}).
-		Where("id = ?", id).
+func (r *emailRepo) CreateEmailTx(tx *gorm.DB, email *models.Email) error {
+	return tx.Create(email).Error
+}
+
+func (r *emailRepo) FindByID(id uuid.UUID) (*models.Email, error) {
+	var email models.Email
+	if err := r.db.First(&email, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+func (r *emailRepo) FindWithAttachments(id uuid.UUID) (*models.Email, error) {
+	var email models.Email
+	err := r.db.Preload("Attachments", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("content").Order("created_at")
+	}).First(&email, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+func (r *emailRepo) Search(filter *dto.EmailFilter) ([]models.Email, int64, error) {
+	var emails []models.Email
+	var total int64
+
+	query := r.db.Model(&models.Email{})
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(filter.Email)))
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Omit("body", "text_body").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Find(&emails).Error
+	return emails, total, err
+}
+
+func (r *emailRepo) RecordAttempt(id uuid.UUID, attempt int, status models.EmailStatus, errMsg *string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":          status,
+		"error":           errMsg,
+		"attempts":        attempt,
+		"last_attempt_at": now,
+		"updated_at":      now,
+	}
+	if status == models.EmailStatusSent {
+		updates["sent_at"] = now
+	}
+	return r.db.Model(&models.Email{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *emailRepo) ListIDsByStatus(status models.EmailStatus, limit int, skip ...models.EmailType) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := r.db.Model(&models.Email{}).Where("status = ?", status)
+	if len(skip) > 0 {
+		query = query.Where("type NOT IN ?", skip)
+	}
+	err := query.
+		Order("created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *emailRepo) ResetFailed(tx *gorm.DB, id uuid.UUID) (bool, error) {
+	result := tx.Model(&models.Email{}).
+		Where("id = ? AND status = ?", id, models.EmailStatusFailed).
+		Updates(map[string]interface{}{
+			"status":     models.EmailStatusPending,
+			"attempts":   0,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *emailRepo) ResetForResend(tx *gorm.DB, id uuid.UUID, updatedAt time.Time) (bool, error) {
+	result := tx.Model(&models.Email{}).
+		Where("id = ? AND status IN ? AND updated_at = ?", id,
+			[]models.EmailStatus{models.EmailStatusFailed, models.EmailStatusPending}, updatedAt).
 		Updates(map[string]interface{}{
-			"status":  status,
-			"error":   errMsg,
-			"sent_at": gorm.Expr("NOW()"),
-		}).Error
+			"status":     models.EmailStatusPending,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *emailRepo) MarkBounced(id uuid.UUID, reason string) error {
+	now := time.Now()
+	return r.db.Model(&models.Email{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.EmailStatusBounced,
+		"error":      reason,
+		"bounced_at": now,
+		"updated_at": now,
+	}).Error
+}
+
+func (r *emailRepo) RecordOpen(id uuid.UUID) error {
+	return r.db.Model(&models.Email{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"opens":     gorm.Expr("opens + 1"),
+		"opened_at": gorm.Expr("COALESCE(opened_at, ?)", time.Now()),
+	}).Error
+}
+
+func (r *emailRepo) ListAttachments(emailID uuid.UUID) ([]models.EmailAttachment, error) {
+	var attachments []models.EmailAttachment
+	err := r.db.Where("email_id = ?", emailID).Order("created_at").Find(&attachments).Error
+	return attachments, err
 }
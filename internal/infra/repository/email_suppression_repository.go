@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailSuppressionRepository interface {
+	// Add records the suppression; one that already exists is left as is
+	Add(s *models.EmailSuppression) error
+	FindByEmail(email string) ([]models.EmailSuppression, error)
+	// List returns a page of suppressions, newest first
+	List(filter *dto.EmailSuppressionFilter) ([]models.EmailSuppression, int64, error)
+	Delete(id uuid.UUID) (*models.EmailSuppression, error)
+}
+
+type emailSuppressionRepo struct {
+	db *gorm.DB
+}
+
+func EmailSuppressionNewRepository(db *gorm.DB) EmailSuppressionRepository {
+	return &emailSuppressionRepo{db: db}
+}
+
+func (r *emailSuppressionRepo) Add(s *models.EmailSuppression) error {
+	s.Email = strings.ToLower(strings.TrimSpace(s.Email))
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(s).Error
+}
+
+func (r *emailSuppressionRepo) FindByEmail(email string) ([]models.EmailSuppression, error) {
+	var suppressions []models.EmailSuppression
+	err := r.db.Where("email = ?", strings.ToLower(strings.TrimSpace(email))).Find(&suppressions).Error
+	return suppressions, err
+}
+
+func (r *emailSuppressionRepo) List(filter *dto.EmailSuppressionFilter) ([]models.EmailSuppression, int64, error) {
+	var suppressions []models.EmailSuppression
+	var total int64
+
+	query := r.db.Model(&models.EmailSuppression{})
+	if filter.Email != "" {
+		query = query.Where("email = ?", strings.ToLower(strings.TrimSpace(filter.Email)))
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(filter.PageSize).Find(&suppressions).Error
+	return suppressions, total, err
+}
+
+func (r *emailSuppressionRepo) Delete(id uuid.UUID) (*models.EmailSuppression, error) {
+	var s models.EmailSuppression
+	if err := r.db.First(&s, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Delete(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
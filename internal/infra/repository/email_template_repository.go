@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailTemplateRepository interface {
+	FindActive(typ models.EmailType) (*models.EmailTemplate, error)
+	FindVersion(typ models.EmailType, version int) (*models.EmailTemplate, error)
+	ListVersions(typ models.EmailType) ([]models.EmailTemplate, error)
+	// ListLatest returns the active version of every type that has one,
+	// otherwise its newest version
+	ListLatest() ([]models.EmailTemplate, error)
+	// CreateVersion stores tmpl as the next version of its type, making it
+	// the active one when activate is set
+	CreateVersion(tmpl *models.EmailTemplate, activate bool) error
+	// Activate makes the given version live, reporting false when it does not exist
+	Activate(typ models.EmailType, version int) (bool, error)
+	// Deactivate reverts a type to its bundled default
+	Deactivate(typ models.EmailType) error
+}
+
+type emailTemplateRepo struct {
+	db *gorm.DB
+}
+
+func EmailTemplateNewRepository(db *gorm.DB) EmailTemplateRepository {
+	return &emailTemplateRepo{db: db}
+}
+
+func (r *emailTemplateRepo) FindActive(typ models.EmailType) (*models.EmailTemplate, error) {
+	var tmpl models.EmailTemplate
+	if err := r.db.First(&tmpl, "type = ? AND is_active", typ).Error; err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+func (r *emailTemplateRepo) FindVersion(typ models.EmailType, version int) (*models.EmailTemplate, error) {
+	var tmpl models.EmailTemplate
+	if err := r.db.First(&tmpl, "type = ? AND version = ?", typ, version).Error; err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+func (r *emailTemplateRepo) ListVersions(typ models.EmailType) ([]models.EmailTemplate, error) {
+	var tmpls []models.EmailTemplate
+	err := r.db.Where("type = ?", typ).Order("version DESC").Find(&tmpls).Error
+	return tmpls, err
+}
+
+func (r *emailTemplateRepo) ListLatest() ([]models.EmailTemplate, error) {
+	var tmpls []models.EmailTemplate
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (type) *
+		FROM email_templates
+		ORDER BY type, is_active DESC, version DESC`).
+		Scan(&tmpls).Error
+	return tmpls, err
+}
+
+func (r *emailTemplateRepo) CreateVersion(tmpl *models.EmailTemplate, activate bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the type's rows so two editors cannot claim the same version
+		var versions []int
+		if err := tx.Model(&models.EmailTemplate{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("type = ?", tmpl.Type).
+			Pluck("version", &versions).Error; err != nil {
+			return err
+		}
+		tmpl.Version = 1
+		for _, v := range versions {
+			if v >= tmpl.Version {
+				tmpl.Version = v + 1
+			}
+		}
+
+		if activate {
+			if err := deactivateTemplates(tx, tmpl.Type); err != nil {
+				return err
+			}
+		}
+		tmpl.IsActive = activate
+		return tx.Create(tmpl).Error
+	})
+}
+
+func (r *emailTemplateRepo) Activate(typ models.EmailType, version int) (bool, error) {
+	found := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deactivateTemplates(tx, typ); err != nil {
+			return err
+		}
+		result := tx.Model(&models.EmailTemplate{}).
+			Where("type = ? AND version = ?", typ, version).
+			Update("is_active", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound // roll back the deactivation
+		}
+		found = true
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	return found, err
+}
+
+func (r *emailTemplateRepo) Deactivate(typ models.EmailType) error {
+	return deactivateTemplates(r.db, typ)
+}
+
+func deactivateTemplates(tx *gorm.DB, typ models.EmailType) error {
+	return tx.Model(&models.EmailTemplate{}).
+		Where("type = ? AND is_active", typ).
+		Update("is_active", false).Error
+}
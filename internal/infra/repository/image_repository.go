@@ -10,6 +10,8 @@ import (
 
 type ImageRepository interface {
 	Create(image *models.Image) error
+	// CreateTx inserts the image as part of tx
+	CreateTx(tx *gorm.DB, image *models.Image) error
 	FindByID(id uuid.UUID) (*models.Image, error)
 	FindByUserID(userID uuid.UUID, page, pageSize int) ([]models.Image, int64, error)
 	FindByPublicID(publicID string) (*models.Image, error)
@@ -31,10 +33,15 @@ func (r *imageRepo) Create(image *models.Image) error {
 	return r.db.Create(image).Error
 }
 
-// FindByID retrieves image by ID
+func (r *imageRepo) CreateTx(tx *gorm.DB, image *models.Image) error {
+	return tx.Create(image).Error
+}
+
+// FindByID retrieves image by ID. Document files are excluded; they are
+// served by the documents API which enforces per-document access.
 func (r *imageRepo) FindByID(id uuid.UUID) (*models.Image, error) {
 	var image models.Image
-	err := r.db.Where("id = ? AND is_deleted = false", id).First(&image).Error
+	err := r.db.Where("id = ? AND is_deleted = false AND image_type <> ?", id, models.ImageTypeDocument).First(&image).Error
 	if err != nil {
 		return nil, err
 	}
@@ -50,13 +57,13 @@ func (r *imageRepo) FindByUserID(userID uuid.UUID, page, pageSize int) ([]models
 
 	// Count total
 	if err := r.db.Model(&models.Image{}).
-		Where("user_id = ? AND is_deleted = false", userID).
+		Where("user_id = ? AND is_deleted = false AND image_type <> ?", userID, models.ImageTypeDocument).
 		Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	err := r.db.Where("user_id = ? AND is_deleted = false", userID).
+	err := r.db.Where("user_id = ? AND is_deleted = false AND image_type <> ?", userID, models.ImageTypeDocument).
 		Order("created_at DESC").
 		Limit(pageSize).
 		Offset(offset).
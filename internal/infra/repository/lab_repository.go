@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LabRepository interface {
+	// Transaction runs fn in a transaction, so a notification can be
+	// enqueued together with the change it announces
+	Transaction(fn func(tx *gorm.DB) error) error
+
+	CreateTest(test *models.LabTest) error
+	// UpdateTest saves the test's own fields and, when params is not nil,
+	// makes them its parameters: those with an ID are updated in place, the
+	// others created, and any parameter not in params is deleted
+	UpdateTest(test *models.LabTest, params []models.LabTestParameter) error
+	// CountOpenOrders counts the ordered or collected orders that include
+	// the test
+	CountOpenOrders(testID uuid.UUID) (int64, error)
+	FindTestByID(id uuid.UUID) (*models.LabTest, error)
+	FindTestsByIDs(ids []uuid.UUID) ([]models.LabTest, error)
+	ListTests(filter *dto.LabTestFilter) ([]models.LabTest, int64, error)
+
+	// CreateOrder inserts the order with its tests
+	CreateOrder(order *models.LabOrder) error
+	// FindOrderByID loads the order with its samples and its tests, each
+	// with the catalogue entry, parameters and results
+	FindOrderByID(id uuid.UUID) (*models.LabOrder, error)
+	// ListOrders returns a page of orders, newest first, with their tests
+	// and results
+	ListOrders(filter *dto.LabOrderFilter) ([]models.LabOrder, int64, error)
+	// SetStatus moves the order to status, with the extra updates, only
+	// while it is in one of from. It reports false when it was not. A nil
+	// tx runs it outside any transaction.
+	// VisibleTo reports whether userID ordered tests for the order's patient
+	// or was granted the order
+	VisibleTo(order *models.LabOrder, userID uuid.UUID) (bool, error)
+	// Grant is idempotent: granting twice keeps the original grant
+	Grant(access *models.LabOrderAccess) error
+	Revoke(orderID, userID uuid.UUID) error
+	SetStatus(tx *gorm.DB, id uuid.UUID, from []models.LabOrderStatus, status models.LabOrderStatus, updates map[string]interface{}) (bool, error)
+	AddSample(tx *gorm.DB, sample *models.LabSample) error
+	// ListSamples lists the samples collected for an order
+	ListSamples(tx *gorm.DB, orderID uuid.UUID) ([]models.LabSample, error)
+	// LockOrder locks the order row until tx ends and returns its status
+	LockOrder(tx *gorm.DB, id uuid.UUID) (models.LabOrderStatus, error)
+	// ResultedParameters lists the parameters one order test has results for
+	ResultedParameters(tx *gorm.DB, orderTestID uuid.UUID) ([]uuid.UUID, error)
+	// SaveResults upserts the results of one order test by parameter and
+	// updates the test's notes and resulted state
+	SaveResults(tx *gorm.DB, orderTest *models.LabOrderTest, results []models.LabResult) error
+}
+
+type labRepo struct {
+	db *gorm.DB
+}
+
+func LabNewRepository(db *gorm.DB) LabRepository {
+	return &labRepo{db: db}
+}
+
+func (r *labRepo) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *labRepo) CreateTest(test *models.LabTest) error {
+	return r.db.Create(test).Error
+}
+
+func (r *labRepo) UpdateTest(test *models.LabTest, params []models.LabTestParameter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(test).Error; err != nil {
+			return err
+		}
+		if params == nil {
+			return nil
+		}
+		// IDs are kept, so results already entered still match their parameter
+		keep := []uuid.UUID{uuid.Nil}
+		for i := range params {
+			params[i].LabTestID = test.ID
+			if params[i].ID != uuid.Nil {
+				keep = append(keep, params[i].ID)
+			}
+		}
+		if err := tx.Where("lab_test_id = ? AND id NOT IN ?", test.ID, keep).Delete(&models.LabTestParameter{}).Error; err != nil {
+			return err
+		}
+		for i := range params {
+			if params[i].ID == uuid.Nil {
+				if err := tx.Create(&params[i]).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Save(&params[i]).Error; err != nil {
+				return err
+			}
+		}
+		test.Parameters = params
+		return nil
+	})
+}
+
+func (r *labRepo) CountOpenOrders(testID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LabOrder{}).
+		Joins("JOIN lab_order_tests ON lab_order_tests.order_id = lab_orders.id").
+		Where("lab_order_tests.lab_test_id = ? AND lab_orders.status IN ?", testID,
+			[]models.LabOrderStatus{models.LabOrderOrdered, models.LabOrderCollected}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *labRepo) FindTestByID(id uuid.UUID) (*models.LabTest, error) {
+	var test models.LabTest
+	err := r.db.Preload("Parameters", orderByPosition).First(&test, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &test, nil
+}
+
+func (r *labRepo) FindTestsByIDs(ids []uuid.UUID) ([]models.LabTest, error) {
+	var tests []models.LabTest
+	err := r.db.Where("id IN ?", ids).Find(&tests).Error
+	return tests, err
+}
+
+func (r *labRepo) ListTests(filter *dto.LabTestFilter) ([]models.LabTest, int64, error) {
+	var tests []models.LabTest
+	var total int64
+
+	query := r.db.Model(&models.LabTest{})
+	if filter.Search != "" {
+		like := "%" + strings.ToLower(strings.TrimSpace(filter.Search)) + "%"
+		query = query.Where("LOWER(code) LIKE ? OR LOWER(name) LIKE ?", like, like)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("is_active = TRUE")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Preload("Parameters", orderByPosition).
+		Order("name").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Find(&tests).Error
+	return tests, total, err
+}
+
+func (r *labRepo) CreateOrder(order *models.LabOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tests := order.Tests
+		order.Tests = nil
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+		for i := range tests {
+			tests[i].OrderID = order.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&tests).Error; err != nil {
+			return err
+		}
+		order.Tests = tests
+		return nil
+	})
+}
+
+func (r *labRepo) FindOrderByID(id uuid.UUID) (*models.LabOrder, error) {
+	var order models.LabOrder
+	err := r.db.
+		Preload("Tests.LabTest.Parameters", orderByPosition).
+		Preload("Tests.Results", orderByPosition).
+		Preload("Samples", func(db *gorm.DB) *gorm.DB { return db.Order("collected_at") }).
+		First(&order, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *labRepo) ListOrders(filter *dto.LabOrderFilter) ([]models.LabOrder, int64, error) {
+	var orders []models.LabOrder
+	var total int64
+
+	query := r.db.Model(&models.LabOrder{})
+	if filter.PatientID != "" {
+		query = query.Where("patient_id = ?", filter.PatientID)
+	}
+	if filter.OrderedBy != "" {
+		query = query.Where("ordered_by = ?", filter.OrderedBy)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	if filter.VisibleTo != nil {
+		query = query.Where("(patient_id IN (?) OR id IN (?))",
+			r.db.Model(&models.LabOrder{}).Select("patient_id").Where("ordered_by = ?", *filter.VisibleTo),
+			r.db.Model(&models.LabOrderAccess{}).Select("order_id").Where("user_id = ?", *filter.VisibleTo))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.
+		Preload("Tests.LabTest").
+		Preload("Tests.Results", orderByPosition).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+func (r *labRepo) VisibleTo(order *models.LabOrder, userID uuid.UUID) (bool, error) {
+	if order.OrderedBy == userID {
+		return true, nil
+	}
+	var count int64
+	err := r.db.Model(&models.LabOrder{}).
+		Where("patient_id = ? AND ordered_by = ?", order.PatientID, userID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = r.db.Model(&models.LabOrderAccess{}).
+		Where("order_id = ? AND user_id = ?", order.ID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *labRepo) Grant(access *models.LabOrderAccess) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(access).Error
+}
+
+func (r *labRepo) Revoke(orderID, userID uuid.UUID) error {
+	return r.db.Where("order_id = ? AND user_id = ?", orderID, userID).Delete(&models.LabOrderAccess{}).Error
+}
+
+func (r *labRepo) SetStatus(tx *gorm.DB, id uuid.UUID, from []models.LabOrderStatus, status models.LabOrderStatus, updates map[string]interface{}) (bool, error) {
+	values := map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	for k, v := range updates {
+		values[k] = v
+	}
+	if tx == nil {
+		tx = r.db
+	}
+	result := tx.Model(&models.LabOrder{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(values)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *labRepo) AddSample(tx *gorm.DB, sample *models.LabSample) error {
+	return tx.Create(sample).Error
+}
+
+func (r *labRepo) ListSamples(tx *gorm.DB, orderID uuid.UUID) ([]models.LabSample, error) {
+	var samples []models.LabSample
+	err := tx.Where("order_id = ?", orderID).Find(&samples).Error
+	return samples, err
+}
+
+func (r *labRepo) LockOrder(tx *gorm.DB, id uuid.UUID) (models.LabOrderStatus, error) {
+	var order models.LabOrder
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		First(&order, "id = ?", id).Error
+	return order.Status, err
+}
+
+func (r *labRepo) ResultedParameters(tx *gorm.DB, orderTestID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&models.LabResult{}).Where("order_test_id = ?", orderTestID).Pluck("parameter_id", &ids).Error
+	return ids, err
+}
+
+func (r *labRepo) SaveResults(tx *gorm.DB, orderTest *models.LabOrderTest, results []models.LabResult) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "order_test_id"}, {Name: "parameter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "value", "unit", "ref_low", "ref_high", "reference_text", "flag", "position", "entered_by", "entered_at",
+		}),
+	}).Create(&results).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.LabOrderTest{}).Where("id = ?", orderTest.ID).Updates(map[string]interface{}{
+		"notes":       orderTest.Notes,
+		"resulted_by": orderTest.ResultedBy,
+		"resulted_at": orderTest.ResultedAt,
+	}).Error
+}
+
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
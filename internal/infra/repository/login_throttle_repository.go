@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+	"hospital_management_system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository interface {
+	FindByKey(key string) (*models.LoginThrottle, error)
+	RecordFailure(key, scope string, window time.Duration, lockAfter int, lockFor time.Duration) (*models.LoginThrottle, bool, error)
+	Reset(key string) error
+}
+
+type loginThrottleRepo struct {
+	db *gorm.DB
+}
+
+func LoginThrottleNewRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepo{db: db}
+}
+
+// FindByKey returns nil when there are no recorded failures
+func (r *loginThrottleRepo) FindByKey(key string) (*models.LoginThrottle, error) {
+	var t models.LoginThrottle
+	err := r.db.Where("key = ?", key).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RecordFailure increments the counter in a single upsert, so concurrent failures
+// for the same key are all counted. Counters older than window, or whose lock has
+// expired, start over. It reports whether this failure just triggered a lockout.
+func (r *loginThrottleRepo) RecordFailure(key, scope string, window time.Duration, lockAfter int, lockFor time.Duration) (*models.LoginThrottle, bool, error) {
+	now := time.Now()
+	// Postgres keeps microseconds; truncating lets the returned lock be matched
+	until := now.Add(lockFor).Truncate(time.Microsecond)
+
+	restart := gorm.Expr("login_throttles.last_failed_at IS NULL OR login_throttles.last_failed_at < ? OR login_throttles.locked_until < ?",
+		now.Add(-window), now)
+	count := gorm.Expr("CASE WHEN (?) THEN 1 ELSE login_throttles.failed_count + 1 END", restart)
+
+	result := models.LoginThrottle{Key: key, Scope: scope, FailedCount: 1, LastFailedAt: &now}
+	if lockAfter <= 1 {
+		result.LockedUntil = &until
+	}
+
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_count":   count,
+				"last_failed_at": now,
+				"locked_until": gorm.Expr("CASE WHEN login_throttles.locked_until >= ? THEN login_throttles.locked_until WHEN ? >= ? THEN ?::timestamptz ELSE NULL END",
+					now, count, lockAfter, until),
+				"updated_at": now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&result).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	newlyLocked := result.LockedUntil != nil && result.LockedUntil.Equal(until)
+	return &result, newlyLocked, nil
+}
+
+// Reset clears the counter and any lock
+func (r *loginThrottleRepo) Reset(key string) error {
+	return r.db.Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"errors"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	Create(n *models.Notification) error
+	CreateTx(tx *gorm.DB, n *models.Notification) error
+	// FindByID returns nil when the notification does not exist
+	FindByID(id uuid.UUID) (*models.Notification, error)
+	// Show fills in a hidden notification's title and body and puts it in
+	// the inbox
+	Show(n *models.Notification) error
+	// MarkDelivered records that channel has delivered the notification
+	MarkDelivered(id uuid.UUID, channel string) error
+	List(filter *dto.NotificationFilter) ([]models.Notification, int64, error)
+	UnreadCount(userID uuid.UUID) (int64, error)
+	// MarkRead reports false when the notification is not the user's
+	MarkRead(userID, id uuid.UUID) (bool, error)
+	MarkAllRead(userID uuid.UUID) (int64, error)
+
+	ListPreferences(userID uuid.UUID) ([]models.NotificationPreference, error)
+	SavePreferences(prefs []models.NotificationPreference) error
+}
+
+type notificationRepo struct {
+	db *gorm.DB
+}
+
+func NotificationNewRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) Create(n *models.Notification) error {
+	return r.db.Create(n).Error
+}
+
+func (r *notificationRepo) CreateTx(tx *gorm.DB, n *models.Notification) error {
+	return tx.Create(n).Error
+}
+
+func (r *notificationRepo) FindByID(id uuid.UUID) (*models.Notification, error) {
+	var n models.Notification
+	err := r.db.First(&n, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *notificationRepo) Show(n *models.Notification) error {
+	return r.db.Model(&models.Notification{}).Where("id = ?", n.ID).Updates(map[string]interface{}{
+		"title":  n.Title,
+		"body":   n.Body,
+		"hidden": false,
+	}).Error
+}
+
+func (r *notificationRepo) MarkDelivered(id uuid.UUID, channel string) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ?", id).
+		Update("delivered", gorm.Expr("COALESCE(delivered, '[]'::jsonb) || to_jsonb(?::text)", channel)).Error
+}
+
+func (r *notificationRepo) List(filter *dto.NotificationFilter) ([]models.Notification, int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("user_id = ? AND NOT hidden", filter.UserID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.Notification
+	err := query.Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&items).Error
+	return items, total, err
+}
+
+func (r *notificationRepo) UnreadCount(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND NOT hidden", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepo) MarkRead(userID, id uuid.UUID) (bool, error) {
+	var n models.Notification
+	if err := r.db.Select("id").First(&n, "id = ? AND user_id = ? AND NOT hidden", id, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	// Already-read notifications keep their original read time
+	err := r.db.Model(&models.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", time.Now()).Error
+	return true, err
+}
+
+func (r *notificationRepo) MarkAllRead(userID uuid.UUID) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND NOT hidden", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepo) ListPreferences(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+// SavePreferences upserts each (user, event, channel) setting
+func (r *notificationRepo) SavePreferences(prefs []models.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&prefs).Error
+}
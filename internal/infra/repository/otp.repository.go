@@ -9,7 +9,7 @@ import (
 
 // Repository defines database operations for OTP
 type OtpRepository interface {
-	SaveOTP(otp *models.OTP) error
+	SaveOTP(tx *gorm.DB, otp *models.OTP) error
 	GetOTPByCodeAndEmail(email string, code string) (*models.OTP, error)
 	MarkOTPUsed(tx *gorm.DB, id uuid.UUID) error
 	MarkUserVerified(tx *gorm.DB, email string) error
@@ -26,9 +26,9 @@ func OtpNewRepository(db *gorm.DB) OtpRepository {
 	return &otpRepo{db: db}
 }
 
-// SaveOTP inserts new OTP into DB
-func (r *otpRepo) SaveOTP(otp *models.OTP) error {
-	return r.db.Create(otp).Error
+// SaveOTP inserts new OTP into DB as part of tx
+func (r *otpRepo) SaveOTP(tx *gorm.DB, otp *models.OTP) error {
+	return tx.Create(otp).Error
 }
 
 // GetOTPByCode fetches OTP by userID, code, and purpose
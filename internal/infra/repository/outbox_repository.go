@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	// Transaction runs fn in a transaction that business rows and outbox
+	// messages are written in together
+	Transaction(fn func(tx *gorm.DB) error) error
+	// Add writes msgs as part of tx, the caller's business transaction
+	Add(tx *gorm.DB, msgs ...*models.OutboxMessage) error
+	// Dispatch locks up to limit pending messages, skipping rows another
+	// relay holds, and hands them to publish in order as one batch; publish
+	// reports an error, or nil, per message. Published rows are marked
+	// dispatched, failed ones are pushed back by retryAfter.
+	Dispatch(limit int, publish func([]models.OutboxMessage) []error, retryAfter func(attempts int) time.Duration) (int, error)
+	// PurgeDispatched deletes messages dispatched before the given time
+	PurgeDispatched(before time.Time) (int64, error)
+}
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func OutboxNewRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepo{db: db}
+}
+
+func (r *outboxRepo) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *outboxRepo) Add(tx *gorm.DB, msgs ...*models.OutboxMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	return tx.Create(msgs).Error
+}
+
+func (r *outboxRepo) Dispatch(limit int, publish func([]models.OutboxMessage) []error, retryAfter func(attempts int) time.Duration) (int, error) {
+	published := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var msgs []models.OutboxMessage
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= ?", models.OutboxPending, time.Now()).
+			Order("created_at").
+			Limit(limit).
+			Find(&msgs).Error; err != nil {
+			return err
+		}
+
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		errs := publish(msgs)
+		for i := range msgs {
+			msg := &msgs[i]
+			if errs[i] != nil {
+				if err := tx.Model(msg).Updates(map[string]interface{}{
+					"attempts":     msg.Attempts + 1,
+					"last_error":   errs[i].Error(),
+					"available_at": time.Now().Add(retryAfter(msg.Attempts + 1)),
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(msg).Updates(map[string]interface{}{
+				"status":        models.OutboxDispatched,
+				"dispatched_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+func (r *outboxRepo) PurgeDispatched(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND dispatched_at < ?", models.OutboxDispatched, before).
+		Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -11,6 +11,7 @@ type PaymentRepository interface {
 	GetAll() ([]models.Payment, error)
 	GetByTranID(tranID string) (*models.Payment, error)
 	Update(payment *models.Payment) error
+	UpdateTx(tx *gorm.DB, payment *models.Payment) error
 }
 
 type paymentRepository struct {
@@ -32,7 +33,11 @@ func (r *paymentRepository) GetByTranID(tranID string) (*models.Payment, error)
 }
 
 func (r *paymentRepository) Update(payment *models.Payment) error {
-	return r.db.Save(payment).Error
+	return r.UpdateTx(r.db, payment)
+}
+
+func (r *paymentRepository) UpdateTx(tx *gorm.DB, payment *models.Payment) error {
+	return tx.Save(payment).Error
 }
 
 func (r *paymentRepository) GetAll() ([]models.Payment, error) {
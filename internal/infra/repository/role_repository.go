@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Create(role *models.Role) (*models.Role, error)
+	GetByID(id string) (*models.Role, error)
+	GetByName(name string) (*models.Role, error)
+	GetAll() ([]models.Role, error)
+	Update(role *models.Role) (*models.Role, error)
+	ReplacePermissions(role *models.Role, perms []models.Permission) error
+	Delete(role *models.Role) error
+	CountUsers(roleName string) (int64, error)
+
+	CreatePermission(perm *models.Permission) (*models.Permission, error)
+	FirstOrCreatePermission(perm *models.Permission) (*models.Permission, bool, error)
+	GetAllPermissions() ([]models.Permission, error)
+	FindPermissionsByKeys(keys []string) ([]models.Permission, error)
+}
+
+type roleRepo struct {
+	db *gorm.DB
+}
+
+func RoleNewRepository(db *gorm.DB) RoleRepository {
+	return &roleRepo{db: db}
+}
+
+func (r *roleRepo) Create(role *models.Role) (*models.Role, error) {
+	if err := r.db.Create(role).Error; err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// GetByID loads a role with its permissions
+func (r *roleRepo) GetByID(id string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Preload("Permissions").Where("id = ?", id).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetByName loads a role with its permissions
+func (r *roleRepo) GetByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepo) GetAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepo) Update(role *models.Role) (*models.Role, error) {
+	if err := r.db.Model(&models.Role{}).
+		Where("id = ?", role.ID).
+		Updates(map[string]interface{}{
+			"description":        role.Description,
+			"require_two_factor": role.Require2FA,
+		}).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(role.ID.String())
+}
+
+// ReplacePermissions swaps the role's permission set for the given one
+func (r *roleRepo) ReplacePermissions(role *models.Role, perms []models.Permission) error {
+	return r.db.Model(role).Association("Permissions").Replace(perms)
+}
+
+// Delete removes the role and its permission links
+func (r *roleRepo) Delete(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Role{}, "id = ?", role.ID).Error
+	})
+}
+
+// CountUsers counts active users currently holding the role
+func (r *roleRepo) CountUsers(roleName string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("role = ? AND is_deleted = FALSE", roleName).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepo) CreatePermission(perm *models.Permission) (*models.Permission, error) {
+	if err := r.db.Create(perm).Error; err != nil {
+		return nil, err
+	}
+	return perm, nil
+}
+
+// FirstOrCreatePermission returns the existing resource/action pair or inserts it,
+// reporting whether it was created
+func (r *roleRepo) FirstOrCreatePermission(perm *models.Permission) (*models.Permission, bool, error) {
+	var existing models.Permission
+	result := r.db.
+		Where(models.Permission{Resource: perm.Resource, Action: perm.Action}).
+		Attrs(models.Permission{Description: perm.Description}).
+		FirstOrCreate(&existing)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	return &existing, result.RowsAffected > 0, nil
+}
+
+func (r *roleRepo) GetAllPermissions() ([]models.Permission, error) {
+	var perms []models.Permission
+	err := r.db.Order("resource ASC, action ASC").Find(&perms).Error
+	return perms, err
+}
+
+// FindPermissionsByKeys resolves "resource:action" keys to permission rows
+func (r *roleRepo) FindPermissionsByKeys(keys []string) ([]models.Permission, error) {
+	var perms []models.Permission
+	if len(keys) == 0 {
+		return perms, nil
+	}
+	err := r.db.Where("CONCAT(resource, ':', action) IN ?", keys).Find(&perms).Error
+	return perms, err
+}
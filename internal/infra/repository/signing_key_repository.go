@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Arbitrary constant identifying the rotation advisory lock
+const signingKeyLockID = 70301
+
+type SigningKeyRepository interface {
+	FindUsable(now time.Time) ([]models.SigningKey, error)
+	Create(key *models.SigningKey) error
+	DeleteExpired(before time.Time) error
+	// WithLock runs fn in a transaction holding a cluster-wide lock so only one
+	// instance rotates at a time
+	WithLock(fn func(repo SigningKeyRepository) error) error
+}
+
+type signingKeyRepo struct {
+	db *gorm.DB
+}
+
+func SigningKeyNewRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepo{db: db}
+}
+
+// FindUsable returns keys that can still verify tokens, newest first
+func (r *signingKeyRepo) FindUsable(now time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("verify_until > ?", now).Order("active_from DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepo) Create(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *signingKeyRepo) DeleteExpired(before time.Time) error {
+	return r.db.Where("verify_until <= ?", before).Delete(&models.SigningKey{}).Error
+}
+
+func (r *signingKeyRepo) WithLock(fn func(repo SigningKeyRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeyLockID).Error; err != nil {
+			return err
+		}
+		return fn(&signingKeyRepo{db: tx})
+	})
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// storageGCLockID is the pg advisory lock held while collecting orphans
+const storageGCLockID = 70360
+
+// StorageGCRepository gathers every reference to a stored file
+type StorageGCRepository interface {
+	// ReferencedKeys returns the storage keys held by image rows, including
+	// soft-deleted ones and generated variants
+	ReferencedKeys() ([]string, error)
+	// ReferencedURLs returns the links saved on rooms, doctors and patients
+	ReferencedURLs() ([]string, error)
+	// TryLock runs fn unless another instance is already collecting,
+	// reporting whether fn ran
+	TryLock(fn func() error) (bool, error)
+}
+
+type storageGCRepo struct {
+	db *gorm.DB
+}
+
+func StorageGCNewRepository(db *gorm.DB) StorageGCRepository {
+	return &storageGCRepo{db: db}
+}
+
+func (r *storageGCRepo) ReferencedKeys() ([]string, error) {
+	var keys []string
+	err := r.db.Raw(`
+		SELECT public_id FROM images WHERE public_id <> ''
+		UNION SELECT thumbnail_public_id FROM images WHERE thumbnail_public_id <> ''
+		UNION SELECT medium_public_id FROM images WHERE medium_public_id <> ''`).
+		Scan(&keys).Error
+	return keys, err
+}
+
+func (r *storageGCRepo) ReferencedURLs() ([]string, error) {
+	var urls []string
+	sources := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.Room{}, "image"},
+		{&models.Doctor{}, "profile_image_url"},
+		{&models.Patient{}, "profile_image_url"},
+	}
+	for _, src := range sources {
+		var found []string
+		err := r.db.Unscoped().Model(src.model).
+			Where(src.column+" IS NOT NULL AND "+src.column+" <> ''").
+			Distinct().
+			Pluck(src.column, &found).Error
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, found...)
+	}
+	return urls, nil
+}
+
+func (r *storageGCRepo) TryLock(fn func() error) (bool, error) {
+	ran := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", storageGCLockID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		ran = true
+		return fn()
+	})
+	return ran, err
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"errors"
+	"hospital_management_system/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	FindByUserID(userID uuid.UUID) (*models.TwoFactor, error)
+	Save(tf *models.TwoFactor) error
+	Enable(userID uuid.UUID, step int64, codeHashes []string) error
+	// UseStep records a TOTP step as used, reporting false when it or a
+	// later one already was, so the same code cannot pass twice
+	UseStep(userID uuid.UUID, step int64) (bool, error)
+	DeleteByUserID(userID uuid.UUID) error
+
+	ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error)
+}
+
+type twoFactorRepo struct {
+	db *gorm.DB
+}
+
+func TwoFactorNewRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepo{db: db}
+}
+
+// FindByUserID returns nil when the user never enrolled
+func (r *twoFactorRepo) FindByUserID(userID uuid.UUID) (*models.TwoFactor, error) {
+	var tf models.TwoFactor
+	err := r.db.Where("user_id = ?", userID).First(&tf).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+func (r *twoFactorRepo) Save(tf *models.TwoFactor) error {
+	return r.db.Save(tf).Error
+}
+
+// Enable switches on 2FA and stores the first batch of recovery codes atomically
+func (r *twoFactorRepo) Enable(userID uuid.UUID, step int64, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"is_enabled":     true,
+				"enabled_at":     now,
+				"last_used_step": step,
+			}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *twoFactorRepo) UseStep(userID uuid.UUID, step int64) (bool, error) {
+	res := r.db.Model(&models.TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// DeleteByUserID removes the enrollment together with its recovery codes
+func (r *twoFactorRepo) DeleteByUserID(userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error
+	})
+}
+
+func (r *twoFactorRepo) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// UseRecoveryCode marks a matching unused code as used and reports whether one was found
+func (r *twoFactorRepo) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *twoFactorRepo) CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: h})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"hospital_management_system/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadSessionRepository interface {
+	Create(session *models.UploadSession) error
+	FindByID(id uuid.UUID) (*models.UploadSession, error)
+	// TransitionStatus moves the session from one status to another, reporting
+	// false when it was not in the expected status
+	TransitionStatus(id uuid.UUID, from, to string) (bool, error)
+	Complete(id, resultID uuid.UUID) error
+	// Fail marks an assembling session failed, keeping the result that was
+	// already created so the client can find it
+	Fail(id, resultID uuid.UUID) error
+	Delete(id uuid.UUID) error
+	DeleteExpired(before time.Time) (int64, error)
+
+	SaveChunk(chunk *models.UploadChunk) error
+	FindChunk(sessionID uuid.UUID, index int) (*models.UploadChunk, error)
+	ChunkIndexes(sessionID uuid.UUID) ([]int, error)
+}
+
+type uploadSessionRepo struct {
+	db *gorm.DB
+}
+
+func UploadSessionNewRepository(db *gorm.DB) UploadSessionRepository {
+	return &uploadSessionRepo{db: db}
+}
+
+func (r *uploadSessionRepo) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *uploadSessionRepo) FindByID(id uuid.UUID) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := r.db.First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *uploadSessionRepo) TransitionStatus(id uuid.UUID, from, to string) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Complete records the result and drops the chunks, which are no longer needed
+func (r *uploadSessionRepo) Complete(id, resultID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UploadSession{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"status":     models.UploadStatusCompleted,
+				"result_id":  resultID,
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Where("session_id = ?", id).Delete(&models.UploadChunk{}).Error
+	})
+}
+
+func (r *uploadSessionRepo) Fail(id, resultID uuid.UUID) error {
+	return r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, models.UploadStatusAssembling).
+		Updates(map[string]interface{}{
+			"status":     models.UploadStatusFailed,
+			"result_id":  resultID,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// Delete removes the session together with its chunks
+func (r *uploadSessionRepo) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", id).Delete(&models.UploadChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.UploadSession{}, "id = ?", id).Error
+	})
+}
+
+// DeleteExpired removes sessions (and chunks) that expired before the given time
+func (r *uploadSessionRepo) DeleteExpired(before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.UploadSession{}).Select("id").Where("expires_at < ?", before)
+		if err := tx.Where("session_id IN (?)", expired).Delete(&models.UploadChunk{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("expires_at < ?", before).Delete(&models.UploadSession{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// SaveChunk stores a chunk, replacing an earlier copy of the same index so
+// clients can safely resend after a dropped connection
+func (r *uploadSessionRepo) SaveChunk(chunk *models.UploadChunk) error {
+	chunk.CreatedAt = time.Now()
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "chunk_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "checksum", "data", "created_at"}),
+	}).Create(chunk).Error
+}
+
+func (r *uploadSessionRepo) FindChunk(sessionID uuid.UUID, index int) (*models.UploadChunk, error) {
+	var chunk models.UploadChunk
+	if err := r.db.First(&chunk, "session_id = ? AND chunk_index = ?", sessionID, index).Error; err != nil {
+		return nil, err
+	}
+	return &chunk, nil
+}
+
+func (r *uploadSessionRepo) ChunkIndexes(sessionID uuid.UUID) ([]int, error) {
+	var indexes []int
+	err := r.db.Model(&models.UploadChunk{}).
+		Where("session_id = ?", sessionID).
+		Order("chunk_index ASC").
+		Pluck("chunk_index", &indexes).Error
+	return indexes, err
+}
@@ -15,6 +15,7 @@ type UserRepository interface {
 	FindByEmail(email string) (*models.User, error)        // user with doctor preloaded
 	FindByEmailTx(tx *gorm.DB, email string) (*models.User, error)
 	FindByID(id string) (*models.User, error)             // user with doctor preloaded
+	UpdateRole(id string, role string) error
 }
 
 type userRepo struct {
@@ -71,3 +72,11 @@ func (r *userRepo) FindByID(id string) (*models.User, error) {
 	}
 	return &user, err
 }
+
+
+// Update the role assigned to a user
+func (r *userRepo) UpdateRole(id string, role string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND is_deleted = ?", id, false).
+		Update("role", role).Error
+}
@@ -0,0 +1,52 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// fakeKeep bounds how many messages the fake remembers
+const fakeKeep = 100
+
+// Message is a text the fake sender accepted
+type Message struct {
+	To     string
+	Body   string
+	SentAt time.Time
+}
+
+// Fake logs messages instead of sending them, for local development
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Send(ctx context.Context, to, body string) error {
+	if to == "" {
+		return errors.New("no phone number")
+	}
+
+	f.mu.Lock()
+	f.messages = append(f.messages, Message{To: to, Body: body, SentAt: time.Now()})
+	if len(f.messages) > fakeKeep {
+		f.messages = f.messages[len(f.messages)-fakeKeep:]
+	}
+	f.mu.Unlock()
+
+	log.Printf("SMS (fake) to %s: %s", to, body)
+	return nil
+}
+
+// Messages returns the most recent messages, oldest first
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
@@ -0,0 +1,27 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"hospital_management_system/config"
+)
+
+// Drivers selectable through SMS_DRIVER
+const (
+	DriverFake = "fake"
+)
+
+// Sender delivers a text message through an SMS provider
+type Sender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// NewFromConfig builds the sender selected by SMS_DRIVER
+func NewFromConfig() (Sender, error) {
+	switch config.ENV.SMSDriver {
+	case DriverFake:
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_DRIVER %q", config.ENV.SMSDriver)
+	}
+}
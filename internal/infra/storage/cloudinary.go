@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/cloudinary/cloudinary-go/v2/asset"
+)
+
+// cloudinaryStorage stores files on Cloudinary.
+// Images and PDFs are "image" assets whose public IDs carry no extension;
+// anything else (e.g. DICOM) is a "raw" asset whose public ID keeps it,
+// which is how the resource type is recovered from a key later on.
+//
+// Public objects are plain uploads with permanent URLs. Private objects are
+// "authenticated" assets kept under the private/ folder. Cloudinary cannot
+// expire their links on its own, so they are streamed through our signed
+// /files endpoint and the signed Cloudinary URL never leaves the server.
+type cloudinaryStorage struct {
+	cld    *cloudinary.Cloudinary
+	signer *URLSigner
+}
+
+const cloudinaryPrivateFolder = "private"
+
+func NewCloudinary(cloudName, apiKey, apiSecret string, signer *URLSigner) (Storage, error) {
+	if cloudName == "" || apiKey == "" || apiSecret == "" {
+		return nil, errors.New("cloudinary credentials are not configured")
+	}
+	if signer == nil {
+		return nil, errors.New("cloudinary storage needs a URL signer")
+	}
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudinaryStorage{cld: cld, signer: signer}, nil
+}
+
+func (s *cloudinaryStorage) Put(ctx context.Context, body io.Reader, opts PutOptions) (*Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Sniff the type rather than trusting the caller's, as the other drivers do
+	buffered := bufio.NewReader(body)
+	head, _ := buffered.Peek(512)
+	contentType := SniffContentType(head)
+	body = buffered
+
+	name := newKey("", contentType)
+	params := uploader.UploadParams{
+		PublicID:       strings.TrimSuffix(name, path.Ext(name)),
+		Folder:         opts.Folder,
+		ResourceType:   "image",
+		Transformation: "q_auto,f_auto",
+	}
+	if !isCloudinaryImage(contentType) {
+		params.PublicID = name
+		params.ResourceType = "raw"
+		params.Transformation = ""
+	}
+	if opts.Private {
+		params.Folder = path.Join(cloudinaryPrivateFolder, opts.Folder)
+		params.Type = api.Authenticated
+	}
+
+	result, err := s.cld.Upload.Upload(ctx, body, params)
+	if err != nil {
+		return nil, fmt.Errorf("Cloudinary upload failed: %w", err)
+	}
+
+	obj := &Object{
+		Key:         result.PublicID,
+		FileName:    opts.FileName,
+		ContentType: contentType,
+		Size:        opts.Size,
+		Width:       result.Width,
+		Height:      result.Height,
+	}
+	if !opts.Private {
+		obj.URL = result.SecureURL
+	}
+	return obj, nil
+}
+
+func (s *cloudinaryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	link, err := s.deliveryURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cloudinary returned %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// URL returns the delivery URL of a public object, which does not expire,
+// or a link through /files that is valid for ttl for a private one
+func (s *cloudinaryStorage) URL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if deliveryType(key) == api.Authenticated {
+		return s.signer.Sign(key, ttl), nil
+	}
+	return s.deliveryURL(key)
+}
+
+// deliveryURL builds the Cloudinary URL of key, signed when the asset is private
+func (s *cloudinaryStorage) deliveryURL(key string) (string, error) {
+	var (
+		a   *asset.Asset
+		err error
+	)
+	if resourceType(key) == "raw" {
+		a, err = s.cld.File(key)
+	} else {
+		a, err = s.cld.Image(key)
+	}
+	if err != nil {
+		return "", err
+	}
+	a.Config.URL.Secure = true
+	if deliveryType(key) == api.Authenticated {
+		a.DeliveryType = api.Authenticated
+		a.Config.URL.SignURL = true
+	}
+	return a.String()
+}
+
+func (s *cloudinaryStorage) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     key,
+		Type:         string(deliveryType(key)),
+		ResourceType: resourceType(key),
+	})
+	return err
+}
+
+// List walks image and raw assets of both delivery types. Private assets
+// live under private/, so that prefix is searched for them.
+func (s *cloudinaryStorage) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	sources := []struct {
+		assetType    api.AssetType
+		deliveryType string
+		prefix       string
+	}{
+		{api.Image, string(api.Upload), prefix},
+		{api.File, string(api.Upload), prefix},
+		{api.Image, api.Authenticated, path.Join(cloudinaryPrivateFolder, prefix)},
+		{api.File, api.Authenticated, path.Join(cloudinaryPrivateFolder, prefix)},
+	}
+
+	for _, src := range sources {
+		cursor := ""
+		for {
+			result, err := s.cld.Admin.Assets(ctx, admin.AssetsParams{
+				AssetType:    src.assetType,
+				DeliveryType: src.deliveryType,
+				Prefix:       src.prefix,
+				MaxResults:   500,
+				NextCursor:   cursor,
+			})
+			if err != nil {
+				return err
+			}
+			if result.Error.Message != "" {
+				return fmt.Errorf("cloudinary: %s", result.Error.Message)
+			}
+			for _, a := range result.Assets {
+				if err := fn(ObjectInfo{Key: a.PublicID, Size: int64(a.Bytes), LastModified: a.CreatedAt}); err != nil {
+					return err
+				}
+			}
+			if result.NextCursor == "" {
+				break
+			}
+			cursor = result.NextCursor
+		}
+	}
+	return nil
+}
+
+// KeyFromURL parses delivery URLs of the form
+// https://res.cloudinary.com/<cloud>/<resource>/<type>/[transformations/][v123/]<public id>[.<format>]
+func (s *cloudinaryStorage) KeyFromURL(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Host != "res.cloudinary.com" {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != s.cld.Config.Cloud.CloudName {
+		return "", false
+	}
+	resource, rest := parts[1], parts[3:]
+
+	// Everything up to the version segment is signatures and transformations
+	for i, p := range rest {
+		if len(p) > 1 && p[0] == 'v' && strings.Trim(p[1:], "0123456789") == "" {
+			rest = rest[i+1:]
+			break
+		}
+	}
+	if len(rest) == 0 {
+		return "", false
+	}
+
+	key := strings.Join(rest, "/")
+	if resource == string(api.Image) {
+		key = strings.TrimSuffix(key, path.Ext(key))
+	}
+	return key, true
+}
+
+func isCloudinaryImage(contentType string) bool {
+	return contentType == "" || strings.HasPrefix(contentType, "image/") || contentType == "application/pdf"
+}
+
+func deliveryType(key string) api.DeliveryType {
+	if strings.HasPrefix(key, cloudinaryPrivateFolder+"/") {
+		return api.Authenticated
+	}
+	return api.Upload
+}
+
+func resourceType(key string) string {
+	if path.Ext(key) != "" {
+		return "raw"
+	}
+	return "image"
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ObjectInfo describes a stored object found while listing
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Inventory is implemented by drivers that can enumerate their objects.
+// It is used to find files that no database row points at any more.
+type Inventory interface {
+	// List calls fn for every object whose key starts with prefix
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	// KeyFromURL recovers the key from a link this driver handed out,
+	// reporting false for links it does not recognise
+	KeyFromURL(link string) (string, bool)
+}
+
+// keyAfter returns the unescaped path of link below base (which must be a
+// URL prefix such as https://host/api/v1/files), ignoring any query string
+func keyAfter(link, base string) (string, bool) {
+	base = strings.TrimRight(base, "/") + "/"
+	if !strings.HasPrefix(link, base) {
+		return "", false
+	}
+	rest := strings.TrimPrefix(link, base)
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	key, err := url.PathUnescape(rest)
+	if err != nil || key == "" {
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/config"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid object key")
+
+// localStorage keeps files on disk under root and serves them through signed links
+type localStorage struct {
+	root   string
+	signer *URLSigner
+	urlTTL time.Duration
+}
+
+func NewLocal(root string, signer *URLSigner) (Storage, error) {
+	if signer == nil {
+		return nil, errors.New("local storage needs a URL signer")
+	}
+	ttl, err := time.ParseDuration(config.ENV.StorageURLTTL)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid STORAGE_URL_TTL %q", config.ENV.StorageURLTTL)
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &localStorage{root: root, signer: signer, urlTTL: ttl}, nil
+}
+
+func (s *localStorage) Put(ctx context.Context, body io.Reader, opts PutOptions) (*Object, error) {
+	up, err := readAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	key := newKey(opts.Folder, up.contentType)
+	full, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+		return nil, err
+	}
+
+	// Write to a temp file first so readers never see a partial object
+	tmp := full + ".tmp"
+	if err := os.WriteFile(tmp, up.data, 0o640); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, full); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+
+	obj := &Object{
+		Key:         key,
+		FileName:    opts.FileName,
+		ContentType: up.contentType,
+		Size:        int64(len(up.data)),
+		Width:       up.width,
+		Height:      up.height,
+	}
+	if !opts.Private {
+		obj.URL = s.signer.Sign(key, s.urlTTL)
+	}
+	return obj, nil
+}
+
+func (s *localStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	full, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(full)
+}
+
+func (s *localStorage) URL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return s.signer.Sign(key, ttl), nil
+}
+
+// Resign issues a new link for a link this driver handed out earlier
+func (s *localStorage) Resign(link string) string {
+	key, ok := s.KeyFromURL(link)
+	if !ok {
+		return link
+	}
+	return s.signer.Sign(key, s.urlTTL)
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	full, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key to a file under root, rejecting anything that escapes it
+func (s *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+func (s *localStorage) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	root, err := filepath.Abs(s.root)
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(root, func(full string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || strings.HasSuffix(full, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, full)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *localStorage) KeyFromURL(link string) (string, bool) {
+	return keyAfter(link, s.signer.baseURL)
+}
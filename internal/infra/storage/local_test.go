@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hospital_management_system/config"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBaseURL = "http://localhost/api/v1/files"
+
+func newTestLocal(t *testing.T) (*localStorage, *URLSigner) {
+	t.Helper()
+	config.ENV = &config.Config{StorageURLTTL: "1h"}
+	signer := NewURLSigner("test-secret", testBaseURL)
+	s, err := NewLocal(t.TempDir(), signer)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	return s.(*localStorage), signer
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLocalPutOpenDelete(t *testing.T) {
+	s, _ := newTestLocal(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		body       []byte
+		opts       PutOptions
+		wantPrefix string
+		wantExt    string
+		wantType   string
+		wantURL    bool
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "public image",
+			body:       testPNG(t, 3, 2),
+			opts:       PutOptions{Folder: "room_images", FileName: "Room.PNG", ContentType: "image/png"},
+			wantPrefix: "room_images/",
+			wantExt:    ".png",
+			wantType:   "image/png",
+			wantURL:    true,
+			wantWidth:  3,
+			wantHeight: 2,
+		},
+		{
+			name:       "private document",
+			body:       []byte("%PDF-1.4 report"),
+			opts:       PutOptions{Folder: "documents/abc", FileName: "report.pdf", ContentType: "application/pdf", Private: true},
+			wantPrefix: "documents/abc/",
+			wantExt:    ".pdf",
+			wantType:   "application/pdf",
+		},
+		{
+			name:     "no folder",
+			body:     []byte("plain"),
+			opts:     PutOptions{FileName: "notes.txt"},
+			wantExt:  ".bin",
+			wantType: "application/octet-stream",
+			wantURL:  true,
+		},
+		{
+			name:     "html posing as an image",
+			body:     []byte("<html><script>alert(1)</script></html>"),
+			opts:     PutOptions{Folder: "user_profiles", FileName: "me.html", ContentType: "image/png"},
+			wantExt:  ".bin",
+			wantType: "application/octet-stream",
+			wantURL:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := s.Put(ctx, bytes.NewReader(tt.body), tt.opts)
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if !strings.HasPrefix(obj.Key, tt.wantPrefix) || !strings.HasSuffix(obj.Key, tt.wantExt) {
+				t.Errorf("key %q, want prefix %q and extension %q", obj.Key, tt.wantPrefix, tt.wantExt)
+			}
+			if obj.ContentType != tt.wantType {
+				t.Errorf("content type %q, want %q", obj.ContentType, tt.wantType)
+			}
+			if obj.Size != int64(len(tt.body)) {
+				t.Errorf("size %d, want %d", obj.Size, len(tt.body))
+			}
+			if (obj.URL != "") != tt.wantURL {
+				t.Errorf("URL %q, want a URL: %v", obj.URL, tt.wantURL)
+			}
+			if obj.Width != tt.wantWidth || obj.Height != tt.wantHeight {
+				t.Errorf("dimensions %dx%d, want %dx%d", obj.Width, obj.Height, tt.wantWidth, tt.wantHeight)
+			}
+
+			r, err := s.Open(ctx, obj.Key)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !bytes.Equal(got, tt.body) {
+				t.Errorf("read back %q, want %q", got, tt.body)
+			}
+
+			if err := s.Delete(ctx, obj.Key); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := s.Open(ctx, obj.Key); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Open after delete: %v, want not exist", err)
+			}
+			// Deleting twice is not an error
+			if err := s.Delete(ctx, obj.Key); err != nil {
+				t.Errorf("second Delete: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalPutLeavesNoTempFile(t *testing.T) {
+	s, _ := newTestLocal(t)
+
+	obj, err := s.Put(context.Background(), strings.NewReader("data"), PutOptions{Folder: "uploads", FileName: "a.txt"})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(s.root, "uploads"))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(obj.Key) {
+		t.Errorf("folder holds %v, want only %s", entries, filepath.Base(obj.Key))
+	}
+}
+
+func TestLocalRejectsInvalidKeys(t *testing.T) {
+	s, _ := newTestLocal(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/", "../secret", "uploads/../../etc/passwd", `uploads\file`} {
+		t.Run(key, func(t *testing.T) {
+			if _, err := s.Open(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Open: %v, want ErrInvalidKey", err)
+			}
+			if err := s.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Delete: %v, want ErrInvalidKey", err)
+			}
+			if _, err := s.URL(ctx, key, time.Minute); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("URL: %v, want ErrInvalidKey", err)
+			}
+		})
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	s, signer := newTestLocal(t)
+	ctx := context.Background()
+	const key = "documents/p 1/report.pdf"
+
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		tamper  func(q url.Values)
+		wantErr error
+	}{
+		{name: "valid", ttl: time.Minute},
+		{name: "expired", ttl: -time.Minute, wantErr: ErrURLExpired},
+		{name: "bad signature", ttl: time.Minute, tamper: func(q url.Values) { q.Set("signature", strings.Repeat("0", 64)) }, wantErr: ErrURLInvalidSignature},
+		{name: "extended expiry", ttl: time.Minute, tamper: func(q url.Values) { q.Set("expires", "9999999999") }, wantErr: ErrURLInvalidSignature},
+		{name: "bad expiry", ttl: time.Minute, tamper: func(q url.Values) { q.Set("expires", "soon") }, wantErr: ErrURLInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := s.URL(ctx, key, tt.ttl)
+			if err != nil {
+				t.Fatalf("URL: %v", err)
+			}
+
+			gotKey, ok := s.KeyFromURL(link)
+			if !ok || gotKey != key {
+				t.Fatalf("KeyFromURL(%q) = %q, %v; want %q", link, gotKey, ok, key)
+			}
+
+			u, err := url.Parse(link)
+			if err != nil {
+				t.Fatalf("parse %q: %v", link, err)
+			}
+			q := u.Query()
+			if tt.tamper != nil {
+				tt.tamper(q)
+			}
+			if err := signer.Verify(gotKey, q.Get("expires"), q.Get("signature")); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify: %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocalSignedURLIsBoundToKey(t *testing.T) {
+	s, signer := newTestLocal(t)
+
+	link, err := s.URL(context.Background(), "uploads/a.png", time.Minute)
+	if err != nil {
+		t.Fatalf("URL: %v", err)
+	}
+	u, _ := url.Parse(link)
+	q := u.Query()
+	if err := signer.Verify("uploads/b.png", q.Get("expires"), q.Get("signature")); !errors.Is(err, ErrURLInvalidSignature) {
+		t.Errorf("Verify for another key: %v, want ErrURLInvalidSignature", err)
+	}
+}
+
+func TestLocalKeyFromForeignURL(t *testing.T) {
+	s, _ := newTestLocal(t)
+
+	for _, link := range []string{
+		"https://res.cloudinary.com/demo/image/upload/sample.jpg",
+		"http://localhost/api/v1/filesystem/x",
+		testBaseURL + "/",
+	} {
+		if key, ok := s.KeyFromURL(link); ok {
+			t.Errorf("KeyFromURL(%q) = %q, want no match", link, key)
+		}
+	}
+}
+
+func TestLocalResign(t *testing.T) {
+	s, signer := newTestLocal(t)
+	const key = "user_profiles/a.png"
+
+	stale, err := s.URL(context.Background(), key, -time.Minute)
+	if err != nil {
+		t.Fatalf("URL: %v", err)
+	}
+	fresh := Resign(s, stale)
+	u, err := url.Parse(fresh)
+	if err != nil {
+		t.Fatalf("parse %q: %v", fresh, err)
+	}
+	q := u.Query()
+	if err := signer.Verify(key, q.Get("expires"), q.Get("signature")); err != nil {
+		t.Errorf("Verify re-signed link: %v", err)
+	}
+
+	foreign := "https://res.cloudinary.com/demo/image/upload/sample.jpg"
+	if got := Resign(s, foreign); got != foreign {
+		t.Errorf("Resign(%q) = %q, want it unchanged", foreign, got)
+	}
+}
+
+func TestServedContentType(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		head   []byte
+		want   string
+		inline bool
+	}{
+		{name: "recorded image", key: "room_images/a.png", want: "image/png", inline: true},
+		{name: "recorded pdf", key: "documents/p/a.pdf", want: "application/pdf", inline: true},
+		{name: "recorded dicom", key: "documents/p/a.dcm", want: "application/dicom"},
+		{name: "sniffed image", key: "private/scan", head: testPNG(t, 1, 1), want: "image/png", inline: true},
+		{name: "legacy html key", key: "user_profiles/x.html", head: []byte("<html><script>alert(1)</script>"), want: "application/octet-stream"},
+		{name: "legacy svg key", key: "user_profiles/x.svg", head: []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), want: "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TypeOfKey(tt.key)
+			if !ok {
+				got = SniffContentType(tt.head)
+			}
+			if got != tt.want {
+				t.Errorf("content type %q, want %q", got, tt.want)
+			}
+			if Inline(got) != tt.inline {
+				t.Errorf("Inline(%q) = %v, want %v", got, Inline(got), tt.inline)
+			}
+		})
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	s, _ := newTestLocal(t)
+	ctx := context.Background()
+
+	var want []string
+	for _, folder := range []string{"uploads/profile", "uploads/room", "documents"} {
+		obj, err := s.Put(ctx, strings.NewReader(folder), PutOptions{Folder: folder, FileName: "f.txt"})
+		if err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		if strings.HasPrefix(folder, "uploads/") {
+			want = append(want, obj.Key)
+		}
+	}
+
+	got := map[string]bool{}
+	err := s.List(ctx, "uploads/", func(info ObjectInfo) error {
+		got[info.Key] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("listed %v, want %v", got, want)
+	}
+	for _, key := range want {
+		if !got[key] {
+			t.Errorf("%s not listed", key)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Options struct {
+	Endpoint  string // host[:port], without scheme
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+}
+
+// s3Storage talks to any S3-compatible service such as MinIO. The bucket
+// should not be publicly readable: private objects are only reachable
+// through presigned links.
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3(opts S3Options) (Storage, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for the s3 storage driver")
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach S3 bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, opts.Bucket, minio.MakeBucketOptions{Region: opts.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create S3 bucket: %w", err)
+		}
+	}
+
+	return &s3Storage{client: client, bucket: opts.Bucket}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, body io.Reader, opts PutOptions) (*Object, error) {
+	up, err := readAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	key := newKey(opts.Folder, up.contentType)
+	putOpts := minio.PutObjectOptions{ContentType: up.contentType}
+	if !Inline(up.contentType) {
+		putOpts.ContentDisposition = "attachment"
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(up.data), int64(len(up.data)), putOpts)
+	if err != nil {
+		return nil, fmt.Errorf("S3 upload failed: %w", err)
+	}
+
+	obj := &Object{
+		Key:         key,
+		FileName:    opts.FileName,
+		ContentType: up.contentType,
+		Size:        int64(len(up.data)),
+		Width:       up.width,
+		Height:      up.height,
+	}
+	if !opts.Private {
+		obj.URL = s.objectURL(key)
+	}
+	return obj, nil
+}
+
+func (s *s3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy; Stat surfaces a missing key before streaming starts
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+// URL presigns a GET; S3 caps presigned links at seven days
+func (s *s3Storage) URL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	const maxTTL = 7 * 24 * time.Hour
+	if ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *s3Storage) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the listing goroutine if fn bails out early
+
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(ObjectInfo{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *s3Storage) KeyFromURL(link string) (string, bool) {
+	return keyAfter(link, strings.TrimRight(s.client.EndpointURL().String(), "/")+"/"+s.bucket)
+}
+
+// objectURL is the plain path-style URL, readable when the bucket policy allows it
+func (s *s3Storage) objectURL(key string) string {
+	return strings.TrimRight(s.client.EndpointURL().String(), "/") + "/" + s.bucket + "/" + escapeKey(key)
+}
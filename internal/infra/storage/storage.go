@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hospital_management_system/config"
+	"image"
+	_ "image/gif" // register decoders for DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Drivers selectable through STORAGE_DRIVER
+const (
+	DriverCloudinary = "cloudinary"
+	DriverLocal      = "local"
+	DriverS3         = "s3"
+)
+
+// Storage is an object store for uploaded files
+type Storage interface {
+	// Put stores the body under a new key inside opts.Folder
+	Put(ctx context.Context, body io.Reader, opts PutOptions) (*Object, error)
+	// Open streams a stored object
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// URL returns a link to the object that expires after ttl (private
+	// objects) or stays valid for at least ttl (public ones)
+	URL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type PutOptions struct {
+	Folder      string
+	FileName    string
+	ContentType string
+	Size        int64
+	// Private objects are never given a lasting public link; callers ask
+	// for a short-lived one through Storage.URL when it is needed
+	Private bool
+}
+
+// Object describes a stored file. Key is what gets persisted (images.public_id).
+// URL is empty for private objects.
+type Object struct {
+	Key         string
+	URL         string
+	FileName    string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+}
+
+// PutMultipart stores an uploaded form file whose content type the caller
+// has already sniffed and validated
+func PutMultipart(ctx context.Context, s Storage, file multipart.File, fileHeader *multipart.FileHeader, folder, contentType string) (*Object, error) {
+	return s.Put(ctx, file, PutOptions{
+		Folder:      folder,
+		FileName:    fileHeader.Filename,
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+	})
+}
+
+// PutPrivateMultipart stores an uploaded form file as a private object
+func PutPrivateMultipart(ctx context.Context, s Storage, file multipart.File, fileHeader *multipart.FileHeader, folder string) (*Object, error) {
+	return s.Put(ctx, file, PutOptions{
+		Folder:      folder,
+		FileName:    fileHeader.Filename,
+		ContentType: fileHeader.Header.Get("Content-Type"),
+		Size:        fileHeader.Size,
+		Private:     true,
+	})
+}
+
+// Resigner is implemented by drivers whose links expire, so links kept in
+// the database can be renewed each time they are read
+type Resigner interface {
+	// Resign returns a fresh link to the object behind link, or link itself
+	// when it did not come from this driver
+	Resign(link string) string
+}
+
+// Resign renews link when the driver supports it
+func Resign(s Storage, link string) string {
+	if r, ok := s.(Resigner); ok {
+		return r.Resign(link)
+	}
+	return link
+}
+
+// Discard deletes an object whose database row was never written. Failures
+// are only logged; the storage sweep picks up anything left behind.
+func Discard(ctx context.Context, s Storage, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		log.Printf("Failed to discard unused upload %s: %v", key, err)
+	}
+}
+
+// NewFromConfig builds the driver named by STORAGE_DRIVER
+func NewFromConfig(signer *URLSigner) (Storage, error) {
+	switch config.ENV.StorageDriver {
+	case DriverCloudinary:
+		return NewCloudinary(config.ENV.CloudinaryCloudName, config.ENV.CloudinaryApiKey, config.ENV.CloudinaryApiSecret, signer)
+	case DriverLocal:
+		return NewLocal(config.ENV.StorageLocalDir, signer)
+	case DriverS3:
+		return NewS3(S3Options{
+			Endpoint:  config.ENV.S3Endpoint,
+			AccessKey: config.ENV.S3AccessKey,
+			SecretKey: config.ENV.S3SecretKey,
+			Bucket:    config.ENV.S3Bucket,
+			Region:    config.ENV.S3Region,
+			UseSSL:    config.ENV.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", config.ENV.StorageDriver)
+	}
+}
+
+// servedTypes are the content types objects are stored and served as, with
+// the extension their keys get. Anything else is stored as opaque bytes.
+var servedTypes = map[string]string{
+	"image/jpeg":        ".jpg",
+	"image/png":         ".png",
+	"image/gif":         ".gif",
+	"image/webp":        ".webp",
+	"application/pdf":   ".pdf",
+	"application/dicom": ".dcm",
+}
+
+const opaqueType = "application/octet-stream"
+
+// SniffContentType detects the type of an object from its first bytes,
+// returning application/octet-stream for anything outside servedTypes
+func SniffContentType(head []byte) string {
+	// DICOM has a 128 byte preamble followed by "DICM"
+	if len(head) >= 132 && bytes.Equal(head[128:132], []byte("DICM")) {
+		return "application/dicom"
+	}
+	ct := http.DetectContentType(head)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	if _, ok := servedTypes[ct]; !ok {
+		return opaqueType
+	}
+	return ct
+}
+
+// TypeOfKey returns the content type recorded in the key's extension when
+// the object was stored, reporting false for keys outside servedTypes
+func TypeOfKey(key string) (string, bool) {
+	ext := strings.ToLower(path.Ext(key))
+	for ct, e := range servedTypes {
+		if e == ext {
+			return ct, true
+		}
+	}
+	return "", false
+}
+
+// Inline reports whether browsers may display the type in place; anything
+// else is only offered as a download
+func Inline(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") || contentType == "application/pdf"
+}
+
+// newKey builds a unique object key whose extension comes from the sniffed
+// content type, never from the client's file name
+func newKey(folder, contentType string) string {
+	ext, ok := servedTypes[contentType]
+	if !ok {
+		ext = ".bin"
+	}
+	name := uuid.New().String() + ext
+	if folder == "" {
+		return name
+	}
+	return path.Join(folder, name)
+}
+
+// upload is a buffered body with what could be learnt from its bytes
+type upload struct {
+	data          []byte
+	contentType   string
+	width, height int
+}
+
+// readAll buffers the body, sniffs its content type and reports image
+// dimensions when it is a decodable image. Uploads are capped well below
+// what makes buffering a concern.
+func readAll(body io.Reader) (*upload, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	u := &upload{data: data, contentType: SniffContentType(data)}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		u.width, u.height = cfg.Width, cfg.Height
+	}
+	return u, nil
+}
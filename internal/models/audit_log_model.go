@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditActionLogin          = "login"
+	AuditActionLoginFailed    = "login_failed"
+	AuditActionTwoFactorReset = "two_factor_reset"
+	AuditActionAccountUnlock  = "account_unlock"
+	AuditActionView           = "view"
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionStatusChange   = "status_change"
+	AuditActionDelete         = "delete"
+	AuditActionRoleAssign     = "role_assign"
+	AuditActionAccessGrant    = "access_grant"
+	AuditActionAccessRevoke   = "access_revoke"
+	AuditActionDownload       = "download"
+	AuditActionActivate       = "activate"
+	AuditActionRequeue        = "requeue"
+	AuditActionResend         = "resend"
+)
+
+const (
+	AuditResourceUser     = "user"
+	AuditResourceRole     = "role"
+	AuditResourceBooking  = "booking"
+	AuditResourceImage    = "image"
+	AuditResourceDocument = "document"
+	AuditResourceStorage  = "storage"
+
+	AuditResourceEmailTemplate    = "email_template"
+	AuditResourceEmail            = "email"
+	AuditResourceEmailSuppression = "email_suppression"
+
+	AuditResourceLabTest  = "lab_test"
+	AuditResourceLabOrder = "lab_order"
+
+	AuditResourceUserImages = "user_images" // listing of every image owned by a user
+)
+
+// AuditLog is an append-only record of a security relevant action.
+// Rows are never updated or deleted; the migration installs a trigger enforcing it.
+type AuditLog struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ActorID      *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"` // nil for anonymous or system actions
+	ActorEmail   string     `gorm:"type:varchar(255)" json:"actor_email"`
+	ActorRole    string     `gorm:"type:varchar(50)" json:"actor_role"`
+	Action       string     `gorm:"type:varchar(50);not null;index" json:"action"`
+	ResourceType string     `gorm:"type:varchar(50);not null;index:idx_audit_resource" json:"resource_type"`
+	ResourceID   string     `gorm:"type:varchar(100);index:idx_audit_resource" json:"resource_id"`
+	Before       JSON       `gorm:"type:jsonb" json:"before,omitempty"`
+	After        JSON       `gorm:"type:jsonb" json:"after,omitempty"`
+	Changes      JSON       `gorm:"type:jsonb" json:"changes,omitempty"` // field → {"from", "to"}
+	IP           string     `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent    string     `gorm:"type:text" json:"user_agent"`
+	CreatedAt    time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	a.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ReminderPending    = "pending"
+	ReminderProcessing = "processing" // claimed by a scheduler; see LockedUntil
+	ReminderSent       = "sent"
+	ReminderCanceled   = "canceled"
+	ReminderFailed     = "failed"
+)
+
+// BookingReminder is a reminder waiting to go out before a booking. Rows are
+// persisted so pending reminders survive restarts, and each is claimed by one
+// scheduler at a time.
+type BookingReminder struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	BookingID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_booking_reminder_offset" json:"booking_id"`
+	PatientID   uuid.UUID  `gorm:"type:uuid;not null" json:"patient_id"` // the patient's user ID
+	Offset      string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_booking_reminder_offset" json:"offset"`
+	RemindAt    time.Time  `gorm:"not null;index:idx_booking_reminder_due" json:"remind_at"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_booking_reminder_due" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (r *BookingReminder) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	now := time.Now()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	return nil
+}
+
+func (r *BookingReminder) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
@@ -46,3 +46,9 @@ func (d *Doctor) BeforeUpdate(tx *gorm.DB) error {
 	d.UpdatedAt = time.Now()
 	return nil
 }
+
+// AfterFind hook: renew the stored profile image link
+func (d *Doctor) AfterFind(tx *gorm.DB) error {
+	resignURL(d.ProfileImageURL)
+	return nil
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DocumentCategory string
+
+const (
+	DocumentLabReport        DocumentCategory = "lab_report"
+	DocumentRadiology        DocumentCategory = "radiology"
+	DocumentDischargeSummary DocumentCategory = "discharge_summary"
+	DocumentIdentity         DocumentCategory = "identity"
+)
+
+// ImageTypeDocument marks image rows that hold document versions. They are
+// only reachable through the documents API, which enforces per-document access.
+const ImageTypeDocument = "document"
+
+func IsValidDocumentCategory(c DocumentCategory) bool {
+	switch c {
+	case DocumentLabReport, DocumentRadiology, DocumentDischargeSummary, DocumentIdentity:
+		return true
+	}
+	return false
+}
+
+// PatientDocument is a versioned file in a patient's record
+type PatientDocument struct {
+	ID             uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	PatientID      uuid.UUID        `gorm:"type:uuid;not null;index" json:"patient_id"` // the patient's user ID
+	Title          string           `gorm:"type:varchar(255);not null" json:"title"`
+	Category       DocumentCategory `gorm:"type:varchar(50);not null;index" json:"category"`
+	Description    string           `gorm:"type:text" json:"description,omitempty"`
+	CurrentVersion int              `gorm:"not null;default:1" json:"current_version"`
+	UploadedBy     uuid.UUID        `gorm:"type:uuid;not null" json:"uploaded_by"`
+	IsDeleted      bool             `gorm:"default:false;index" json:"is_deleted"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+
+	Versions []DocumentVersion `gorm:"foreignKey:DocumentID" json:"versions,omitempty"`
+	Tags     []DocumentTag     `gorm:"foreignKey:DocumentID" json:"tags,omitempty"`
+	Grants   []DocumentAccess  `gorm:"foreignKey:DocumentID" json:"grants,omitempty"`
+}
+
+func (d *PatientDocument) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	now := time.Now()
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	return nil
+}
+
+func (d *PatientDocument) BeforeUpdate(tx *gorm.DB) error {
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// DocumentVersion points at the stored file for one revision of a document
+type DocumentVersion struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_document_version" json:"document_id"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_document_version" json:"version"`
+	ImageID    uuid.UUID `gorm:"type:uuid;not null" json:"image_id"`
+	Image      Image     `gorm:"foreignKey:ImageID" json:"file"`
+	UploadedBy uuid.UUID `gorm:"type:uuid;not null" json:"uploaded_by"`
+	Note       string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (v *DocumentVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	v.CreatedAt = time.Now()
+	return nil
+}
+
+type DocumentTag struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_document_tag" json:"-"`
+	Tag        string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_document_tag;index" json:"tag"`
+}
+
+func (t *DocumentTag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// DocumentAccess grants a user (typically a treating doctor) access to one document.
+// The patient and admins always have access and need no grant.
+type DocumentAccess struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_document_access" json:"document_id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_document_access;index" json:"user_id"`
+	GrantedBy  uuid.UUID `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (a *DocumentAccess) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	a.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailAttachment is a file sent with an email, such as an invoice. The
+// content is kept with the email so it can be requeued or resent.
+type EmailAttachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	EmailID     uuid.UUID `gorm:"type:uuid;not null;index" json:"email_id"`
+	FileName    string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int       `gorm:"not null" json:"size"`
+	Content     []byte    `gorm:"type:bytea;not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (a *EmailAttachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	a.Size = len(a.Content)
+	return nil
+}
@@ -13,30 +13,103 @@ type EmailStatus string
 const (
 	EmailTypeOTP                 EmailType = "otp"
 	EmailTypeBookingConfirmation EmailType = "booking_confirmation"
+	EmailTypeBookingUpdate       EmailType = "booking_update"
+	EmailTypeBookingReminder     EmailType = "booking_reminder"
 	EmailTypePasswordReset       EmailType = "password_reset"
 	EmailTypeProfileUpdate       EmailType = "profile_update"
 	EmailTypePaymentReceipt      EmailType = "payment_receipt"
+	EmailTypePaymentFailed       EmailType = "payment_failed"
+	EmailTypeAccountLocked       EmailType = "account_locked"
+	EmailTypeLabResultsReady     EmailType = "lab_results_ready"
+	EmailTypeLabCriticalResult   EmailType = "lab_critical_result"
+	EmailTypeMarketing           EmailType = "marketing" // newsletters and announcements
 	EmailTypeOther               EmailType = "other"
 
-	EmailStatusPending EmailStatus = "pending"
-	EmailStatusSent    EmailStatus = "sent"
-	EmailStatusFailed  EmailStatus = "failed"
+	EmailStatusPending    EmailStatus = "pending"
+	EmailStatusRetrying   EmailStatus = "retrying" // waiting in a retry queue
+	EmailStatusSent       EmailStatus = "sent"
+	EmailStatusFailed     EmailStatus = "failed"     // out of attempts, or rejected outright
+	EmailStatusBounced    EmailStatus = "bounced"    // accepted, then returned by the recipient's server
+	EmailStatusSuppressed EmailStatus = "suppressed" // never sent: the address is suppressed for this type
 )
 
+// TemplatedEmailTypes are the email types rendered from a template
+var TemplatedEmailTypes = []EmailType{
+	EmailTypeOTP,
+	EmailTypeBookingConfirmation,
+	EmailTypeBookingUpdate,
+	EmailTypeBookingReminder,
+	EmailTypePasswordReset,
+	EmailTypeProfileUpdate,
+	EmailTypePaymentReceipt,
+	EmailTypePaymentFailed,
+	EmailTypeAccountLocked,
+	EmailTypeLabResultsReady,
+	EmailTypeLabCriticalResult,
+}
+
+func IsTemplatedEmailType(t EmailType) bool {
+	for _, typ := range TemplatedEmailTypes {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// NonTransactionalEmailTypes are the types a recipient may unsubscribe from.
+// Everything else is needed to use an account, booking or payment.
+var NonTransactionalEmailTypes = []EmailType{
+	EmailTypeBookingReminder,
+	EmailTypeMarketing,
+}
+
+func IsTransactionalEmailType(t EmailType) bool {
+	for _, typ := range NonTransactionalEmailTypes {
+		if typ == t {
+			return false
+		}
+	}
+	return true
+}
+
+// SecretEmailTypes carry one-time codes or reset links. Their bodies are
+// never shown back, not even to staff who can read the email log.
+var SecretEmailTypes = []EmailType{
+	EmailTypeOTP,
+	EmailTypePasswordReset,
+}
+
+func IsSecretEmailType(t EmailType) bool {
+	for _, typ := range SecretEmailTypes {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
 type Email struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	Email     string         `gorm:"type:varchar(255);not null" json:"email"`
-	Subject   string         `gorm:"type:varchar(255);not null" json:"subject"`
-	Body      string         `gorm:"type:text;not null" json:"body"`
-	Type      EmailType      `gorm:"type:varchar(50);not null" json:"type"`
-	Status    EmailStatus    `gorm:"type:varchar(50);default:'pending';not null" json:"status"`
-	Error     *string        `gorm:"type:text" json:"error"`
-	SentAt    *time.Time     `json:"sent_at"`
-	IsDeleted bool           `gorm:"default:false" json:"is_deleted"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID            uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID        uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	Email         string            `gorm:"type:varchar(255);not null" json:"email"`
+	Subject       string            `gorm:"type:varchar(255);not null" json:"subject"`
+	Body          string            `gorm:"type:text;not null" json:"body"`
+	TextBody      string            `gorm:"type:text" json:"text_body,omitempty"` // plain-text alternative
+	Type          EmailType         `gorm:"type:varchar(50);not null" json:"type"`
+	Status        EmailStatus       `gorm:"type:varchar(50);default:'pending';not null" json:"status"`
+	Error         *string           `gorm:"type:text" json:"error"`
+	Attempts      int               `gorm:"default:0;not null" json:"attempts"`
+	LastAttemptAt *time.Time        `json:"last_attempt_at"`
+	SentAt        *time.Time        `json:"sent_at"`
+	BouncedAt     *time.Time        `json:"bounced_at"`
+	OpenedAt      *time.Time        `json:"opened_at"` // first open; only tracked for marketing mail
+	Opens         int               `gorm:"default:0;not null" json:"opens"`
+	Attachments   []EmailAttachment `gorm:"foreignKey:EmailID" json:"attachments,omitempty"`
+	IsDeleted     bool              `gorm:"default:false" json:"is_deleted"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `gorm:"index" json:"deleted_at"`
 }
 
 // BeforeCreate hook: auto-generate UUID and timestamps
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EmailSuppressionReason string
+
+const (
+	SuppressionHardBounce  EmailSuppressionReason = "hard_bounce"
+	SuppressionComplaint   EmailSuppressionReason = "complaint" // reported as spam
+	SuppressionUnsubscribe EmailSuppressionReason = "unsubscribe"
+)
+
+// EmailSuppression stops mail to an address. An address has at most one
+// row per reason.
+type EmailSuppression struct {
+	ID        uuid.UUID              `gorm:"type:uuid;primaryKey" json:"id"`
+	Email     string                 `gorm:"type:varchar(255);not null;uniqueIndex:idx_email_suppression" json:"email"` // lowercased
+	Reason    EmailSuppressionReason `gorm:"type:varchar(50);not null;uniqueIndex:idx_email_suppression" json:"reason"`
+	Detail    *string                `gorm:"type:text" json:"detail"`   // e.g. the bounce diagnostic
+	EmailID   *uuid.UUID             `gorm:"type:uuid" json:"email_id"` // the email that caused it
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+func (s *EmailSuppression) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Blocks reports whether the suppression stops mail of type t. A hard
+// bounce stops everything; complaints and unsubscribes only stop mail the
+// recipient can do without.
+func (s EmailSuppression) Blocks(t EmailType) bool {
+	if s.Reason == SuppressionHardBounce {
+		return true
+	}
+	return !IsTransactionalEmailType(t)
+}
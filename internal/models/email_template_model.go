@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailTemplate is one saved version of the template for an email type. At
+// most one version per type is active; with none active the bundled default
+// is used.
+type EmailTemplate struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Type      EmailType `gorm:"type:varchar(50);not null;uniqueIndex:idx_email_template_version" json:"type"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_email_template_version" json:"version"`
+	Subject   string    `gorm:"type:varchar(255);not null" json:"subject"`
+	HTMLBody  string    `gorm:"type:text;not null" json:"html_body"`
+	TextBody  string    `gorm:"type:text" json:"text_body"`
+	Note      string    `gorm:"type:text" json:"note,omitempty"`
+	IsActive  bool      `gorm:"default:false;index" json:"is_active"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (t *EmailTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	return nil
+}
+
+func (t *EmailTemplate) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
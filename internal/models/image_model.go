@@ -8,10 +8,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// Processing states of an uploaded image
+const (
+	ImageStatusPending = "pending"
+	ImageStatusReady   = "ready"
+	ImageStatusFailed  = "failed"
+)
+
+// Variants generated by the image worker, as requested through GET /images/{id}/url
+const (
+	ImageVariantThumbnail = "thumbnail"
+	ImageVariantMedium    = "medium"
+)
+
 type Image struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
 	UserID       uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
-	URL          string         `gorm:"type:varchar(500);not null" json:"url"`
+	URL          string         `gorm:"type:varchar(500);not null" json:"url,omitempty"` // empty for private files; see GET /images/{id}/url
 	PublicID     string         `gorm:"type:varchar(255);not null" json:"public_id"`
 	FileName     string         `gorm:"type:varchar(255)" json:"file_name"`
 	FileSize     int64          `gorm:"type:bigint" json:"file_size"`
@@ -19,6 +32,13 @@ type Image struct {
 	Width        int            `gorm:"type:int" json:"width"`
 	Height       int            `gorm:"type:int" json:"height"`
 	ImageType    string         `gorm:"type:varchar(50);default:'general'" json:"image_type"` // profile, document, general
+
+	// Set by the image worker once EXIF has been stripped and variants made
+	ThumbnailPublicID string `gorm:"type:varchar(255)" json:"thumbnail_public_id,omitempty"`
+	MediumPublicID    string `gorm:"type:varchar(255)" json:"medium_public_id,omitempty"`
+	Status            string `gorm:"type:varchar(20);default:'ready';index" json:"status"` // pending, ready, failed
+	ProcessingError   string `gorm:"type:text" json:"processing_error,omitempty"`
+
 	IsDeleted    bool           `gorm:"default:false;index" json:"is_deleted"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
@@ -36,6 +56,22 @@ func (i *Image) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// AfterFind hook: renew the stored link
+func (i *Image) AfterFind(tx *gorm.DB) error {
+	resignURL(&i.URL)
+	return nil
+}
+
+// ResignURL renews a stored link to an uploaded file. It is set at startup
+// when the storage driver's links expire; nil keeps links as stored.
+var ResignURL func(link string) string
+
+func resignURL(link *string) {
+	if link != nil && *link != "" && ResignURL != nil {
+		*link = ResignURL(*link)
+	}
+}
+
 // TableName specifies table name
 func (Image) TableName() string {
 	return "images"
@@ -0,0 +1,275 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LabOrderStatus string
+type LabOrderPriority string
+type LabResultFlag string
+type LabValueType string
+
+const (
+	LabOrderOrdered   LabOrderStatus = "ordered"
+	LabOrderCollected LabOrderStatus = "collected" // every sample the tests need has been taken
+	LabOrderCompleted LabOrderStatus = "completed" // results released to the patient record
+	LabOrderCancelled LabOrderStatus = "cancelled"
+
+	LabPriorityRoutine LabOrderPriority = "routine"
+	LabPriorityUrgent  LabOrderPriority = "urgent"
+	LabPriorityStat    LabOrderPriority = "stat"
+
+	LabFlagNormal       LabResultFlag = "normal"
+	LabFlagLow          LabResultFlag = "low"
+	LabFlagHigh         LabResultFlag = "high"
+	LabFlagCriticalLow  LabResultFlag = "critical_low"
+	LabFlagCriticalHigh LabResultFlag = "critical_high"
+	LabFlagAbnormal     LabResultFlag = "abnormal" // a text result other than the expected one
+
+	LabValueNumeric LabValueType = "numeric"
+	LabValueText    LabValueType = "text"
+)
+
+func IsValidLabOrderPriority(p LabOrderPriority) bool {
+	switch p {
+	case LabPriorityRoutine, LabPriorityUrgent, LabPriorityStat:
+		return true
+	}
+	return false
+}
+
+// LabTest is an orderable test in the lab catalogue, e.g. a complete blood
+// count, with the parameters its result reports
+type LabTest struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Code        string    `gorm:"type:varchar(30);not null;uniqueIndex" json:"code"`
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	SampleType  string    `gorm:"type:varchar(50);not null" json:"sample_type"` // blood, urine, swab, ...
+	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	IsActive    bool      `gorm:"default:true;not null" json:"is_active"` // inactive tests cannot be ordered
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Parameters []LabTestParameter `gorm:"foreignKey:LabTestID" json:"parameters,omitempty"`
+}
+
+func (t *LabTest) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	return nil
+}
+
+func (t *LabTest) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// LabTestParameter is one measured value of a test and its reference range.
+// Numeric parameters are flagged against the low/high bounds, either of
+// which may be open; text parameters against the expected ReferenceText.
+type LabTestParameter struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	LabTestID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"lab_test_id"`
+	Name          string       `gorm:"type:varchar(100);not null" json:"name"`
+	Unit          string       `gorm:"type:varchar(30)" json:"unit,omitempty"`
+	ValueType     LabValueType `gorm:"type:varchar(20);not null" json:"value_type"`
+	RefLow        *float64     `json:"ref_low,omitempty"`
+	RefHigh       *float64     `json:"ref_high,omitempty"`
+	CriticalLow   *float64     `json:"critical_low,omitempty"`
+	CriticalHigh  *float64     `json:"critical_high,omitempty"`
+	ReferenceText string       `gorm:"type:varchar(100)" json:"reference_text,omitempty"` // e.g. Negative
+	Position      int          `gorm:"not null" json:"position"`
+}
+
+func (p *LabTestParameter) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// Evaluate flags a result value against the parameter's reference range
+func (p LabTestParameter) Evaluate(value string) (LabResultFlag, error) {
+	value = strings.TrimSpace(value)
+	if p.ValueType == LabValueText {
+		if p.ReferenceText == "" || strings.EqualFold(value, p.ReferenceText) {
+			return LabFlagNormal, nil
+		}
+		return LabFlagAbnormal, nil
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", errors.New(p.Name + " must be a number")
+	}
+	switch {
+	case p.CriticalLow != nil && v <= *p.CriticalLow:
+		return LabFlagCriticalLow, nil
+	case p.CriticalHigh != nil && v >= *p.CriticalHigh:
+		return LabFlagCriticalHigh, nil
+	case p.RefLow != nil && v < *p.RefLow:
+		return LabFlagLow, nil
+	case p.RefHigh != nil && v > *p.RefHigh:
+		return LabFlagHigh, nil
+	}
+	return LabFlagNormal, nil
+}
+
+// LabOrder is a doctor's request for tests on a patient. It moves from
+// ordered to collected as samples are taken, then to completed when the lab
+// releases the results.
+type LabOrder struct {
+	ID            uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	PatientID     uuid.UUID        `gorm:"type:uuid;not null;index" json:"patient_id"` // the patient's user ID
+	OrderedBy     uuid.UUID        `gorm:"type:uuid;not null;index" json:"ordered_by"`
+	Priority      LabOrderPriority `gorm:"type:varchar(20);not null" json:"priority"`
+	Status        LabOrderStatus   `gorm:"type:varchar(20);not null;index" json:"status"`
+	ClinicalNotes string           `gorm:"type:text" json:"clinical_notes,omitempty"`
+	CancelReason  *string          `gorm:"type:text" json:"cancel_reason,omitempty"`
+	ReleasedBy    *uuid.UUID       `gorm:"type:uuid" json:"released_by,omitempty"`
+	ReleasedAt    *time.Time       `json:"released_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+
+	Tests   []LabOrderTest `gorm:"foreignKey:OrderID" json:"tests,omitempty"`
+	Samples []LabSample    `gorm:"foreignKey:OrderID" json:"samples,omitempty"`
+}
+
+func (o *LabOrder) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	now := time.Now()
+	o.CreatedAt = now
+	o.UpdatedAt = now
+	return nil
+}
+
+func (o *LabOrder) BeforeUpdate(tx *gorm.DB) error {
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// SampleTypesNeeded lists the sample types the order's tests are run on;
+// Tests must be loaded with their LabTest
+func (o *LabOrder) SampleTypesNeeded() map[string]bool {
+	needed := make(map[string]bool, len(o.Tests))
+	for _, t := range o.Tests {
+		needed[strings.ToLower(t.LabTest.SampleType)] = true
+	}
+	return needed
+}
+
+// SamplesComplete reports whether every needed sample type has been collected
+func (o *LabOrder) SamplesComplete() bool {
+	collected := make(map[string]bool, len(o.Samples))
+	for _, s := range o.Samples {
+		collected[strings.ToLower(s.SampleType)] = true
+	}
+	for sampleType := range o.SampleTypesNeeded() {
+		if !collected[sampleType] {
+			return false
+		}
+	}
+	return true
+}
+
+// LabOrderTest is one test of an order. ResultedAt is set once every
+// parameter has a result.
+type LabOrderTest struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_lab_order_test" json:"order_id"`
+	LabTestID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_lab_order_test" json:"lab_test_id"`
+	LabTest    LabTest    `gorm:"foreignKey:LabTestID" json:"lab_test"`
+	Notes      string     `gorm:"type:text" json:"notes,omitempty"`
+	ResultedBy *uuid.UUID `gorm:"type:uuid" json:"resulted_by,omitempty"`
+	ResultedAt *time.Time `json:"resulted_at,omitempty"`
+
+	Results []LabResult `gorm:"foreignKey:OrderTestID" json:"results,omitempty"`
+}
+
+func (t *LabOrderTest) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// LabSample is a specimen taken for an order, labelled with its barcode
+type LabSample struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID     uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	SampleType  string    `gorm:"type:varchar(50);not null" json:"sample_type"`
+	Barcode     string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"barcode"`
+	CollectedBy uuid.UUID `gorm:"type:uuid;not null" json:"collected_by"`
+	CollectedAt time.Time `json:"collected_at"`
+	Notes       string    `gorm:"type:text" json:"notes,omitempty"`
+}
+
+func (s *LabSample) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// LabOrderAccess shares one order with a staff member, e.g. a consulting
+// doctor. Lab staff, admins, the patient and the doctors who order tests
+// for the patient need no grant.
+type LabOrderAccess struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lab_order_access" json:"order_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lab_order_access;index" json:"user_id"`
+	GrantedBy uuid.UUID `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (a *LabOrderAccess) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	a.CreatedAt = time.Now()
+	return nil
+}
+
+// LabResult is the value measured for one parameter. The parameter's name,
+// unit and reference range are copied in, so editing the catalogue later
+// does not change how a past result reads.
+type LabResult struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderTestID   uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_lab_result" json:"order_test_id"`
+	ParameterID   uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_lab_result" json:"parameter_id"`
+	Name          string        `gorm:"type:varchar(100);not null" json:"name"`
+	Value         string        `gorm:"type:varchar(255);not null" json:"value"`
+	Unit          string        `gorm:"type:varchar(30)" json:"unit,omitempty"`
+	RefLow        *float64      `json:"ref_low,omitempty"`
+	RefHigh       *float64      `json:"ref_high,omitempty"`
+	ReferenceText string        `gorm:"type:varchar(100)" json:"reference_text,omitempty"`
+	Flag          LabResultFlag `gorm:"type:varchar(20);not null" json:"flag"`
+	Position      int           `gorm:"not null" json:"position"`
+	EnteredBy     uuid.UUID     `gorm:"type:uuid;not null" json:"entered_by"`
+	EnteredAt     time.Time     `json:"entered_at"`
+}
+
+func (r *LabResult) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Critical reports a flag that needs the ordering doctor's attention now
+func (f LabResultFlag) Critical() bool {
+	return f == LabFlagCriticalLow || f == LabFlagCriticalHigh
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginThrottle counts recent failed logins for one account or one client IP
+type LoginThrottle struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Key          string     `gorm:"type:varchar(320);not null;uniqueIndex" json:"key"` // "<scope>:<email|ip>"
+	Scope        string     `gorm:"type:varchar(20);not null" json:"scope"`
+	FailedCount  int        `gorm:"not null;default:0" json:"failed_count"`
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ThrottleKey builds the unique key for a scope and subject
+func ThrottleKey(scope, subject string) string {
+	return scope + ":" + subject
+}
+
+// BeforeCreate hook
+func (t *LoginThrottle) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook
+func (t *LoginThrottle) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationEvent is something a user can be notified about. Events share
+// their names with the email types used to render them.
+type NotificationEvent string
+
+const (
+	NotificationOTP                 = NotificationEvent(EmailTypeOTP)
+	NotificationPasswordReset       = NotificationEvent(EmailTypePasswordReset)
+	NotificationAccountLocked       = NotificationEvent(EmailTypeAccountLocked)
+	NotificationBookingConfirmation = NotificationEvent(EmailTypeBookingConfirmation)
+	NotificationBookingUpdate       = NotificationEvent(EmailTypeBookingUpdate)
+	NotificationBookingReminder     = NotificationEvent(EmailTypeBookingReminder)
+	NotificationPaymentReceipt      = NotificationEvent(EmailTypePaymentReceipt)
+	NotificationPaymentFailed       = NotificationEvent(EmailTypePaymentFailed)
+	NotificationProfileUpdate       = NotificationEvent(EmailTypeProfileUpdate)
+	NotificationLabResultsReady     = NotificationEvent(EmailTypeLabResultsReady)
+	NotificationLabCriticalResult   = NotificationEvent(EmailTypeLabCriticalResult)
+)
+
+// Channels a notification can be delivered through
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelInApp = "in_app"
+)
+
+// NotificationChannels lists every channel, in delivery order
+var NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelSMS}
+
+// NotificationEventConfig says where an event goes when the user has not
+// chosen. Required channels cannot be switched off, so account security
+// messages always arrive.
+type NotificationEventConfig struct {
+	Defaults []string
+	Required []string
+}
+
+var NotificationEvents = map[NotificationEvent]NotificationEventConfig{
+	NotificationOTP:                 {Defaults: []string{ChannelEmail}, Required: []string{ChannelEmail}},
+	NotificationPasswordReset:       {Defaults: []string{ChannelEmail}, Required: []string{ChannelEmail}},
+	NotificationAccountLocked:       {Defaults: []string{ChannelEmail, ChannelInApp}, Required: []string{ChannelEmail}},
+	NotificationBookingConfirmation: {Defaults: []string{ChannelEmail, ChannelInApp}},
+	NotificationBookingUpdate:       {Defaults: []string{ChannelEmail, ChannelInApp}},
+	NotificationBookingReminder:     {Defaults: []string{ChannelEmail, ChannelInApp}},
+	NotificationPaymentReceipt:      {Defaults: []string{ChannelEmail, ChannelInApp}},
+	NotificationPaymentFailed:       {Defaults: []string{ChannelEmail, ChannelInApp}},
+	NotificationProfileUpdate:       {Defaults: []string{ChannelInApp}},
+	NotificationLabResultsReady:     {Defaults: []string{ChannelEmail, ChannelInApp}},
+	NotificationLabCriticalResult:   {Defaults: []string{ChannelInApp, ChannelEmail, ChannelSMS}, Required: []string{ChannelInApp, ChannelEmail}},
+}
+
+// AllNotificationEvents in a NotificationPreference applies to every event
+// without a more specific preference
+const AllNotificationEvents NotificationEvent = "*"
+
+func IsValidNotificationChannel(c string) bool {
+	for _, ch := range NotificationChannels {
+		if ch == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is an entry in a user's in-app inbox
+type Notification struct {
+	ID        uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID         `gorm:"type:uuid;not null;index:idx_notification_user_created" json:"user_id"`
+	Event     NotificationEvent `gorm:"type:varchar(50);not null" json:"event"`
+	Title     string            `gorm:"type:varchar(255);not null" json:"title"`
+	Body      string            `gorm:"type:text;not null" json:"body"`
+	Data      JSON              `gorm:"type:jsonb" json:"data,omitempty"`
+	ReadAt    *time.Time        `json:"read_at"`
+	CreatedAt time.Time         `gorm:"index:idx_notification_user_created" json:"created_at"`
+	// Rows recorded through the outbox stay Hidden from the inbox until the
+	// in-app channel has delivered them
+	Hidden bool `gorm:"not null;default:false" json:"-"`
+	// Delivered lists the channels that have delivered the notification, so
+	// a retried job only repeats the ones that failed
+	Delivered JSON `gorm:"type:jsonb" json:"-"`
+}
+
+// DeliveredTo reports whether the channel has already delivered n
+func (n *Notification) DeliveredTo(channel string) bool {
+	var channels []string
+	if len(n.Delivered) > 0 {
+		_ = json.Unmarshal(n.Delivered, &channels)
+	}
+	return slices.Contains(channels, channel)
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	n.CreatedAt = time.Now()
+	return nil
+}
+
+// NotificationPreference turns one channel on or off for one event, or for
+// all events when Event is "*"
+type NotificationPreference struct {
+	UserID    uuid.UUID         `gorm:"type:uuid;primaryKey" json:"-"`
+	Event     NotificationEvent `gorm:"type:varchar(50);primaryKey" json:"event"`
+	Channel   string            `gorm:"type:varchar(20);primaryKey" json:"channel"`
+	Enabled   bool              `gorm:"not null" json:"enabled"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (p *NotificationPreference) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OutboxPending    = "pending"
+	OutboxDispatched = "dispatched"
+)
+
+// OutboxMessage is a message written in the same transaction as the change
+// it announces. The outbox relay publishes it once that transaction has
+// committed, so the message is neither lost when the broker is down nor
+// sent for a change that was rolled back.
+type OutboxMessage struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Exchange     string     `gorm:"type:varchar(100);not null;default:''" json:"exchange"` // "" is the default exchange
+	RoutingKey   string     `gorm:"type:varchar(255);not null" json:"routing_key"`
+	Type         string     `gorm:"type:varchar(100);not null" json:"type"`
+	Payload      JSON       `gorm:"type:jsonb;not null" json:"payload"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_pending" json:"status"`
+	AvailableAt  time.Time  `gorm:"not null;index:idx_outbox_pending" json:"available_at"` // pushed back after a failed publish
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// NewOutboxMessage builds a pending message carrying payload as JSON
+func NewOutboxMessage(exchange, routingKey, typ string, payload interface{}) (*OutboxMessage, error) {
+	body, err := ToJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Type:       typ,
+		Payload:    body,
+		Status:     OutboxPending,
+	}, nil
+}
+
+func (m *OutboxMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	now := time.Now()
+	m.CreatedAt = now
+	if m.AvailableAt.IsZero() {
+		m.AvailableAt = now
+	}
+	return nil
+}
@@ -45,3 +45,9 @@ func (p *Patient) BeforeUpdate(tx *gorm.DB) error {
 	p.UpdatedAt = time.Now()
 	return nil
 }
+
+// AfterFind hook: renew the stored profile image link
+func (p *Patient) AfterFind(tx *gorm.DB) error {
+	resignURL(p.ProfileImageURL)
+	return nil
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Resources that permissions can be granted on
+const (
+	ResourceUsers     = "users"
+	ResourceRoles     = "roles"
+	ResourcePatients  = "patients"
+	ResourceBookings  = "bookings"
+	ResourcePayments  = "payments"
+	ResourceRooms     = "rooms"
+	ResourceServices  = "services"
+	ResourceImages    = "images"
+	ResourceDocuments = "documents"
+
+	ResourceLabTests   = "lab_tests"   // the test catalogue
+	ResourceLabOrders  = "lab_orders"  // orders and sample collection
+	ResourceLabResults = "lab_results" // entering (create) and releasing (update) results
+
+	ResourceEmailTemplates = "email_templates"
+	ResourceEmails         = "emails"
+
+	ResourceAuditLogs = "audit_logs" // read-only
+	ResourceStorage   = "storage"    // orphaned file sweeps; delete only
+)
+
+// Actions that can be performed on a resource
+const (
+	ActionCreate = "create"
+	ActionRead   = "read"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Resource    string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_permission_resource_action" json:"resource"`
+	Action      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_permission_resource_action" json:"action"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Key returns the "resource:action" form used by the API
+func (p Permission) Key() string {
+	return PermissionKey(p.Resource, p.Action)
+}
+
+// PermissionKey builds the "resource:action" form of a permission
+func PermissionKey(resource, action string) string {
+	return resource + ":" + action
+}
+
+// BeforeCreate hook
+func (p *Permission) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	now := time.Now()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook
+func (p *Permission) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string       `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"`
+	Description string       `gorm:"type:text" json:"description,omitempty"`
+	IsSystem    bool         `gorm:"default:false" json:"is_system"` // built-in roles cannot be deleted
+	Require2FA  bool         `gorm:"column:require_two_factor;default:false" json:"require_two_factor"`
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// BeforeCreate hook
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	now := time.Now()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate hook
+func (r *Role) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// DefaultPermissions is the permission catalogue seeded on startup
+func DefaultPermissions() []Permission {
+	crud := []string{ActionCreate, ActionRead, ActionUpdate, ActionDelete}
+	resources := []string{
+		ResourceUsers,
+		ResourceRoles,
+		ResourcePatients,
+		ResourceBookings,
+		ResourcePayments,
+		ResourceRooms,
+		ResourceServices,
+		ResourceImages,
+		ResourceDocuments,
+		ResourceEmailTemplates,
+		ResourceEmails,
+		ResourceLabTests,
+		ResourceLabOrders,
+		ResourceLabResults,
+	}
+
+	var perms []Permission
+	for _, res := range resources {
+		for _, act := range crud {
+			perms = append(perms, Permission{Resource: res, Action: act})
+		}
+	}
+
+	// The audit trail is append-only, so reading it is the only grantable action
+	perms = append(perms, Permission{Resource: ResourceAuditLogs, Action: ActionRead})
+	// Sweeping storage removes files, so deleting is the only grantable action
+	perms = append(perms, Permission{Resource: ResourceStorage, Action: ActionDelete})
+	return perms
+}
+
+// DefaultRolePermissions bundles the seeded permissions into the built-in roles.
+// The admin role is not listed: it always receives every permission.
+var DefaultRolePermissions = map[string][]string{
+	RolePatient: {
+		PermissionKey(ResourceBookings, ActionCreate),
+		PermissionKey(ResourcePayments, ActionCreate),
+		PermissionKey(ResourceImages, ActionCreate),
+		PermissionKey(ResourceImages, ActionRead),
+		PermissionKey(ResourceImages, ActionDelete),
+		PermissionKey(ResourceDocuments, ActionCreate),
+		PermissionKey(ResourceDocuments, ActionRead),
+		PermissionKey(ResourceDocuments, ActionUpdate),
+		PermissionKey(ResourceLabOrders, ActionRead),
+	},
+	RoleDoctor: {
+		PermissionKey(ResourcePatients, ActionRead),
+		PermissionKey(ResourceBookings, ActionRead),
+		PermissionKey(ResourceBookings, ActionUpdate),
+		PermissionKey(ResourceBookings, ActionDelete),
+		PermissionKey(ResourcePayments, ActionCreate),
+		PermissionKey(ResourceImages, ActionCreate),
+		PermissionKey(ResourceImages, ActionRead),
+		PermissionKey(ResourceImages, ActionDelete),
+		PermissionKey(ResourceDocuments, ActionCreate),
+		PermissionKey(ResourceDocuments, ActionRead),
+		PermissionKey(ResourceDocuments, ActionUpdate),
+		PermissionKey(ResourceDocuments, ActionDelete),
+		PermissionKey(ResourceLabTests, ActionRead),
+		PermissionKey(ResourceLabOrders, ActionCreate),
+		PermissionKey(ResourceLabOrders, ActionRead),
+		PermissionKey(ResourceLabOrders, ActionUpdate),
+	},
+	RoleReceptionist: {
+		PermissionKey(ResourcePatients, ActionRead),
+		PermissionKey(ResourceBookings, ActionCreate),
+		PermissionKey(ResourceBookings, ActionRead),
+		PermissionKey(ResourceBookings, ActionUpdate),
+		PermissionKey(ResourceRooms, ActionUpdate),
+		PermissionKey(ResourcePayments, ActionCreate),
+		PermissionKey(ResourceImages, ActionCreate),
+		PermissionKey(ResourceImages, ActionRead),
+		PermissionKey(ResourceDocuments, ActionCreate),
+		PermissionKey(ResourceDocuments, ActionRead),
+	},
+	RoleNurse: {
+		PermissionKey(ResourcePatients, ActionRead),
+		PermissionKey(ResourceBookings, ActionRead),
+		PermissionKey(ResourceImages, ActionCreate),
+		PermissionKey(ResourceImages, ActionRead),
+		PermissionKey(ResourceDocuments, ActionRead),
+		PermissionKey(ResourceLabOrders, ActionRead),
+		PermissionKey(ResourceLabOrders, ActionUpdate),
+	},
+	RoleCashier: {
+		PermissionKey(ResourceBookings, ActionRead),
+		PermissionKey(ResourcePayments, ActionCreate),
+		PermissionKey(ResourcePayments, ActionRead),
+	},
+	RoleLabTechnician: {
+		PermissionKey(ResourcePatients, ActionRead),
+		PermissionKey(ResourceImages, ActionCreate),
+		PermissionKey(ResourceImages, ActionRead),
+		PermissionKey(ResourceDocuments, ActionCreate),
+		PermissionKey(ResourceDocuments, ActionRead),
+		PermissionKey(ResourceDocuments, ActionUpdate),
+		PermissionKey(ResourceLabTests, ActionRead),
+		PermissionKey(ResourceLabOrders, ActionRead),
+		PermissionKey(ResourceLabOrders, ActionUpdate),
+		PermissionKey(ResourceLabResults, ActionCreate),
+		PermissionKey(ResourceLabResults, ActionUpdate),
+	},
+	RolePharmacist: {
+		PermissionKey(ResourcePatients, ActionRead),
+		PermissionKey(ResourceDocuments, ActionRead),
+	},
+}
+
+// WithdrawnRolePermissions were once granted by default and are taken back
+// from the built-in roles on startup: cashiers and pharmacists have no need
+// to read patients' medical images.
+var WithdrawnRolePermissions = map[string][]string{
+	RoleCashier:    {PermissionKey(ResourceImages, ActionRead)},
+	RolePharmacist: {PermissionKey(ResourceImages, ActionRead)},
+}
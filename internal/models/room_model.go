@@ -44,6 +44,11 @@ func (r *Room) BeforeUpdate(tx *gorm.DB) error {
 	return nil
 }
 
+func (r *Room) AfterFind(tx *gorm.DB) error {
+	resignURL(r.Image)
+	return nil
+}
+
 func UUIDFromString(s string) uuid.UUID {
 	if s == "" {
 		return uuid.Nil
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SigningKey is a JWT signing key pair. Keys are pre-published before they
+// start signing and stay verifiable for a grace period after they stop.
+type SigningKey struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Kid         string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"kid"`
+	Algorithm   string    `gorm:"type:varchar(20);not null" json:"algorithm"`
+	PrivateKey  string    `gorm:"type:text;not null" json:"-"` // sealed with JWT_SECRET
+	PublicKey   string    `gorm:"type:text;not null" json:"public_key"`
+	ActiveFrom  time.Time `gorm:"not null" json:"active_from"`
+	SignUntil   time.Time `gorm:"not null" json:"sign_until"`
+	VerifyUntil time.Time `gorm:"not null;index" json:"verify_until"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (k *SigningKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	k.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What an assembled upload is handed to
+const (
+	UploadTargetImage    = "image"
+	UploadTargetDocument = "document"
+)
+
+const (
+	UploadStatusPending    = "pending"
+	UploadStatusAssembling = "assembling"
+	UploadStatusCompleted  = "completed"
+	UploadStatusFailed     = "failed" // handed off, but the session could not be closed; abort it
+)
+
+// UploadSession tracks a resumable upload sent in fixed-size chunks
+type UploadSession struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Target      string     `gorm:"type:varchar(20);not null" json:"target"`
+	FileName    string     `gorm:"type:varchar(255);not null" json:"file_name"`
+	FileSize    int64      `gorm:"not null" json:"file_size"`
+	ChunkSize   int64      `gorm:"not null" json:"chunk_size"`
+	TotalChunks int        `gorm:"not null" json:"total_chunks"`
+	Checksum    string     `gorm:"type:varchar(64);not null" json:"checksum"` // sha256 of the whole file, hex
+	Metadata    JSON       `gorm:"type:jsonb" json:"metadata,omitempty"`      // request for the target usecase
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ResultID    *uuid.UUID `gorm:"type:uuid" json:"result_id,omitempty"` // image or document created on completion
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (s *UploadSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+func (s *UploadSession) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// UploadChunk holds the bytes of one chunk until the session is completed
+type UploadChunk struct {
+	SessionID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Index     int       `gorm:"column:chunk_index;primaryKey;autoIncrement:false"`
+	Size      int64     `gorm:"not null"`
+	Checksum  string    `gorm:"type:varchar(64);not null"`
+	Data      []byte    `gorm:"type:bytea;not null"`
+	CreatedAt time.Time
+}
@@ -8,9 +8,14 @@ import (
 )
 
 const (
-	RolePatient = "patient"
-	RoleDoctor  = "doctor"
-	RoleAdmin   = "admin"
+	RolePatient       = "patient"
+	RoleDoctor        = "doctor"
+	RoleAdmin         = "admin"
+	RoleReceptionist  = "receptionist"
+	RoleNurse         = "nurse"
+	RoleCashier       = "cashier"
+	RoleLabTechnician = "lab_technician"
+	RolePharmacist    = "pharmacist"
 )
 
 type User struct {
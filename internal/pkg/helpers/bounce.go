@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotDSN is returned for messages that are not delivery status notifications
+var ErrNotDSN = errors.New("not a delivery status notification")
+
+// DSN is a parsed delivery status notification (RFC 3464), the report a
+// mail server sends back when it could not deliver a message
+type DSN struct {
+	EmailID    uuid.UUID // from EmailIDHeader of the returned message; Nil if absent
+	Recipients []DSNRecipient
+}
+
+// DSNRecipient is the outcome for one recipient of the returned message
+type DSNRecipient struct {
+	Address    string
+	Action     string // failed, delayed, delivered, relayed or expanded
+	Status     string // enhanced status code, e.g. 5.1.1
+	Diagnostic string
+}
+
+// Hard reports a permanent failure, such as an unknown mailbox
+func (r DSNRecipient) Hard() bool {
+	return r.Action == "failed" && strings.HasPrefix(r.Status, "5")
+}
+
+// ParseDSN reads a multipart/report bounce message
+func ParseDSN(r io.Reader) (*DSN, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" || params["boundary"] == "" {
+		return nil, ErrNotDSN
+	}
+
+	dsn := &DSN{}
+	found := false
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch partType {
+		case "message/delivery-status":
+			found = true
+			if dsn.Recipients, err = parseDeliveryStatus(part); err != nil {
+				return nil, err
+			}
+		case "message/rfc822", "text/rfc822-headers":
+			// The returned message, or just its headers
+			if original, err := mail.ReadMessage(part); err == nil {
+				dsn.EmailID, _ = uuid.Parse(strings.TrimSpace(original.Header.Get(EmailIDHeader)))
+			}
+		}
+	}
+	if !found {
+		return nil, ErrNotDSN
+	}
+	return dsn, nil
+}
+
+// parseDeliveryStatus reads the field blocks of a delivery-status part,
+// keeping the per-recipient ones
+func parseDeliveryStatus(r io.Reader) ([]DSNRecipient, error) {
+	tp := textproto.NewReader(bufio.NewReader(r))
+	var recipients []DSNRecipient
+	for {
+		fields, err := tp.ReadMIMEHeader()
+		if fields.Get("Final-Recipient") != "" {
+			recipients = append(recipients, DSNRecipient{
+				Address:    dsnValue(fields.Get("Final-Recipient")),
+				Action:     strings.ToLower(strings.TrimSpace(fields.Get("Action"))),
+				Status:     strings.TrimSpace(fields.Get("Status")),
+				Diagnostic: dsnValue(fields.Get("Diagnostic-Code")),
+			})
+		}
+		if err == io.EOF {
+			return recipients, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// dsnValue drops the type prefix of fields like "rfc822; user@example.com"
+func dsnValue(field string) string {
+	if _, value, ok := strings.Cut(field, ";"); ok {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(field)
+}
@@ -1,33 +1,28 @@
 package helpers
 
 import (
-	"log"
-
 	"github.com/google/uuid"
-	"gopkg.in/gomail.v2"
 )
 
+// EmailIDHeader carries the email's ID, so bounces can be matched to it
+const EmailIDHeader = "X-HMS-Email-ID"
+
 type EmailJob struct {
-	EmailID uuid.UUID `json:"email_id"`
-	To      string    `json:"to"`
-	Subject string    `json:"subject"`
-	Body    string    `json:"body"`
+	EmailID     uuid.UUID         `json:"email_id"`
+	To          string            `json:"to"`
+	Subject     string            `json:"subject"`
+	Body        string            `json:"body"`
+	TextBody    string            `json:"text_body,omitempty"` // plain-text alternative to Body
+	Headers     map[string]string `json:"headers,omitempty"`   // extra headers, e.g. List-Unsubscribe
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+	// PriorAttempts counts sends before this job was published, so a
+	// resend is recorded as a further attempt
+	PriorAttempts int `json:"prior_attempts,omitempty"`
 }
 
-func SendEmail(job EmailJob, smtpHost string, smtpPort int, smtpUser, smtpPass string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", smtpUser)
-	m.SetHeader("To", job.To)
-	m.SetHeader("Subject", job.Subject)
-	m.SetBody("text/html", job.Body)
-
-	d := gomail.NewDialer(smtpHost, smtpPort, smtpUser, smtpPass)
-
-	if err := d.DialAndSend(m); err != nil {
-		log.Println("Failed to send email:", err)
-		return err
-	} else {
-		log.Println("Email sent to", job.To)
-		return nil
-	}
+// EmailAttachment is a file sent along with an EmailJob
+type EmailAttachment struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"` // base64 in the queued JSON
 }
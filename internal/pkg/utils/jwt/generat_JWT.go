@@ -1,29 +1,56 @@
 package jwt
 
 import (
+	"errors"
+	"hospital_management_system/config"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token purposes. Access tokens carry no purpose; scoped tokens are only
+// accepted by the endpoint they were issued for.
+const (
+	PurposeTwoFactorChallenge = "2fa_challenge"
+	PurposeTwoFactorSetup     = "2fa_setup"
+)
+
 // Claims structure
 type JWTClaims struct {
-	UserID string `json:"userId"`
-	Role   string `json:"role"`
+	UserID  string `json:"userId"`
+	Role    string `json:"role"`
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
 
 func GenerateJWT(userID, email, role string, expiry time.Duration) (string, error) {
+	return GenerateScopedJWT(userID, email, role, "", expiry)
+}
+
+// GenerateScopedJWT issues a token restricted to the given purpose
+func GenerateScopedJWT(userID, email, role, purpose string, expiry time.Duration) (string, error) {
+	now := time.Now()
 	claims := &JWTClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:  userID,
+		Role:    role,
+		Purpose: purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getSecret())
+	if config.ENV.JWTSigningAlg == AlgHS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(getSecret())
+	}
+
+	key := signingKey(now)
+	if key == nil {
+		return "", errors.New("no active JWT signing key")
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.PrivateKey)
 }
@@ -0,0 +1,222 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256" // legacy shared secret, no kid
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a signing key pair. PrivateKey is only needed by instances that issue tokens.
+type Key struct {
+	Kid         string
+	Algorithm   string
+	PrivateKey  crypto.Signer
+	PublicKey   crypto.PublicKey
+	ActiveFrom  time.Time // starts signing
+	SignUntil   time.Time // replaced by the next key
+	VerifyUntil time.Time // tokens signed with it are rejected after this
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   []Key
+)
+
+// SetKeys replaces the in-memory key set
+func SetKeys(k []Key) {
+	sorted := append([]Key(nil), k...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ActiveFrom.After(sorted[j].ActiveFrom) })
+
+	keysMu.Lock()
+	keys = sorted
+	keysMu.Unlock()
+}
+
+// signingKey returns the newest key that is allowed to sign at the given time
+func signingKey(now time.Time) *Key {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	for i := range keys {
+		k := keys[i]
+		if k.PrivateKey != nil && !now.Before(k.ActiveFrom) && now.Before(k.SignUntil) {
+			return &k
+		}
+	}
+	return nil
+}
+
+// verificationKey returns the key with the given kid if it is still inside its grace period
+func verificationKey(kid string, now time.Time) *Key {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	for i := range keys {
+		if keys[i].Kid == kid && now.Before(keys[i].VerifyUntil) {
+			k := keys[i]
+			return &k
+		}
+	}
+	return nil
+}
+
+// JWK is the public part of a key as published on the JWKS endpoint (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every key that can currently verify tokens, including
+// upcoming keys so verifiers learn about them before they start signing
+func JWKS() JWKSet {
+	now := time.Now()
+	set := JWKSet{Keys: []JWK{}}
+
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	for _, k := range keys {
+		if !now.Before(k.VerifyUntil) {
+			continue
+		}
+		jwk := JWK{Kid: k.Kid, Use: "sig", Alg: k.Algorithm}
+		switch pub := k.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// GenerateKey creates a new private key for the algorithm
+func GenerateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+}
+
+// KeyID derives a stable kid from the public key
+func KeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:12]), nil
+}
+
+// EncodePublicKey returns the PEM form of a public key
+func EncodePublicKey(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// DecodePublicKey parses a PEM public key
+func DecodePublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid public key PEM")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// SealPrivateKey encrypts a private key with AES-GCM under a key derived from JWT_SECRET,
+// so a database dump alone is not enough to mint tokens
+func SealPrivateKey(priv crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := sealCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, der, nil)), nil
+}
+
+// OpenPrivateKey reverses SealPrivateKey
+func OpenPrivateKey(sealed string) (crypto.Signer, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := sealCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("sealed private key is too short")
+	}
+	der, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+	return signer, nil
+}
+
+func sealCipher() (cipher.AEAD, error) {
+	sum := sha256.Sum256(append([]byte("jwt-signing-key:"), getSecret()...))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,182 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"hospital_management_system/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func setTestConfig(t *testing.T, secret, alg string) {
+	t.Helper()
+	prev := config.ENV
+	config.ENV = &config.Config{JWTSecret: secret, JWTSigningAlg: alg}
+	t.Cleanup(func() { config.ENV = prev })
+}
+
+// newTestKey generates a key that signs in [from, until) and verifies until verifyUntil
+func newTestKey(t *testing.T, alg string, from, until, verifyUntil time.Time) Key {
+	t.Helper()
+	priv, err := GenerateKey(alg)
+	if err != nil {
+		t.Fatalf("GenerateKey(%s): %v", alg, err)
+	}
+	kid, err := KeyID(priv.Public())
+	if err != nil {
+		t.Fatalf("KeyID: %v", err)
+	}
+	return Key{
+		Kid:         kid,
+		Algorithm:   alg,
+		PrivateKey:  priv,
+		PublicKey:   priv.Public(),
+		ActiveFrom:  from,
+		SignUntil:   until,
+		VerifyUntil: verifyUntil,
+	}
+}
+
+func TestSealPrivateKey(t *testing.T) {
+	for _, alg := range []string{AlgEdDSA, AlgRS256} {
+		t.Run(alg, func(t *testing.T) {
+			setTestConfig(t, "first-secret", alg)
+			priv, err := GenerateKey(alg)
+			if err != nil {
+				t.Fatalf("GenerateKey: %v", err)
+			}
+			sealed, err := SealPrivateKey(priv)
+			if err != nil {
+				t.Fatalf("SealPrivateKey: %v", err)
+			}
+
+			opened, err := OpenPrivateKey(sealed)
+			if err != nil {
+				t.Fatalf("OpenPrivateKey: %v", err)
+			}
+			wantKid, _ := KeyID(priv.Public())
+			gotKid, _ := KeyID(opened.Public())
+			if gotKid != wantKid {
+				t.Errorf("opened key has kid %s, want %s", gotKid, wantKid)
+			}
+
+			again, _ := SealPrivateKey(priv)
+			if again == sealed {
+				t.Error("sealing twice gave the same ciphertext; the nonce is not random")
+			}
+
+			config.ENV.JWTSecret = "other-secret"
+			if _, err := OpenPrivateKey(sealed); err == nil {
+				t.Error("opened a key sealed under a different JWT_SECRET")
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	setTestConfig(t, "test-secret", AlgEdDSA)
+	now := time.Now()
+	retired := newTestKey(t, AlgEdDSA, now.Add(-2*time.Hour), now.Add(-time.Hour), now.Add(time.Hour))
+	active := newTestKey(t, AlgEdDSA, now.Add(-time.Hour), now.Add(time.Hour), now.Add(2*time.Hour))
+	upcoming := newTestKey(t, AlgEdDSA, now.Add(time.Hour), now.Add(2*time.Hour), now.Add(3*time.Hour))
+	expired := newTestKey(t, AlgEdDSA, now.Add(-4*time.Hour), now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	SetKeys([]Key{retired, upcoming, expired, active})
+	t.Cleanup(func() { SetKeys(nil) })
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"active key signs now", now, active.Kid},
+		{"retired key signed before the rotation", now.Add(-90 * time.Minute), retired.Kid},
+		{"upcoming key takes over", now.Add(90 * time.Minute), upcoming.Kid},
+		{"nothing signs after the last key", now.Add(3 * time.Hour), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if k := signingKey(tt.at); k != nil {
+				got = k.Kid
+			}
+			if got != tt.want {
+				t.Errorf("signingKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	published := map[string]bool{}
+	for _, jwk := range JWKS().Keys {
+		published[jwk.Kid] = true
+	}
+	for _, k := range []Key{retired, active, upcoming} {
+		if !published[k.Kid] {
+			t.Errorf("JWKS is missing %s", k.Kid)
+		}
+	}
+	if published[expired.Kid] {
+		t.Error("JWKS publishes a key past its grace period")
+	}
+}
+
+func TestVerifyKidLookup(t *testing.T) {
+	setTestConfig(t, "test-secret", AlgEdDSA)
+	now := time.Now()
+	retired := newTestKey(t, AlgEdDSA, now.Add(-2*time.Hour), now.Add(-time.Hour), now.Add(time.Hour))
+	active := newTestKey(t, AlgEdDSA, now.Add(-time.Hour), now.Add(time.Hour), now.Add(2*time.Hour))
+	expired := newTestKey(t, AlgEdDSA, now.Add(-4*time.Hour), now.Add(-3*time.Hour), now.Add(-time.Minute))
+	stranger := newTestKey(t, AlgEdDSA, now.Add(-time.Hour), now.Add(time.Hour), now.Add(2*time.Hour))
+	SetKeys([]Key{retired, active, expired})
+	t.Cleanup(func() { SetKeys(nil) })
+
+	// sign issues a token with key's private half under the given kid header
+	sign := func(key Key, kid string) string {
+		claims := &JWTClaims{
+			UserID: "user-1",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+				IssuedAt:  jwt.NewNumericDate(now),
+			},
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key.PrivateKey.(ed25519.PrivateKey))
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+
+	issued, err := GenerateJWT("user-1", "", "doctor", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"issued with the active key", issued, ""},
+		{"retired key inside its grace period", sign(retired, retired.Kid), ""},
+		{"expired key", sign(expired, expired.Kid), "unknown or expired signing key"},
+		{"unknown kid", sign(stranger, stranger.Kid), "unknown or expired signing key"},
+		{"kid of another key", sign(stranger, active.Kid), "signature is invalid"},
+		{"no kid", sign(active, ""), "token has no kid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyJWT(tt.token)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("VerifyJWT: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("VerifyJWT error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
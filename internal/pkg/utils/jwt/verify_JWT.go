@@ -1,8 +1,11 @@
 package jwt
 
 import (
+	"fmt"
 	"hospital_management_system/config"
 	"hospital_management_system/internal/pkg/helpers"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -16,11 +19,15 @@ func getSecret() []byte {
 }
 
 
-// VerifyJWT verifies the token and returns the claims
+// VerifyJWT verifies the token and returns the claims. It is the only place
+// tokens are parsed; an optional "Bearer " prefix is accepted.
 func VerifyJWT(tokenStr string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return getSecret(), nil
-	})
+	tokenStr = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tokenStr), "Bearer "))
+
+	token, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, keyFunc,
+		jwt.WithValidMethods(validMethods()),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -28,5 +35,34 @@ func VerifyJWT(tokenStr string) (*JWTClaims, error) {
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
 		return claims, nil
 	}
-	 return nil, helpers.NewAppError(401, "Invalid or expired token")
+	return nil, helpers.NewAppError(401, "Invalid or expired token")
+}
+
+// keyFunc picks the verification key: the shared secret for legacy HS256
+// tokens, otherwise the published key named by the kid header
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return getSecret(), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid")
+	}
+	key := verificationKey(kid, time.Now())
+	if key == nil {
+		return nil, fmt.Errorf("unknown or expired signing key %q", kid)
+	}
+	if key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
+	}
+	return key.PublicKey, nil
+}
+
+func validMethods() []string {
+	methods := []string{AlgRS256, AlgEdDSA}
+	if config.ENV.JWTSigningAlg == AlgHS256 || config.ENV.JWTAcceptHS256 {
+		methods = append(methods, AlgHS256)
+	}
+	return methods
 }
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"context"
+	"hospital_management_system/internal/shared/constants"
+)
+
+// RequestMeta describes where a request came from
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
+
+// WithRequestMeta stores the request origin on the context
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, constants.RequestMetaContextKey, meta)
+}
+
+// RequestMetaFromContext returns the request origin, or an empty value outside HTTP requests
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	if ctx == nil {
+		return RequestMeta{}
+	}
+	meta, _ := ctx.Value(constants.RequestMetaContextKey).(RequestMeta)
+	return meta
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed from RFC 6238 appendix B
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B lists eight digits; six-digit codes are their last six
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(step), step, true},
+		{"previous step within skew", codeAt(step - 1), step - 1, true},
+		{"next step within skew", codeAt(step + 1), step + 1, true},
+		{"outside skew", codeAt(step - 2), 0, false},
+		{"surrounding spaces", " " + codeAt(step) + " ", step, true},
+		{"wrong length", codeAt(step)[:5], 0, false},
+		{"wrong code", "000000", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(rfc6238Secret, tt.code, now, 1)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestValidateTOTPReplay checks that the reported step lets callers refuse a
+// code once it, or a later one, has been used
+func TestValidateTOTPReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	var lastUsed int64
+	use := func(code string) bool {
+		step, ok := ValidateTOTP(rfc6238Secret, code, now, 1)
+		if !ok || step <= lastUsed {
+			return false
+		}
+		lastUsed = step
+		return true
+	}
+
+	current, _ := TOTPCode(rfc6238Secret, TOTPStep(now))
+	previous, _ := TOTPCode(rfc6238Secret, TOTPStep(now)-1)
+
+	if !use(current) {
+		t.Fatal("first use of the current code was refused")
+	}
+	if use(current) {
+		t.Error("the same code was accepted twice")
+	}
+	if use(previous) {
+		t.Error("an older code was accepted after a newer one was used")
+	}
+}
@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"bytes"
+	"fmt"
+	"hospital_management_system/internal/pkg/helpers"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+const MaxDocumentSize = int64(25 << 20) // 25MB
+
+// Content types accepted for patient documents; scans may also be plain images
+var documentTypes = map[string]bool{
+	"application/pdf":   true,
+	"application/dicom": true,
+	"image/jpeg":        true,
+	"image/png":         true,
+}
+
+// ValidateDocument checks the size and sniffs the real file type from its
+// first bytes, returning the detected content type. The file is rewound.
+func ValidateDocument(file multipart.File, fileHeader *multipart.FileHeader) (string, error) {
+	if fileHeader.Size > MaxDocumentSize {
+		return "", helpers.NewAppError(400, fmt.Sprintf("Document too large. Max %d MB", MaxDocumentSize>>20))
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", helpers.NewAppError(400, "Failed to read document")
+	}
+	head = head[:n]
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", helpers.NewAppError(500, "Failed to read document")
+	}
+
+	contentType := sniffDocument(head)
+	if !documentTypes[contentType] {
+		return "", helpers.NewAppError(400, "Invalid file type. Only PDF, DICOM, JPEG and PNG allowed")
+	}
+	return contentType, nil
+}
+
+// sniffDocument recognises DICOM (128 byte preamble followed by "DICM") on top
+// of what http.DetectContentType already knows
+func sniffDocument(head []byte) string {
+	if len(head) >= 132 && bytes.Equal(head[128:132], []byte("DICM")) {
+		return "application/dicom"
+	}
+	ct := http.DetectContentType(head)
+	if i := bytes.IndexByte([]byte(ct), ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	return ct
+}
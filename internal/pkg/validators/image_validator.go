@@ -3,26 +3,40 @@ package validators
 import (
 	"fmt"
 	"hospital_management_system/internal/pkg/helpers"
+	"io"
 	"mime/multipart"
+	"net/http"
 )
 
-func ValidateImage(fileHeader *multipart.FileHeader) error {
+const MaxImageSize = int64(10 << 20) // 10MB
+
+// ValidateImage checks the size and sniffs the real image type from its
+// first bytes rather than trusting the client's Content-Type header.
+// Returns the detected content type; the file is rewound.
+func ValidateImage(file multipart.File, fileHeader *multipart.FileHeader) (string, error) {
 	allowedTypes := map[string]bool{
 		"image/jpeg": true,
-		"image/jpg":  true,
 		"image/png":  true,
 		"image/webp": true,
 	}
 
-	contentType := fileHeader.Header.Get("Content-Type")
-	if !allowedTypes[contentType] {
-		return helpers.NewAppError(400, "Invalid file type. Only JPEG, PNG, and WebP allowed")
+	if fileHeader.Size > MaxImageSize {
+		return "", helpers.NewAppError(400, fmt.Sprintf("Image size too large. Max %d MB", MaxImageSize>>20))
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", helpers.NewAppError(400, "Failed to read image")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", helpers.NewAppError(500, "Failed to read image")
 	}
 
-	const maxSize = int64(10 << 20) // 10MB
-	if fileHeader.Size > maxSize {
-		return helpers.NewAppError(400, fmt.Sprintf("Image size too large. Max %d MB", maxSize>>20))
+	contentType := http.DetectContentType(head[:n])
+	if !allowedTypes[contentType] {
+		return "", helpers.NewAppError(400, "Invalid file type. Only JPEG, PNG, and WebP allowed")
 	}
 
-	return nil
+	return contentType, nil
 }
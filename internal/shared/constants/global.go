@@ -1,6 +1,9 @@
 package constants
 
+// ContextKey namespaces values stored on a request context
+type ContextKey string
 
 const (
-	
-)
\ No newline at end of file
+	UserContextKey        ContextKey = "user"         // *models.User set by the auth middlewares
+	RequestMetaContextKey ContextKey = "request_meta" // utils.RequestMeta set by middlewares.RequestMeta
+)
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/shared/constants"
+	"log"
+	"net/http"
+	"reflect"
+)
+
+// AuditEntry describes one action to record. Before/After are any JSON
+// serialisable values; when both are set the changed fields are stored too.
+type AuditEntry struct {
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Before       interface{}
+	After        interface{}
+	Actor        *models.User // overrides the authenticated user, e.g. during login
+}
+
+type AuditUsecase interface {
+	Record(ctx context.Context, entry AuditEntry)
+	Search(filter *dto.AuditLogFilter) (*dto.ListResponse, error)
+}
+
+type auditUsecase struct {
+	repo repository.AuditLogRepository
+}
+
+func AuditNewUsecase(repo repository.AuditLogRepository) AuditUsecase {
+	return &auditUsecase{repo: repo}
+}
+
+// Fields that change on every write and would only add noise to the diff
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// Record writes the entry synchronously. Failures are logged rather than
+// returned so auditing never breaks the action being audited.
+func (u *auditUsecase) Record(ctx context.Context, entry AuditEntry) {
+	meta := utils.RequestMetaFromContext(ctx)
+	record := &models.AuditLog{
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		IP:           meta.IP,
+		UserAgent:    meta.UserAgent,
+	}
+
+	actor := entry.Actor
+	if actor == nil {
+		actor = actorFromContext(ctx)
+	}
+	if actor != nil {
+		id := actor.ID
+		record.ActorID = &id
+		record.ActorEmail = actor.Email
+		record.ActorRole = actor.Role
+	}
+
+	before, err := models.ToJSON(entry.Before)
+	if err != nil {
+		logAuditError(err)
+		return
+	}
+	after, err := models.ToJSON(entry.After)
+	if err != nil {
+		logAuditError(err)
+		return
+	}
+	record.Before = before
+	record.After = after
+	if before != nil && after != nil {
+		if record.Changes, err = diffJSON(before, after); err != nil {
+			logAuditError(err)
+			return
+		}
+	}
+
+	if err := u.repo.Create(record); err != nil {
+		logAuditError(err)
+	}
+}
+
+func (u *auditUsecase) Search(filter *dto.AuditLogFilter) (*dto.ListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	entries, total, err := u.repo.Search(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve audit logs")
+	}
+
+	data := make([]interface{}, len(entries))
+	for i, e := range entries {
+		data[i] = e
+	}
+
+	totalPages := int(total) / filter.PageSize
+	if int(total)%filter.PageSize > 0 {
+		totalPages++
+	}
+
+	return &dto.ListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// diffJSON compares two JSON objects field by field
+func diffJSON(before, after models.JSON) (models.JSON, error) {
+	var b, a map[string]interface{}
+	if err := json.Unmarshal(before, &b); err != nil {
+		return nil, nil // not objects, nothing to diff
+	}
+	if err := json.Unmarshal(after, &a); err != nil {
+		return nil, nil
+	}
+
+	changes := make(map[string]map[string]interface{})
+	for k, av := range a {
+		if auditIgnoredFields[k] {
+			continue
+		}
+		if bv, ok := b[k]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = map[string]interface{}{"from": b[k], "to": av}
+		}
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok && !auditIgnoredFields[k] {
+			changes[k] = map[string]interface{}{"from": bv, "to": nil}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return models.ToJSON(changes)
+}
+
+// actorFromContext returns the user set by the auth middlewares, if any
+func actorFromContext(ctx context.Context) *models.User {
+	if ctx == nil {
+		return nil
+	}
+	user, _ := ctx.Value(constants.UserContextKey).(*models.User)
+	return user
+}
+
+func logAuditError(err error) {
+	log.Println("Failed to write audit log:", err)
+}
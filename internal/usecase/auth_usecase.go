@@ -1,59 +1,183 @@
 package usecase
 
 import (
+	"context"
 	"hospital_management_system/internal/dto"
 	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
 	"hospital_management_system/internal/pkg/utils/jwt"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 
 type AuthUsecase interface {
-	Login(req *dto.LoginRequest) (string, error)
+	Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
+	VerifyTwoFactor(ctx context.Context, req *dto.TwoFactorLoginRequest) (*dto.LoginResponse, error)
+	IssueAccessToken(userID string) (string, error)
 }
 
 type authUsecase struct {
-	repo       repository.UserRepository
+	repo        repository.UserRepository
+	twoFactorUC TwoFactorUsecase
+	roleUC      RoleUsecase
+	throttleUC  LoginThrottleUsecase
+	auditUC     AuditUsecase
 }
 
-func AuthNewUsecase(repo repository.UserRepository) AuthUsecase {
+func AuthNewUsecase(repo repository.UserRepository, twoFactorUC TwoFactorUsecase, roleUC RoleUsecase, throttleUC LoginThrottleUsecase, auditUC AuditUsecase) AuthUsecase {
 	return &authUsecase{
-		repo:     repo,
+		repo:        repo,
+		twoFactorUC: twoFactorUC,
+		roleUC:      roleUC,
+		throttleUC:  throttleUC,
+		auditUC:     auditUC,
 	}
 }
 
+// Pending two-factor tokens are short lived
+const twoFactorTokenExpiry = 5 * time.Minute
 
 
+func (u *authUsecase) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
+	ip := utils.RequestMetaFromContext(ctx).IP
+	if err := u.throttleUC.Check(req.Email, ip); err != nil {
+		return nil, err
+	}
 
-func (u *authUsecase) Login(req *dto.LoginRequest) (string, error) {
 	user, err := u.repo.FindByEmail(req.Email)
 	if err != nil || user == nil {
-		return "", helpers.NewAppError(406, "You have given a wrong email or password!")
+		u.throttleUC.RecordFailure(req.Email, ip)
+		u.auditLogin(ctx, models.AuditActionLoginFailed, nil, req.Email)
+		return nil, helpers.NewAppError(406, "You have given a wrong email or password!")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return "", helpers.NewAppError(406, "You have given a wrong email or password!")
+		u.throttleUC.RecordFailure(req.Email, ip)
+		u.auditLogin(ctx, models.AuditActionLoginFailed, user, user.ID.String())
+		return nil, helpers.NewAppError(406, "You have given a wrong email or password!")
 	}
 
-	if user.IsBlocked {
-		return "", helpers.NewAppError(403, "User is blocked")
+	if err := checkAccountState(user); err != nil {
+		return nil, err
 	}
-	if user.IsDeleted {
-		return "", helpers.NewAppError(403, "User is deleted")
+
+	// Second step: enrolled users, or users whose role enforces 2FA
+	enabled, err := u.twoFactorUC.IsEnabled(user.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(500, "Failed to check two-factor status")
+	}
+	if enabled {
+		challenge, err := jwt.GenerateScopedJWT(user.ID.String(), user.Email, user.Role, jwt.PurposeTwoFactorChallenge, twoFactorTokenExpiry)
+		if err != nil {
+			return nil, helpers.NewAppError(500, "Failed to generate token")
+		}
+		return &dto.LoginResponse{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	required, err := u.roleUC.RequiresTwoFactor(user.Role)
+	if err != nil {
+		return nil, helpers.NewAppError(500, "Failed to check two-factor status")
+	}
+	if required {
+		setup, err := jwt.GenerateScopedJWT(user.ID.String(), user.Email, user.Role, jwt.PurposeTwoFactorSetup, twoFactorTokenExpiry)
+		if err != nil {
+			return nil, helpers.NewAppError(500, "Failed to generate token")
+		}
+		return &dto.LoginResponse{TwoFactorSetupRequired: true, ChallengeToken: setup}, nil
+	}
+
+	token, err := u.issueToken(user)
+	if err != nil {
+		return nil, err
+	}
+	u.throttleUC.RecordSuccess(user.Email)
+	u.auditLogin(ctx, models.AuditActionLogin, user, user.ID.String())
+	return &dto.LoginResponse{Token: token}, nil
+}
+
+// VerifyTwoFactor exchanges a challenge token plus a TOTP or recovery code for an access token
+func (u *authUsecase) VerifyTwoFactor(ctx context.Context, req *dto.TwoFactorLoginRequest) (*dto.LoginResponse, error) {
+	ip := utils.RequestMetaFromContext(ctx).IP
+	claims, err := jwt.VerifyJWT(req.ChallengeToken)
+	if err != nil || claims.Purpose != jwt.PurposeTwoFactorChallenge {
+		return nil, helpers.NewAppError(401, "Invalid or expired challenge token")
+	}
+
+	user, err := u.repo.FindByID(claims.UserID)
+	if err != nil || user == nil {
+		return nil, helpers.NewAppError(404, "User not found")
+	}
+	if err := checkAccountState(user); err != nil {
+		return nil, err
+	}
+	if err := u.throttleUC.Check(user.Email, ip); err != nil {
+		return nil, err
 	}
-		if !user.IsVerified {
-		return "", helpers.NewAppError(403, "User is not verify")
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, helpers.NewAppError(401, "Invalid or expired challenge token")
+	}
+	if err := u.twoFactorUC.Verify(userID, req.Code, req.RecoveryCode); err != nil {
+		u.throttleUC.RecordFailure(user.Email, ip)
+		u.auditLogin(ctx, models.AuditActionLoginFailed, user, user.ID.String())
+		return nil, err
 	}
 
+	token, err := u.issueToken(user)
+	if err != nil {
+		return nil, err
+	}
+	u.throttleUC.RecordSuccess(user.Email)
+	u.auditLogin(ctx, models.AuditActionLogin, user, user.ID.String())
+	return &dto.LoginResponse{Token: token}, nil
+}
+
+// IssueAccessToken is used once a setup token holder finishes enrollment
+func (u *authUsecase) IssueAccessToken(userID string) (string, error) {
+	user, err := u.repo.FindByID(userID)
+	if err != nil || user == nil {
+		return "", helpers.NewAppError(404, "User not found")
+	}
+	if err := checkAccountState(user); err != nil {
+		return "", err
+	}
+	return u.issueToken(user)
+}
+
+func (u *authUsecase) issueToken(user *models.User) (string, error) {
 	// Generate JWT token
 	token, err := jwt.GenerateJWT(user.ID.String(), user.Email, user.Role, 24*time.Hour)
 	if err != nil {
 		return "", helpers.NewAppError(500, "Failed to generate token")
 	}
-
 	return token, nil
 }
 
+// auditLogin records a login outcome; unknown emails are kept as the resource ID
+func (u *authUsecase) auditLogin(ctx context.Context, action string, user *models.User, subject string) {
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       action,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   subject,
+		Actor:        user,
+	})
+}
+
+func checkAccountState(user *models.User) error {
+	if user.IsBlocked {
+		return helpers.NewAppError(403, "User is blocked")
+	}
+	if user.IsDeleted {
+		return helpers.NewAppError(403, "User is deleted")
+	}
+	if !user.IsVerified {
+		return helpers.NewAppError(403, "User is not verify")
+	}
+	return nil
+}
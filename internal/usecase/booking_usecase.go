@@ -1,23 +1,27 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/events"
 	"hospital_management_system/internal/infra/repository"
 	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
 	"hospital_management_system/internal/pkg/utils"
+	"log"
 	"net/http"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type BookingUsecase interface {
-	Create(req *dto.CreateBookingRequest) (*models.Booking, error)
-	GetByID(id string) (*models.Booking, error)
-	GetAll() ([]models.Booking, error)
-	UpdateStatus(id string, req *dto.UpdateBookingStatusRequest) (*models.Booking, error)
-	Delete(id string) error
+	Create(ctx context.Context, req *dto.CreateBookingRequest) (*models.Booking, error)
+	GetByID(ctx context.Context, id string) (*models.Booking, error)
+	GetAll(ctx context.Context) ([]models.Booking, error)
+	UpdateStatus(ctx context.Context, id string, req *dto.UpdateBookingStatusRequest) (*models.Booking, error)
+	Delete(ctx context.Context, id string) error
 }
 
 type bookingUsecase struct {
@@ -25,6 +29,9 @@ type bookingUsecase struct {
 	patientRepo repository.PatientRepository
 	roomRepo    repository.RoomRepository
 	serviceRepo repository.ServiceRepository
+	outbox      repository.OutboxRepository
+	auditUC     AuditUsecase
+	notifyUC    NotificationUsecase
 }
 
 func BookingNewUsecase(
@@ -32,16 +39,22 @@ func BookingNewUsecase(
 	patientRepo repository.PatientRepository,
 	roomRepo repository.RoomRepository,
 	serviceRepo repository.ServiceRepository,
+	outbox repository.OutboxRepository,
+	auditUC AuditUsecase,
+	notifyUC NotificationUsecase,
 ) BookingUsecase {
 	return &bookingUsecase{
 		bookingRepo: bookingRepo,
 		patientRepo: patientRepo,
 		roomRepo:    roomRepo,
 		serviceRepo: serviceRepo,
+		outbox:      outbox,
+		auditUC:     auditUC,
+		notifyUC:    notifyUC,
 	}
 }
 
-func (u *bookingUsecase) Create(req *dto.CreateBookingRequest) (*models.Booking, error) {
+func (u *bookingUsecase) Create(ctx context.Context, req *dto.CreateBookingRequest) (*models.Booking, error) {
 
 	_, err := u.patientRepo.GetPatientByID(req.PatientID)
 	if err != nil {
@@ -123,10 +136,42 @@ func (u *bookingUsecase) Create(req *dto.CreateBookingRequest) (*models.Booking,
 		booking.TotalPrice = &service.Price
 	}
 
-	return u.bookingRepo.Create(booking)
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.bookingRepo.CreateTx(tx, booking); err != nil {
+			return err
+		}
+		return recordEvent(u.outbox, tx, bookingCreatedEvent(booking))
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := booking
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceBooking,
+		ResourceID:   created.ID.String(),
+		After:        created,
+	})
+	return created, nil
 }
 
-func (u *bookingUsecase) GetByID(id string) (*models.Booking, error) {
+// GetByID returns the booking and records the access, since it exposes patient data
+func (u *bookingUsecase) GetByID(ctx context.Context, id string) (*models.Booking, error) {
+	b, err := u.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceBooking,
+		ResourceID:   b.ID.String(),
+	})
+	return b, nil
+}
+
+func (u *bookingUsecase) find(id string) (*models.Booking, error) {
 	b, err := u.bookingRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -137,17 +182,123 @@ func (u *bookingUsecase) GetByID(id string) (*models.Booking, error) {
 	return b, nil
 }
 
-func (u *bookingUsecase) GetAll() ([]models.Booking, error) {
-	return u.bookingRepo.GetAll()
+// GetAll lists every booking; the listing is audited as one view
+func (u *bookingUsecase) GetAll(ctx context.Context) ([]models.Booking, error) {
+	list, err := u.bookingRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceBooking,
+		ResourceID:   "all",
+		After:        map[string]interface{}{"count": len(list)},
+	})
+	return list, nil
 }
 
-func (u *bookingUsecase) UpdateStatus(id string, req *dto.UpdateBookingStatusRequest) (*models.Booking, error) {
-	b := &models.Booking{ID: models.UUIDFromString(id)}
-	b.Status = models.BookingStatus(req.Status)
+func (u *bookingUsecase) UpdateStatus(ctx context.Context, id string, req *dto.UpdateBookingStatusRequest) (*models.Booking, error) {
+	before, err := u.find(id)
+	if err != nil {
+		return nil, err
+	}
 
-	return u.bookingRepo.Update(b)
+	status := models.BookingStatus(req.Status)
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.bookingRepo.UpdateStatusTx(tx, id, status); err != nil {
+			return err
+		}
+		if before.Status == status {
+			return nil
+		}
+		return recordEvent(u.outbox, tx, events.BookingStatusChanged{
+			BookingID: before.ID,
+			PatientID: before.PatientID,
+			From:      string(before.Status),
+			To:        string(status),
+		})
+	})
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update booking status")
+	}
+
+	after, err := u.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionStatusChange,
+		ResourceType: models.AuditResourceBooking,
+		ResourceID:   id,
+		Before:       map[string]interface{}{"status": before.Status},
+		After:        map[string]interface{}{"status": after.Status},
+	})
+
+	if before.Status != after.Status {
+		if patient, err := u.patientRepo.GetPatientByID(after.PatientID.String()); err == nil && patient != nil {
+			if after.Status == models.BookingConfirmed {
+				go notifyBookingConfirmed(u.notifyUC, patient, after)
+			} else {
+				go notifyBookingUpdated(u.notifyUC, patient, after)
+			}
+		}
+	}
+	return after, nil
+}
+
+// notifyBookingConfirmed tells the patient their booking went through
+func notifyBookingConfirmed(notifyUC NotificationUsecase, patient *models.User, b *models.Booking) {
+	if err := notifyUC.Notify(patient, models.NotificationBookingConfirmation, map[string]string{
+		"BookingID": b.ID.String(),
+		"Date":      bookingDate(b),
+	}); err != nil {
+		log.Println("Failed to send booking confirmation:", err)
+	}
+}
+
+// notifyBookingUpdated tells the patient about any other status change
+func notifyBookingUpdated(notifyUC NotificationUsecase, patient *models.User, b *models.Booking) {
+	if err := notifyUC.Notify(patient, models.NotificationBookingUpdate, map[string]string{
+		"BookingID": b.ID.String(),
+		"Status":    string(b.Status),
+		"Date":      bookingDate(b),
+	}); err != nil {
+		log.Println("Failed to send booking update:", err)
+	}
 }
 
-func (u *bookingUsecase) Delete(id string) error {
-	return u.bookingRepo.Delete(id)
+// bookingDate is the appointment time or check-in date shown to patients
+func bookingDate(b *models.Booking) string {
+	if b.ScheduledAt != nil {
+		return b.ScheduledAt.Format("2006-01-02 15:04")
+	}
+	if b.CheckInDate != nil {
+		return b.CheckInDate.Format("2006-01-02")
+	}
+	return ""
+}
+
+func (u *bookingUsecase) Delete(ctx context.Context, id string) error {
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return helpers.NewAppError(http.StatusBadRequest, "Invalid booking ID")
+	}
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.bookingRepo.DeleteTx(tx, id); err != nil {
+			return err
+		}
+		return recordEvent(u.outbox, tx, events.BookingDeleted{BookingID: bookingID})
+	})
+	if err != nil {
+		return err
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceBooking,
+		ResourceID:   id,
+	})
+	return nil
 }
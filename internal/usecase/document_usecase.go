@@ -0,0 +1,492 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/storage"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/validators"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	documentDownloadTTL = 5 * time.Minute
+	maxDocumentTags     = 20
+	maxDocumentTagLen   = 50
+)
+
+type DocumentUsecase interface {
+	Upload(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, req *dto.DocumentUploadRequest) (*models.PatientDocument, error)
+	AddVersion(ctx context.Context, id uuid.UUID, file multipart.File, fileHeader *multipart.FileHeader, note string) (*models.PatientDocument, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PatientDocument, error)
+	ListByPatient(ctx context.Context, filter *dto.DocumentFilter) (*dto.ListResponse, error)
+	DownloadURL(ctx context.Context, id uuid.UUID, version int) (*dto.DocumentDownloadResponse, error)
+	UpdateTags(ctx context.Context, id uuid.UUID, tags []string) (*models.PatientDocument, error)
+	GrantAccess(ctx context.Context, id uuid.UUID, req *dto.DocumentAccessRequest) error
+	RevokeAccess(ctx context.Context, id, userID uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type documentUsecase struct {
+	repo      repository.DocumentRepository
+	imageRepo repository.ImageRepository
+	userRepo  repository.UserRepository
+	storage   storage.Storage
+	auditUC   AuditUsecase
+}
+
+func DocumentNewUsecase(
+	repo repository.DocumentRepository,
+	imageRepo repository.ImageRepository,
+	userRepo repository.UserRepository,
+	store storage.Storage,
+	auditUC AuditUsecase,
+) DocumentUsecase {
+	return &documentUsecase{
+		repo:      repo,
+		imageRepo: imageRepo,
+		userRepo:  userRepo,
+		storage:   store,
+		auditUC:   auditUC,
+	}
+}
+
+func (u *documentUsecase) Upload(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, req *dto.DocumentUploadRequest) (*models.PatientDocument, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	category := models.DocumentCategory(req.Category)
+	if !models.IsValidDocumentCategory(category) {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid category. Use lab_report, radiology, discharge_summary or identity")
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Title is required")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	patientID, err := u.resolvePatient(actor, req.PatientID)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := u.store(ctx, file, fileHeader, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.PatientDocument{
+		PatientID:      patientID,
+		Title:          title,
+		Category:       category,
+		Description:    req.Description,
+		CurrentVersion: 1,
+		UploadedBy:     actor.ID,
+	}
+	for _, t := range tags {
+		doc.Tags = append(doc.Tags, models.DocumentTag{Tag: t})
+	}
+	version := &models.DocumentVersion{ImageID: image.ID, UploadedBy: actor.ID}
+
+	if err := u.repo.Create(doc, version); err != nil {
+		u.discard(ctx, image)
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save document")
+	}
+	doc.Versions[0].Image = *image
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+		After:        map[string]interface{}{"patient_id": doc.PatientID, "category": doc.Category, "title": doc.Title, "version": 1},
+	})
+	return doc, nil
+}
+
+// AddVersion uploads a new revision; earlier versions stay downloadable
+func (u *documentUsecase) AddVersion(ctx context.Context, id uuid.UUID, file multipart.File, fileHeader *multipart.FileHeader, note string) (*models.PatientDocument, error) {
+	actor, doc, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := u.store(ctx, file, fileHeader, doc.PatientID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.DocumentVersion{ImageID: image.ID, UploadedBy: actor.ID, Note: note}
+	if err := u.repo.AddVersion(doc.ID, version); err != nil {
+		u.discard(ctx, image)
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save document version")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+		Before:       map[string]interface{}{"version": doc.CurrentVersion},
+		After:        map[string]interface{}{"version": version.Version},
+	})
+	return u.repo.FindByID(doc.ID)
+}
+
+func (u *documentUsecase) GetByID(ctx context.Context, id uuid.UUID) (*models.PatientDocument, error) {
+	_, doc, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+	})
+	return doc, nil
+}
+
+// ListByPatient returns the patient's documents the caller may see
+func (u *documentUsecase) ListByPatient(ctx context.Context, filter *dto.DocumentFilter) (*dto.ListResponse, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 10
+	}
+	if actor.Role != models.RoleAdmin && actor.ID != filter.PatientID {
+		filter.VisibleTo = &actor.ID
+	}
+
+	docs, total, err := u.repo.List(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve documents")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   "patient:" + filter.PatientID.String(),
+	})
+
+	data := make([]interface{}, len(docs))
+	for i, d := range docs {
+		data[i] = d
+	}
+	totalPages := int(total) / filter.PageSize
+	if int(total)%filter.PageSize > 0 {
+		totalPages++
+	}
+	return &dto.ListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// DownloadURL returns a short-lived link to one version (0 = current)
+func (u *documentUsecase) DownloadURL(ctx context.Context, id uuid.UUID, version int) (*dto.DocumentDownloadResponse, error) {
+	_, doc, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = doc.CurrentVersion
+	}
+
+	v, err := u.repo.FindVersion(doc.ID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Version not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+
+	link, err := u.storage.URL(ctx, v.Image.PublicID, documentDownloadTTL)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to create download link")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDownload,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+		After:        map[string]interface{}{"version": version},
+	})
+	return &dto.DocumentDownloadResponse{
+		URL:       link,
+		Version:   version,
+		FileName:  v.Image.FileName,
+		FileType:  v.Image.FileType,
+		ExpiresAt: time.Now().Add(documentDownloadTTL),
+	}, nil
+}
+
+func (u *documentUsecase) UpdateTags(ctx context.Context, id uuid.UUID, tags []string) (*models.PatientDocument, error) {
+	_, doc, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.ReplaceTags(doc.ID, normalized); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update tags")
+	}
+
+	before := make([]string, len(doc.Tags))
+	for i, t := range doc.Tags {
+		before[i] = t.Tag
+	}
+	sort.Strings(before)
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+		Before:       map[string]interface{}{"tags": before},
+		After:        map[string]interface{}{"tags": normalized},
+	})
+	return u.repo.FindByID(doc.ID)
+}
+
+// GrantAccess shares a document with a staff member, e.g. a treating doctor.
+// Only the patient and admins can share.
+func (u *documentUsecase) GrantAccess(ctx context.Context, id uuid.UUID, req *dto.DocumentAccessRequest) error {
+	actor, doc, err := u.manageable(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	grantee, err := u.userRepo.FindByID(req.UserID)
+	if err != nil || grantee == nil || grantee.IsDeleted {
+		return helpers.NewAppError(http.StatusNotFound, "User not found")
+	}
+	if grantee.Role == models.RolePatient {
+		return helpers.NewAppError(http.StatusBadRequest, "Documents can only be shared with staff")
+	}
+
+	if err := u.repo.Grant(&models.DocumentAccess{DocumentID: doc.ID, UserID: grantee.ID, GrantedBy: actor.ID}); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to grant access")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionAccessGrant,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+		After:        map[string]interface{}{"user_id": grantee.ID},
+	})
+	return nil
+}
+
+func (u *documentUsecase) RevokeAccess(ctx context.Context, id, userID uuid.UUID) error {
+	_, doc, err := u.manageable(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.Revoke(doc.ID, userID); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to revoke access")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionAccessRevoke,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+		Before:       map[string]interface{}{"user_id": userID},
+	})
+	return nil
+}
+
+// Delete hides the document; stored files are kept for the medical record
+func (u *documentUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+	actor, doc, err := u.viewable(ctx, id)
+	if err != nil {
+		return err
+	}
+	if actor.Role != models.RoleAdmin && actor.ID != doc.UploadedBy {
+		return helpers.NewAppError(http.StatusForbidden, "Only the uploader or an admin can delete this document")
+	}
+
+	if err := u.repo.SoftDelete(doc.ID); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to delete document")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceDocument,
+		ResourceID:   doc.ID.String(),
+	})
+	return nil
+}
+
+// viewable loads the document and checks the caller is the patient, the
+// uploader, an admin, or holds a grant
+func (u *documentUsecase) viewable(ctx context.Context, id uuid.UUID) (*models.User, *models.PatientDocument, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := u.find(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if actor.Role == models.RoleAdmin || actor.ID == doc.PatientID || actor.ID == doc.UploadedBy {
+		return actor, doc, nil
+	}
+	granted, err := u.repo.HasGrant(doc.ID, actor.ID)
+	if err != nil {
+		return nil, nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if !granted {
+		return nil, nil, helpers.NewAppError(http.StatusForbidden, "You do not have access to this document")
+	}
+	return actor, doc, nil
+}
+
+// manageable checks the caller may change who has access
+func (u *documentUsecase) manageable(ctx context.Context, id uuid.UUID) (*models.User, *models.PatientDocument, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := u.find(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor.Role != models.RoleAdmin && actor.ID != doc.PatientID {
+		return nil, nil, helpers.NewAppError(http.StatusForbidden, "Only the patient or an admin can manage access")
+	}
+	return actor, doc, nil
+}
+
+func (u *documentUsecase) find(id uuid.UUID) (*models.PatientDocument, error) {
+	doc, err := u.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Document not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	return doc, nil
+}
+
+// resolvePatient works out whose record the upload belongs to. Patients can
+// only upload to their own record; staff must name a patient.
+func (u *documentUsecase) resolvePatient(actor *models.User, patientID string) (uuid.UUID, error) {
+	if actor.Role == models.RolePatient {
+		if patientID != "" && patientID != actor.ID.String() {
+			return uuid.Nil, helpers.NewAppError(http.StatusForbidden, "Patients can only upload to their own record")
+		}
+		return actor.ID, nil
+	}
+
+	if patientID == "" {
+		return uuid.Nil, helpers.NewAppError(http.StatusBadRequest, "patient_id is required")
+	}
+	patient, err := u.userRepo.FindByID(patientID)
+	if err != nil || patient == nil || patient.IsDeleted || patient.Role != models.RolePatient {
+		return uuid.Nil, helpers.NewAppError(http.StatusNotFound, "Patient not found")
+	}
+	return patient.ID, nil
+}
+
+// store validates and uploads the file and records it as a document image
+func (u *documentUsecase) store(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, patientID uuid.UUID) (*models.Image, error) {
+	contentType, err := validators.ValidateDocument(file, fileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := u.storage.Put(ctx, file, storage.PutOptions{
+		Folder:      "documents/" + patientID.String(),
+		FileName:    fileHeader.Filename,
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		Private:     true,
+	})
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to upload document")
+	}
+
+	image := &models.Image{
+		UserID:    patientID,
+		URL:       obj.URL,
+		PublicID:  obj.Key,
+		FileName:  obj.FileName,
+		FileSize:  obj.Size,
+		FileType:  contentType,
+		Width:     obj.Width,
+		Height:    obj.Height,
+		ImageType: models.ImageTypeDocument,
+	}
+	if err := u.imageRepo.Create(image); err != nil {
+		_ = u.storage.Delete(ctx, obj.Key)
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save document file")
+	}
+	return image, nil
+}
+
+// discard removes an uploaded file whose document record could not be saved
+func (u *documentUsecase) discard(ctx context.Context, image *models.Image) {
+	if err := u.storage.Delete(ctx, image.PublicID); err != nil {
+		log.Println("Failed to remove orphaned document file:", err)
+	}
+	if err := u.imageRepo.Delete(image.ID); err != nil {
+		log.Println("Failed to remove orphaned document record:", err)
+	}
+}
+
+func requireActor(ctx context.Context) (*models.User, error) {
+	actor := actorFromContext(ctx)
+	if actor == nil {
+		return nil, helpers.NewAppError(http.StatusUnauthorized, "Unauthorized")
+	}
+	return actor, nil
+}
+
+// normalizeTags lowercases, trims and de-duplicates tags
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if len(t) > maxDocumentTagLen {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Tags must be at most 50 characters")
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxDocumentTags {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "At most 20 tags are allowed")
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"hospital_management_system/internal/events"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// recordEvent adds e to the outbox as part of tx, so it is published only
+// if the change it describes commits
+func recordEvent(outbox repository.OutboxRepository, tx *gorm.DB, e events.Event) error {
+	msg, err := events.NewOutboxMessage(e)
+	if err != nil {
+		return err
+	}
+	return outbox.Add(tx, msg)
+}
+
+func bookingCreatedEvent(b *models.Booking) events.BookingCreated {
+	return events.BookingCreated{
+		BookingID:    b.ID,
+		PatientID:    b.PatientID,
+		BookingType:  string(b.BookingType),
+		Status:       string(b.Status),
+		RoomID:       b.RoomID,
+		CheckInDate:  b.CheckInDate,
+		CheckOutDate: b.CheckOutDate,
+		ServiceID:    b.ServiceID,
+		ScheduledAt:  b.ScheduledAt,
+		TotalPrice:   b.TotalPrice,
+	}
+}
@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/templates"
+	htmltemplate "html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"gorm.io/gorm"
+)
+
+// EmailTemplateUsecase renders emails from the active stored template of
+// each type, falling back to the bundled default
+type EmailTemplateUsecase interface {
+	Render(typ models.EmailType, data map[string]string) (*dto.RenderedEmail, error)
+
+	List(ctx context.Context) ([]dto.EmailTemplateSummary, error)
+	Versions(ctx context.Context, typ models.EmailType) ([]models.EmailTemplate, error)
+	Create(ctx context.Context, typ models.EmailType, req *dto.EmailTemplateRequest) (*models.EmailTemplate, error)
+	Activate(ctx context.Context, typ models.EmailType, version int) (*models.EmailTemplate, error)
+	ResetToDefault(ctx context.Context, typ models.EmailType) error
+	Preview(ctx context.Context, typ models.EmailType, req *dto.EmailTemplatePreviewRequest) (*dto.RenderedEmail, error)
+}
+
+type emailTemplateUsecase struct {
+	repo    repository.EmailTemplateRepository
+	auditUC AuditUsecase
+}
+
+func EmailTemplateNewUsecase(repo repository.EmailTemplateRepository, auditUC AuditUsecase) EmailTemplateUsecase {
+	return &emailTemplateUsecase{repo: repo, auditUC: auditUC}
+}
+
+// emailTemplateSource is the raw text of a template before rendering
+type emailTemplateSource struct {
+	subject, html, text string
+}
+
+func (u *emailTemplateUsecase) Render(typ models.EmailType, data map[string]string) (*dto.RenderedEmail, error) {
+	def, err := templates.Email(string(typ))
+	if err != nil {
+		return nil, err
+	}
+
+	// A broken stored template must not stop OTPs going out
+	stored, err := u.repo.FindActive(typ)
+	switch {
+	case err == nil:
+		rendered, renderErr := renderEmail(sourceOf(stored), data)
+		if renderErr == nil {
+			return rendered, nil
+		}
+		log.Printf("Email template %s v%d failed to render, using default: %v", typ, stored.Version, renderErr)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Printf("Failed to load email template %s, using default: %v", typ, err)
+	}
+
+	return renderEmail(emailTemplateSource{def.Subject, def.HTML, def.Text}, data)
+}
+
+func (u *emailTemplateUsecase) List(ctx context.Context) ([]dto.EmailTemplateSummary, error) {
+	latest, err := u.repo.ListLatest()
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load email templates")
+	}
+	byType := make(map[models.EmailType]models.EmailTemplate, len(latest))
+	for _, t := range latest {
+		byType[t.Type] = t
+	}
+
+	summaries := make([]dto.EmailTemplateSummary, 0, len(models.TemplatedEmailTypes))
+	for _, typ := range models.TemplatedEmailTypes {
+		def, err := templates.Email(string(typ))
+		if err != nil {
+			return nil, err
+		}
+		summary := dto.EmailTemplateSummary{
+			Type:      string(typ),
+			Source:    "default",
+			Subject:   def.Subject,
+			Variables: sortedKeys(def.Sample),
+		}
+		if t, ok := byType[typ]; ok {
+			summary.LatestVersion = t.Version
+			if t.IsActive {
+				summary.Source = "database"
+				summary.ActiveVersion = t.Version
+				summary.Subject = t.Subject
+				updated := t.UpdatedAt
+				summary.UpdatedAt = &updated
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (u *emailTemplateUsecase) Versions(ctx context.Context, typ models.EmailType) ([]models.EmailTemplate, error) {
+	if err := validateEmailType(typ); err != nil {
+		return nil, err
+	}
+	versions, err := u.repo.ListVersions(typ)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load template versions")
+	}
+	return versions, nil
+}
+
+func (u *emailTemplateUsecase) Create(ctx context.Context, typ models.EmailType, req *dto.EmailTemplateRequest) (*models.EmailTemplate, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEmailType(typ); err != nil {
+		return nil, err
+	}
+
+	tmpl := &models.EmailTemplate{
+		Type:      typ,
+		Subject:   strings.TrimSpace(req.Subject),
+		HTMLBody:  req.HTMLBody,
+		TextBody:  req.TextBody,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedBy: actor.ID,
+	}
+	if tmpl.Subject == "" || strings.TrimSpace(tmpl.HTMLBody) == "" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Subject and HTML body are required")
+	}
+
+	// Render against the sample data so typos in variable names are caught
+	// now rather than when a patient's email fails
+	def, err := templates.Email(string(typ))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := renderEmail(sourceOf(tmpl), def.Sample); err != nil {
+		return nil, helpers.NewAppError(http.StatusUnprocessableEntity, "Invalid template: "+err.Error())
+	}
+
+	if err := u.repo.CreateVersion(tmpl, req.Activate); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save template")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceEmailTemplate,
+		ResourceID:   string(typ),
+		After:        map[string]interface{}{"version": tmpl.Version, "active": tmpl.IsActive, "subject": tmpl.Subject},
+	})
+	return tmpl, nil
+}
+
+func (u *emailTemplateUsecase) Activate(ctx context.Context, typ models.EmailType, version int) (*models.EmailTemplate, error) {
+	if err := validateEmailType(typ); err != nil {
+		return nil, err
+	}
+
+	before := 0
+	if current, err := u.repo.FindActive(typ); err == nil {
+		before = current.Version
+	}
+
+	found, err := u.repo.Activate(typ, version)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to activate template")
+	}
+	if !found {
+		return nil, helpers.NewAppError(http.StatusNotFound, "Template version not found")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionActivate,
+		ResourceType: models.AuditResourceEmailTemplate,
+		ResourceID:   string(typ),
+		Before:       map[string]interface{}{"active_version": before},
+		After:        map[string]interface{}{"active_version": version},
+	})
+	return u.repo.FindVersion(typ, version)
+}
+
+func (u *emailTemplateUsecase) ResetToDefault(ctx context.Context, typ models.EmailType) error {
+	if err := validateEmailType(typ); err != nil {
+		return err
+	}
+
+	before := 0
+	if current, err := u.repo.FindActive(typ); err == nil {
+		before = current.Version
+	}
+	if err := u.repo.Deactivate(typ); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to reset template")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionActivate,
+		ResourceType: models.AuditResourceEmailTemplate,
+		ResourceID:   string(typ),
+		Before:       map[string]interface{}{"active_version": before},
+		After:        map[string]interface{}{"active_version": 0},
+	})
+	return nil
+}
+
+func (u *emailTemplateUsecase) Preview(ctx context.Context, typ models.EmailType, req *dto.EmailTemplatePreviewRequest) (*dto.RenderedEmail, error) {
+	if err := validateEmailType(typ); err != nil {
+		return nil, err
+	}
+	def, err := templates.Email(string(typ))
+	if err != nil {
+		return nil, err
+	}
+
+	data := def.Sample
+	for k, v := range req.Data {
+		data[k] = v
+	}
+
+	var src emailTemplateSource
+	switch {
+	case req.Subject != "" || req.HTMLBody != "":
+		src = emailTemplateSource{req.Subject, req.HTMLBody, req.TextBody}
+	case req.Version > 0:
+		stored, err := u.repo.FindVersion(typ, req.Version)
+		if err != nil {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Template version not found")
+		}
+		src = sourceOf(stored)
+	default:
+		return u.Render(typ, data)
+	}
+
+	rendered, err := renderEmail(src, data)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusUnprocessableEntity, "Invalid template: "+err.Error())
+	}
+	return rendered, nil
+}
+
+func sourceOf(t *models.EmailTemplate) emailTemplateSource {
+	return emailTemplateSource{t.Subject, t.HTMLBody, t.TextBody}
+}
+
+// renderEmail fills in all three parts. Unknown variables are an error
+// rather than silently rendering as blanks.
+func renderEmail(src emailTemplateSource, data map[string]string) (*dto.RenderedEmail, error) {
+	subject, err := executeText("subject", src.subject, data)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("html").Option("missingkey=error").Parse(src.html)
+	if err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+	var html bytes.Buffer
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+
+	text := ""
+	if src.text != "" {
+		if text, err = executeText("text", src.text, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &dto.RenderedEmail{
+		Subject: strings.TrimSpace(subject),
+		HTML:    html.String(),
+		Text:    text,
+	}, nil
+}
+
+func executeText(name, src string, data map[string]string) (string, error) {
+	tmpl, err := texttemplate.New(name).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func validateEmailType(typ models.EmailType) error {
+	if !models.IsTemplatedEmailType(typ) {
+		return helpers.NewAppError(http.StatusNotFound, "Unknown email type")
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
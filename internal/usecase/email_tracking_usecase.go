@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailTrackingUsecase follows emails after they are sent: bounces, opens
+// and unsubscribes, and the suppression list they feed
+type EmailTrackingUsecase interface {
+	// Suppressed returns the suppression that stops mail of typ to address,
+	// or nil when it may be sent
+	Suppressed(address string, typ models.EmailType) (*models.EmailSuppression, error)
+	// Decorate adds the unsubscribe link and headers to non-transactional
+	// mail and, when enabled, the open-tracking pixel to marketing mail
+	Decorate(email *models.Email, job *helpers.EmailJob)
+	// UnsubscribeAddress checks an unsubscribe token and returns its
+	// address, changing nothing
+	UnsubscribeAddress(token string) (string, error)
+	// Unsubscribe suppresses non-transactional mail to the token's address
+	Unsubscribe(token string) error
+	// RecordOpen counts an open of the email when sig is its pixel signature
+	RecordOpen(id uuid.UUID, sig string)
+	// VerifyWebhook checks the token bounce and provider webhooks present
+	VerifyWebhook(token string) error
+	// ProcessDSN applies a bounce message returned by a mail server
+	ProcessDSN(r io.Reader) (*dto.EmailTrackingResult, error)
+	// ProcessProviderEvents applies a batch of provider webhook events
+	ProcessProviderEvents(events []dto.EmailProviderEvent) (*dto.EmailTrackingResult, error)
+	ListSuppressions(filter *dto.EmailSuppressionFilter) (*dto.ListResponse, error)
+	DeleteSuppression(ctx context.Context, id uuid.UUID) error
+}
+
+type emailTrackingUsecase struct {
+	repo         repository.EmailRepository
+	suppressions repository.EmailSuppressionRepository
+	auditUC      AuditUsecase
+	secret       []byte
+	baseURL      string
+}
+
+func EmailTrackingNewUsecase(repo repository.EmailRepository, suppressions repository.EmailSuppressionRepository, auditUC AuditUsecase) EmailTrackingUsecase {
+	return &emailTrackingUsecase{
+		repo:         repo,
+		suppressions: suppressions,
+		auditUC:      auditUC,
+		secret:       []byte("email-tracking:" + config.ENV.JWTSecret),
+		baseURL:      strings.TrimRight(config.ENV.BaseURL, "/") + "/emails",
+	}
+}
+
+func (u *emailTrackingUsecase) Suppressed(address string, typ models.EmailType) (*models.EmailSuppression, error) {
+	suppressions, err := u.suppressions.FindByEmail(address)
+	if err != nil {
+		return nil, err
+	}
+	for i := range suppressions {
+		if suppressions[i].Blocks(typ) {
+			return &suppressions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (u *emailTrackingUsecase) Decorate(email *models.Email, job *helpers.EmailJob) {
+	if email.Type == models.EmailTypeMarketing && config.ENV.EmailOpenTracking {
+		pixel := fmt.Sprintf(`<img src="%s/%s/open?sig=%s" width="1" height="1" alt="" style="display:none">`,
+			u.baseURL, email.ID, u.sign("open", email.ID.String()))
+		job.Body = appendToHTML(job.Body, pixel)
+	}
+
+	if models.IsTransactionalEmailType(email.Type) {
+		return
+	}
+	link := u.baseURL + "/unsubscribe?token=" + url.QueryEscape(u.unsubscribeToken(email.Email))
+	job.Body = appendToHTML(job.Body, fmt.Sprintf(
+		`<p style="font-size:12px;color:#888">Don't want these emails? <a href="%s">Unsubscribe</a></p>`, html.EscapeString(link)))
+	if job.TextBody != "" {
+		job.TextBody += "\n\nUnsubscribe: " + link
+	}
+	if job.Headers == nil {
+		job.Headers = make(map[string]string)
+	}
+	// One-click unsubscribe from the mail client (RFC 8058)
+	job.Headers["List-Unsubscribe"] = "<" + link + ">"
+	job.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+}
+
+func (u *emailTrackingUsecase) UnsubscribeAddress(token string) (string, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	address, err := base64.RawURLEncoding.DecodeString(encoded)
+	if !ok || err != nil || !hmac.Equal([]byte(sig), []byte(u.sign("unsubscribe", string(address)))) {
+		return "", helpers.NewAppError(http.StatusBadRequest, "Invalid unsubscribe link")
+	}
+	return string(address), nil
+}
+
+func (u *emailTrackingUsecase) Unsubscribe(token string) error {
+	address, err := u.UnsubscribeAddress(token)
+	if err != nil {
+		return err
+	}
+
+	if err := u.suppressions.Add(&models.EmailSuppression{
+		Email:  address,
+		Reason: models.SuppressionUnsubscribe,
+	}); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to unsubscribe")
+	}
+	return nil
+}
+
+func (u *emailTrackingUsecase) RecordOpen(id uuid.UUID, sig string) {
+	if !hmac.Equal([]byte(sig), []byte(u.sign("open", id.String()))) {
+		return
+	}
+	if err := u.repo.RecordOpen(id); err != nil {
+		log.Println("Failed to record email open:", err)
+	}
+}
+
+func (u *emailTrackingUsecase) VerifyWebhook(token string) error {
+	if config.ENV.EmailWebhookSecret == "" {
+		return helpers.NewAppError(http.StatusNotFound, "Email webhooks are disabled")
+	}
+	if !hmac.Equal([]byte(token), []byte(config.ENV.EmailWebhookSecret)) {
+		return helpers.NewAppError(http.StatusUnauthorized, "Invalid webhook token")
+	}
+	return nil
+}
+
+func (u *emailTrackingUsecase) ProcessDSN(r io.Reader) (*dto.EmailTrackingResult, error) {
+	dsn, err := helpers.ParseDSN(r)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid bounce message: "+err.Error())
+	}
+
+	result := &dto.EmailTrackingResult{}
+	for _, rcpt := range dsn.Recipients {
+		if !rcpt.Hard() {
+			// Soft bounces are the sending server's to retry
+			result.Ignored++
+			continue
+		}
+		u.bounce(result, dsn.EmailID, rcpt.Address, strings.TrimSpace(rcpt.Status+" "+rcpt.Diagnostic))
+	}
+	return result, nil
+}
+
+func (u *emailTrackingUsecase) ProcessProviderEvents(events []dto.EmailProviderEvent) (*dto.EmailTrackingResult, error) {
+	result := &dto.EmailTrackingResult{}
+	for _, e := range events {
+		emailID, _ := uuid.Parse(e.EmailID)
+		switch {
+		case e.Event == "bounce" && e.Type != "blocked":
+			u.bounce(result, emailID, e.Email, strings.TrimSpace(e.Status+" "+e.Reason))
+		case e.Event == "spamreport":
+			u.suppress(result, emailID, e.Email, models.SuppressionComplaint, "")
+		case e.Event == "unsubscribe":
+			u.suppress(result, emailID, e.Email, models.SuppressionUnsubscribe, "")
+		case e.Event == "open" && emailID != uuid.Nil:
+			if err := u.repo.RecordOpen(emailID); err != nil {
+				log.Println("Failed to record email open:", err)
+				continue
+			}
+			result.Opened++
+		default:
+			result.Ignored++
+		}
+	}
+	return result, nil
+}
+
+// bounce marks the email bounced and suppresses the address for good
+func (u *emailTrackingUsecase) bounce(result *dto.EmailTrackingResult, emailID uuid.UUID, address, reason string) {
+	if emailID != uuid.Nil {
+		if err := u.repo.MarkBounced(emailID, reason); err != nil {
+			log.Println("Failed to mark email bounced:", err)
+		} else {
+			result.Bounced++
+		}
+	}
+	u.suppress(result, emailID, address, models.SuppressionHardBounce, reason)
+}
+
+func (u *emailTrackingUsecase) suppress(result *dto.EmailTrackingResult, emailID uuid.UUID, address string, reason models.EmailSuppressionReason, detail string) {
+	if address == "" {
+		result.Ignored++
+		return
+	}
+	s := &models.EmailSuppression{Email: address, Reason: reason}
+	if detail != "" {
+		s.Detail = &detail
+	}
+	if emailID != uuid.Nil {
+		s.EmailID = &emailID
+	}
+	if err := u.suppressions.Add(s); err != nil {
+		log.Printf("Failed to suppress %s: %v", address, err)
+		return
+	}
+	result.Suppressed++
+}
+
+func (u *emailTrackingUsecase) ListSuppressions(filter *dto.EmailSuppressionFilter) (*dto.ListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	suppressions, total, err := u.suppressions.List(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve suppressions")
+	}
+
+	data := make([]interface{}, len(suppressions))
+	for i, s := range suppressions {
+		data[i] = s
+	}
+
+	totalPages := int(total) / filter.PageSize
+	if int(total)%filter.PageSize > 0 {
+		totalPages++
+	}
+
+	return &dto.ListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (u *emailTrackingUsecase) DeleteSuppression(ctx context.Context, id uuid.UUID) error {
+	s, err := u.suppressions.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return helpers.NewAppError(http.StatusNotFound, "Suppression not found")
+	}
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to delete suppression")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceEmailSuppression,
+		ResourceID:   s.ID.String(),
+		Before:       s,
+	})
+	return nil
+}
+
+// unsubscribeToken encodes the address with its signature. It never
+// expires: old emails must keep working.
+func (u *emailTrackingUsecase) unsubscribeToken(address string) string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	return base64.RawURLEncoding.EncodeToString([]byte(address)) + "." + u.sign("unsubscribe", address)
+}
+
+func (u *emailTrackingUsecase) sign(purpose, value string) string {
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte(purpose + "\n" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// appendToHTML puts fragment just before </body>, or at the end
+func appendToHTML(body, fragment string) string {
+	if i := strings.LastIndex(strings.ToLower(body), "</body>"); i >= 0 {
+		return body[:i] + fragment + body[i:]
+	}
+	return body + fragment
+}
@@ -1,23 +1,70 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/queue"
 	"hospital_management_system/internal/infra/repository"
 	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type EmailUsecase interface {
 	CreateEmail(userID uuid.UUID, to, subject, body string, typ models.EmailType) (models.Email, error)
+	QueueEmail(userID uuid.UUID, to, subject, body string, typ models.EmailType, attachments ...models.EmailAttachment) (models.Email, error)
+	// QueueTemplate renders the template for typ with data and queues the
+	// result, with any attachments such as an invoice
+	QueueTemplate(userID uuid.UUID, to string, typ models.EmailType, data map[string]string, attachments ...models.EmailAttachment) (models.Email, error)
+	// SendTest queues a preview of a template to the caller or req.To
+	SendTest(ctx context.Context, typ models.EmailType, req *dto.EmailTemplateTestRequest) (*dto.RenderedEmail, error)
+	// Requeue gives a failed email a fresh set of attempts. Like Resend it
+	// refuses secret emails and suppressed recipients.
+	Requeue(ctx context.Context, id uuid.UUID) (*models.Email, error)
+	// RequeueFailed requeues the oldest failed emails, up to a batch at a
+	// time. Secret emails are skipped; those to suppressed recipients are
+	// listed as failed.
+	RequeueFailed(ctx context.Context) (*dto.EmailRequeueResponse, error)
+	// Search lists the email log, without bodies
+	Search(filter *dto.EmailFilter) (*dto.ListResponse, error)
+	// Get returns an email with its body and attachment details. Bodies of
+	// secret emails are redacted.
+	Get(ctx context.Context, id uuid.UUID) (*models.Email, error)
+	// Resend publishes a failed or pending email again. Unlike Requeue it
+	// keeps the attempt count, so the send is recorded as a further attempt.
+	// Secret emails are not resent: their codes may have expired or been
+	// used, so the user asks for a new one instead.
+	Resend(ctx context.Context, id uuid.UUID) (*models.Email, error)
 }
 
+// requeueBatch bounds how many failed emails one request puts back on the queue
+const requeueBatch = 500
+
+// redactedBody replaces the body of a secret email wherever it is shown
+const redactedBody = "[redacted: this email carries a one-time code or reset link]"
+
+// maxAttachmentBytes bounds the attachments of one email, well below what
+// mail providers accept once base64 has grown them by a third
+const maxAttachmentBytes = 10 << 20
+
 type emailUsecase struct {
-	repo repository.EmailRepository
+	repo       repository.EmailRepository
+	outbox     repository.OutboxRepository
+	templateUC EmailTemplateUsecase
+	trackingUC EmailTrackingUsecase
+	auditUC    AuditUsecase
 }
 
-func EmailNewUsecase(repo repository.EmailRepository) EmailUsecase {
-	return &emailUsecase{repo: repo}
+func EmailNewUsecase(repo repository.EmailRepository, outbox repository.OutboxRepository, templateUC EmailTemplateUsecase, trackingUC EmailTrackingUsecase, auditUC AuditUsecase) EmailUsecase {
+	return &emailUsecase{repo: repo, outbox: outbox, templateUC: templateUC, trackingUC: trackingUC, auditUC: auditUC}
 }
 
 func (u *emailUsecase) CreateEmail(userID uuid.UUID, to, subject, body string, typ models.EmailType) (models.Email, error) {
@@ -37,3 +84,346 @@ func (u *emailUsecase) CreateEmail(userID uuid.UUID, to, subject, body string, t
 
 	return *email, nil
 }
+
+// QueueEmail records the email and publishes it to the email worker
+func (u *emailUsecase) QueueEmail(userID uuid.UUID, to, subject, body string, typ models.EmailType, attachments ...models.EmailAttachment) (models.Email, error) {
+	return u.queue(&models.Email{
+		UserID:      userID,
+		Email:       to,
+		Subject:     subject,
+		Body:        body,
+		Type:        typ,
+		Attachments: attachments,
+	})
+}
+
+func (u *emailUsecase) QueueTemplate(userID uuid.UUID, to string, typ models.EmailType, data map[string]string, attachments ...models.EmailAttachment) (models.Email, error) {
+	rendered, err := u.templateUC.Render(typ, data)
+	if err != nil {
+		return models.Email{}, fmt.Errorf("failed to render %s email: %w", typ, err)
+	}
+	return u.queue(&models.Email{
+		UserID:      userID,
+		Email:       to,
+		Subject:     rendered.Subject,
+		Body:        rendered.HTML,
+		TextBody:    rendered.Text,
+		Type:        typ,
+		Attachments: attachments,
+	})
+}
+
+func (u *emailUsecase) SendTest(ctx context.Context, typ models.EmailType, req *dto.EmailTemplateTestRequest) (*dto.RenderedEmail, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := u.templateUC.Preview(ctx, typ, &req.EmailTemplatePreviewRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		to = actor.Email
+	}
+	if _, err := u.queue(&models.Email{
+		UserID:   actor.ID,
+		Email:    to,
+		Subject:  "[TEST] " + rendered.Subject,
+		Body:     rendered.HTML,
+		TextBody: rendered.Text,
+		Type:     typ,
+	}); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to queue test email")
+	}
+	return rendered, nil
+}
+
+func (u *emailUsecase) Requeue(ctx context.Context, id uuid.UUID) (*models.Email, error) {
+	email, err := u.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Email not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if err := u.requeue(ctx, email); err != nil {
+		return nil, err
+	}
+	return u.findRedacted(id)
+}
+
+func (u *emailUsecase) RequeueFailed(ctx context.Context) (*dto.EmailRequeueResponse, error) {
+	// Secret emails are never requeued, so they are left out rather than
+	// filling the batch
+	ids, err := u.repo.ListIDsByStatus(models.EmailStatusFailed, requeueBatch, models.SecretEmailTypes...)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load failed emails")
+	}
+
+	resp := &dto.EmailRequeueResponse{}
+	for _, id := range ids {
+		email, err := u.repo.FindByID(id)
+		if err == nil {
+			err = u.requeue(ctx, email)
+		}
+		if err != nil {
+			resp.Failed = append(resp.Failed, id)
+			continue
+		}
+		resp.Requeued++
+	}
+	return resp, nil
+}
+
+func (u *emailUsecase) Search(filter *dto.EmailFilter) (*dto.ListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	emails, total, err := u.repo.Search(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve emails")
+	}
+
+	data := make([]interface{}, len(emails))
+	for i, e := range emails {
+		data[i] = e
+	}
+
+	totalPages := int(total) / filter.PageSize
+	if int(total)%filter.PageSize > 0 {
+		totalPages++
+	}
+
+	return &dto.ListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Get is audited: bodies hold patient details
+func (u *emailUsecase) Get(ctx context.Context, id uuid.UUID) (*models.Email, error) {
+	email, err := u.repo.FindWithAttachments(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Email not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceEmail,
+		ResourceID:   email.ID.String(),
+	})
+	redact(email)
+	return email, nil
+}
+
+func (u *emailUsecase) Resend(ctx context.Context, id uuid.UUID) (*models.Email, error) {
+	email, err := u.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Email not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if err := u.checkSendable(email); err != nil {
+		return nil, err
+	}
+
+	// Conditional on the version loaded, so a double click cannot send twice
+	errNotResendable := errors.New("email not resendable")
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		reset, err := u.repo.ResetForResend(tx, email.ID, email.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if !reset {
+			return errNotResendable
+		}
+		return u.addJob(tx, email, email.Attempts)
+	})
+	if errors.Is(err, errNotResendable) {
+		return nil, helpers.NewAppError(http.StatusConflict, "Only failed or pending emails can be resent; reload and try again")
+	}
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to resend email")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionResend,
+		ResourceType: models.AuditResourceEmail,
+		ResourceID:   email.ID.String(),
+		Before:       map[string]interface{}{"status": email.Status, "attempts": email.Attempts, "error": email.Error},
+	})
+	return u.findRedacted(id)
+}
+
+// findRedacted reloads an email to return it, secrets redacted
+func (u *emailUsecase) findRedacted(id uuid.UUID) (*models.Email, error) {
+	email, err := u.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	redact(email)
+	return email, nil
+}
+
+// redact hides the body of a secret email
+func redact(email *models.Email) {
+	if models.IsSecretEmailType(email.Type) {
+		email.Body = redactedBody
+		email.TextBody = redactedBody
+	}
+}
+
+// checkSendable refuses to send an email again when it is secret, its code
+// may have expired or been used, or its recipient is suppressed
+func (u *emailUsecase) checkSendable(email *models.Email) error {
+	if models.IsSecretEmailType(email.Type) {
+		return helpers.NewAppError(http.StatusConflict, "One-time codes and reset links are not resent; the user must request a new one")
+	}
+
+	suppression, err := u.trackingUC.Suppressed(email.Email, email.Type)
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to check suppressions")
+	}
+	if suppression != nil {
+		return helpers.NewAppError(http.StatusConflict, "Recipient is suppressed: "+string(suppression.Reason))
+	}
+	return nil
+}
+
+// requeue resets a failed email and queues it again. The reset is
+// conditional so two admins cannot queue the same email twice.
+func (u *emailUsecase) requeue(ctx context.Context, email *models.Email) error {
+	if err := u.checkSendable(email); err != nil {
+		return err
+	}
+
+	errNotFailed := errors.New("email not failed")
+	err := u.outbox.Transaction(func(tx *gorm.DB) error {
+		reset, err := u.repo.ResetFailed(tx, email.ID)
+		if err != nil {
+			return err
+		}
+		if !reset {
+			return errNotFailed
+		}
+		return u.addJob(tx, email, 0)
+	})
+	if errors.Is(err, errNotFailed) {
+		return helpers.NewAppError(http.StatusConflict, "Only failed emails can be requeued")
+	}
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to requeue email")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionRequeue,
+		ResourceType: models.AuditResourceEmail,
+		ResourceID:   email.ID.String(),
+		Before:       map[string]interface{}{"status": email.Status, "attempts": email.Attempts, "error": email.Error},
+	})
+	return nil
+}
+
+// queue records the email and, in the same transaction, its job for the
+// email worker, so the email goes out even if the broker is down right now.
+// Mail to a suppressed address is recorded but never sent.
+func (u *emailUsecase) queue(email *models.Email) (models.Email, error) {
+	if err := checkAttachments(email.Attachments); err != nil {
+		return models.Email{}, err
+	}
+	suppression, err := u.trackingUC.Suppressed(email.Email, email.Type)
+	if err != nil {
+		return models.Email{}, fmt.Errorf("failed to check suppressions: %w", err)
+	}
+	if suppression != nil {
+		reason := "recipient suppressed: " + string(suppression.Reason)
+		email.Status = models.EmailStatusSuppressed
+		email.Error = &reason
+		if err := u.repo.CreateEmail(email); err != nil {
+			return models.Email{}, fmt.Errorf("failed to create email: %w", err)
+		}
+		return *email, nil
+	}
+
+	email.Status = models.EmailStatusPending
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.repo.CreateEmailTx(tx, email); err != nil {
+			return err
+		}
+		return u.addJob(tx, email, 0)
+	})
+	if err != nil {
+		return models.Email{}, fmt.Errorf("failed to create email: %w", err)
+	}
+	return *email, nil
+}
+
+// addJob writes the worker's EmailJob for email to the outbox, counting
+// its attempts from priorAttempts. Emails loaded from the database have
+// their attachments loaded here.
+func (u *emailUsecase) addJob(tx *gorm.DB, email *models.Email, priorAttempts int) error {
+	job := helpers.EmailJob{
+		EmailID:       email.ID,
+		To:            email.Email,
+		Subject:       email.Subject,
+		Body:          email.Body,
+		TextBody:      email.TextBody,
+		PriorAttempts: priorAttempts,
+	}
+	attachments := email.Attachments
+	if attachments == nil {
+		var err error
+		if attachments, err = u.repo.ListAttachments(email.ID); err != nil {
+			return err
+		}
+	}
+	for _, a := range attachments {
+		job.Attachments = append(job.Attachments, helpers.EmailAttachment{
+			FileName:    a.FileName,
+			ContentType: a.ContentType,
+			Content:     a.Content,
+		})
+	}
+	u.trackingUC.Decorate(email, &job)
+	msg, err := models.NewOutboxMessage("", queue.EmailQueue, queue.EmailJobType, job)
+	if err != nil {
+		return err
+	}
+	return u.outbox.Add(tx, msg)
+}
+
+// checkAttachments fills in missing content types and enforces the size limit
+func checkAttachments(attachments []models.EmailAttachment) error {
+	total := 0
+	for i := range attachments {
+		a := &attachments[i]
+		if a.FileName == "" || len(a.Content) == 0 {
+			return fmt.Errorf("attachment %d has no file name or content", i+1)
+		}
+		if a.ContentType == "" {
+			a.ContentType = mime.TypeByExtension(filepath.Ext(a.FileName))
+		}
+		if a.ContentType == "" {
+			a.ContentType = http.DetectContentType(a.Content)
+		}
+		total += len(a.Content)
+	}
+	if total > maxAttachmentBytes {
+		return fmt.Errorf("attachments total %d bytes, over the %d byte limit", total, maxAttachmentBytes)
+	}
+	return nil
+}
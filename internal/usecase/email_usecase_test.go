@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeEmailRepo keeps emails in memory and lists failed ones as Postgres would
+type fakeEmailRepo struct {
+	repository.EmailRepository
+	emails map[uuid.UUID]*models.Email
+	order  []uuid.UUID
+}
+
+func (r *fakeEmailRepo) add(email *models.Email) {
+	email.ID = uuid.New()
+	email.Status = models.EmailStatusFailed
+	r.emails[email.ID] = email
+	r.order = append(r.order, email.ID)
+}
+
+func (r *fakeEmailRepo) FindByID(id uuid.UUID) (*models.Email, error) {
+	email, ok := r.emails[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *email
+	return &cp, nil
+}
+
+func (r *fakeEmailRepo) ListIDsByStatus(status models.EmailStatus, limit int, skip ...models.EmailType) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+next:
+	for _, id := range r.order {
+		email := r.emails[id]
+		if email.Status != status {
+			continue
+		}
+		for _, typ := range skip {
+			if email.Type == typ {
+				continue next
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *fakeEmailRepo) ResetFailed(tx *gorm.DB, id uuid.UUID) (bool, error) {
+	email := r.emails[id]
+	if email.Status != models.EmailStatusFailed {
+		return false, nil
+	}
+	email.Status = models.EmailStatusPending
+	return true, nil
+}
+
+func (r *fakeEmailRepo) ListAttachments(id uuid.UUID) ([]models.EmailAttachment, error) {
+	return nil, nil
+}
+
+// fakeOutbox counts the jobs added
+type fakeOutbox struct {
+	repository.OutboxRepository
+	added int
+}
+
+func (o *fakeOutbox) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (o *fakeOutbox) Add(tx *gorm.DB, msgs ...*models.OutboxMessage) error {
+	o.added += len(msgs)
+	return nil
+}
+
+// fakeTracking suppresses the addresses listed
+type fakeTracking struct {
+	EmailTrackingUsecase
+	suppressed map[string]bool
+}
+
+func (t *fakeTracking) Suppressed(address string, typ models.EmailType) (*models.EmailSuppression, error) {
+	if !t.suppressed[address] {
+		return nil, nil
+	}
+	return &models.EmailSuppression{Email: address, Reason: models.SuppressionHardBounce}, nil
+}
+
+func (t *fakeTracking) Decorate(email *models.Email, job *helpers.EmailJob) {}
+
+func newTestEmails() (*emailUsecase, *fakeEmailRepo, *fakeOutbox) {
+	repo := &fakeEmailRepo{emails: map[uuid.UUID]*models.Email{}}
+	outbox := &fakeOutbox{}
+	tracking := &fakeTracking{suppressed: map[string]bool{"bounced@example.com": true}}
+	uc := EmailNewUsecase(repo, outbox, nil, tracking, &fakeAudit{}).(*emailUsecase)
+	return uc, repo, outbox
+}
+
+func TestEmailRequeue(t *testing.T) {
+	tests := []struct {
+		name  string
+		email models.Email
+		want  int
+	}{
+		{"failed receipt", models.Email{Email: "patient@example.com", Type: models.EmailTypePaymentReceipt}, http.StatusOK},
+		{"one-time code", models.Email{Email: "patient@example.com", Type: models.EmailTypeOTP}, http.StatusConflict},
+		{"password reset", models.Email{Email: "patient@example.com", Type: models.EmailTypePasswordReset}, http.StatusConflict},
+		{"suppressed recipient", models.Email{Email: "bounced@example.com", Type: models.EmailTypePaymentReceipt}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, repo, outbox := newTestEmails()
+			email := tt.email
+			repo.add(&email)
+
+			_, err := uc.Requeue(context.Background(), email.ID)
+			if got := statusOf(err); got != tt.want {
+				t.Fatalf("Requeue = %d, want %d", got, tt.want)
+			}
+			if queued := outbox.added == 1; queued != (tt.want == http.StatusOK) {
+				t.Errorf("queued = %v, want %v", queued, tt.want == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestEmailRequeueFailed(t *testing.T) {
+	uc, repo, outbox := newTestEmails()
+	receipt := &models.Email{Email: "patient@example.com", Type: models.EmailTypePaymentReceipt}
+	otp := &models.Email{Email: "patient@example.com", Type: models.EmailTypeOTP}
+	reset := &models.Email{Email: "patient@example.com", Type: models.EmailTypePasswordReset}
+	bounced := &models.Email{Email: "bounced@example.com", Type: models.EmailTypeBookingReminder}
+	for _, email := range []*models.Email{otp, receipt, reset, bounced} {
+		repo.add(email)
+	}
+
+	resp, err := uc.RequeueFailed(context.Background())
+	if err != nil {
+		t.Fatalf("RequeueFailed: %v", err)
+	}
+	if resp.Requeued != 1 || outbox.added != 1 {
+		t.Errorf("requeued %d, queued %d jobs; want only the receipt", resp.Requeued, outbox.added)
+	}
+	if len(resp.Failed) != 1 || resp.Failed[0] != bounced.ID {
+		t.Errorf("failed = %v, want only the suppressed email %s", resp.Failed, bounced.ID)
+	}
+	for _, email := range []*models.Email{otp, reset} {
+		if email.Status != models.EmailStatusFailed {
+			t.Errorf("%s email was reset to %s", email.Type, email.Status)
+		}
+	}
+}
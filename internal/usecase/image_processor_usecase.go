@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/storage"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/utils"
+	"hospital_management_system/internal/pkg/validators"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Longest side of the generated variants, in pixels
+const (
+	thumbnailSize = 200
+	mediumSize    = 800
+)
+
+// ImageProcessorUsecase is run by the image worker for every uploaded image
+type ImageProcessorUsecase interface {
+	// Process strips metadata from the stored original, generates the
+	// thumbnail and medium variants and marks the image ready
+	Process(ctx context.Context, imageID uuid.UUID) error
+}
+
+type imageProcessorUsecase struct {
+	repo    repository.ImageRepository
+	storage storage.Storage
+}
+
+func ImageProcessorNewUsecase(repo repository.ImageRepository, store storage.Storage) ImageProcessorUsecase {
+	return &imageProcessorUsecase{repo: repo, storage: store}
+}
+
+func (u *imageProcessorUsecase) Process(ctx context.Context, imageID uuid.UUID) error {
+	image, err := u.repo.FindByID(imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // deleted before we got to it
+		}
+		return err
+	}
+	if image.Status != models.ImageStatusPending {
+		return nil // already handled; jobs may be delivered twice
+	}
+
+	if err := u.process(ctx, image); err != nil {
+		image.Status = models.ImageStatusFailed
+		image.ProcessingError = err.Error()
+		if saveErr := u.repo.Update(image); saveErr != nil {
+			log.Println("Failed to record image processing failure:", saveErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *imageProcessorUsecase) process(ctx context.Context, image *models.Image) error {
+	data, err := u.read(ctx, image.PublicID)
+	if err != nil {
+		return err
+	}
+
+	// Never trust the stored type; the worker may be fed anything
+	contentType := http.DetectContentType(data)
+	img, err := utils.DecodeImage(data, contentType)
+	if errors.Is(err, utils.ErrImageTooLarge) {
+		return queue.Permanent(fmt.Errorf("failed to decode image: %w", err)) // retrying cannot help
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	original, fileType, ext, err := utils.EncodeImage(img, contentType)
+	if err != nil {
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	folder := "uploads/" + image.ImageType
+	baseName := strings.TrimSuffix(image.FileName, path.Ext(image.FileName))
+	var stored []string
+
+	put := func(body []byte, subFolder, suffix string) (*storage.Object, error) {
+		obj, err := u.storage.Put(ctx, bytes.NewReader(body), storage.PutOptions{
+			Folder:      path.Join(folder, subFolder),
+			FileName:    baseName + suffix + ext,
+			ContentType: fileType,
+			Size:        int64(len(body)),
+			Private:     true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, obj.Key)
+		return obj, nil
+	}
+	cleanup := func() {
+		for _, key := range stored {
+			if err := u.storage.Delete(ctx, key); err != nil {
+				log.Println("Failed to remove image variant:", err)
+			}
+		}
+	}
+
+	cleaned, err := put(original, "", "")
+	if err != nil {
+		cleanup()
+		return fmt.Errorf("failed to store cleaned image: %w", err)
+	}
+
+	variants := map[string]int{
+		models.ImageVariantThumbnail: thumbnailSize,
+		models.ImageVariantMedium:    mediumSize,
+	}
+	keys := make(map[string]string, len(variants))
+	for name, size := range variants {
+		body, _, _, err := utils.EncodeImage(utils.ResizeToFit(img, size), contentType)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		obj, err := put(body, "variants", "_"+name)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to store %s: %w", name, err)
+		}
+		keys[name] = obj.Key
+	}
+
+	bounds := img.Bounds()
+	updated := *image
+	updated.PublicID = cleaned.Key
+	updated.FileName = baseName + ext
+	updated.FileSize = int64(len(original))
+	updated.FileType = fileType
+	updated.Width = bounds.Dx()
+	updated.Height = bounds.Dy()
+	updated.ThumbnailPublicID = keys[models.ImageVariantThumbnail]
+	updated.MediumPublicID = keys[models.ImageVariantMedium]
+	updated.Status = models.ImageStatusReady
+	updated.ProcessingError = ""
+
+	if err := u.repo.Update(&updated); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to save processed image: %w", err)
+	}
+
+	// The upload as received may still carry GPS data
+	if err := u.storage.Delete(ctx, image.PublicID); err != nil {
+		log.Println("Failed to remove unprocessed image:", err)
+	}
+	return nil
+}
+
+// read loads the stored original, refusing anything over the upload limit
+func (u *imageProcessorUsecase) read(ctx context.Context, key string) ([]byte, error) {
+	body, err := u.storage.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, validators.MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > validators.MaxImageSize {
+		return nil, errors.New("image exceeds the upload limit")
+	}
+	return data, nil
+}
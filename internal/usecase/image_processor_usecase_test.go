@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/storage"
+	"hospital_management_system/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeImageRepo is an in-memory ImageRepository
+type fakeImageRepo struct {
+	images map[uuid.UUID]models.Image
+}
+
+func newFakeImageRepo(images ...models.Image) *fakeImageRepo {
+	r := &fakeImageRepo{images: map[uuid.UUID]models.Image{}}
+	for _, img := range images {
+		r.images[img.ID] = img
+	}
+	return r
+}
+
+func (r *fakeImageRepo) Create(image *models.Image) error {
+	if image.ID == uuid.Nil {
+		image.ID = uuid.New()
+	}
+	r.images[image.ID] = *image
+	return nil
+}
+
+func (r *fakeImageRepo) CreateTx(tx *gorm.DB, image *models.Image) error {
+	return r.Create(image)
+}
+
+func (r *fakeImageRepo) FindByID(id uuid.UUID) (*models.Image, error) {
+	img, ok := r.images[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &img, nil
+}
+
+func (r *fakeImageRepo) FindByUserID(userID uuid.UUID, page, pageSize int) ([]models.Image, int64, error) {
+	var list []models.Image
+	for _, img := range r.images {
+		if img.UserID == userID {
+			list = append(list, img)
+		}
+	}
+	return list, int64(len(list)), nil
+}
+
+func (r *fakeImageRepo) FindByPublicID(publicID string) (*models.Image, error) {
+	for _, img := range r.images {
+		if img.PublicID == publicID {
+			return &img, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeImageRepo) Update(image *models.Image) error {
+	r.images[image.ID] = *image
+	return nil
+}
+
+func (r *fakeImageRepo) Delete(id uuid.UUID) error {
+	delete(r.images, id)
+	return nil
+}
+
+func (r *fakeImageRepo) SoftDelete(id uuid.UUID) error {
+	img := r.images[id]
+	img.IsDeleted = true
+	r.images[id] = img
+	return nil
+}
+
+func TestImageProcessorProcess(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       []byte
+		status     string
+		wantStatus string
+		wantErr    bool
+		permanent  bool // the job is dead-lettered rather than retried
+		wantKept   bool // original still stored afterwards
+	}{
+		{name: "png", body: testPNG(t, 1200, 600), status: models.ImageStatusPending, wantStatus: models.ImageStatusReady},
+		{name: "small png", body: testPNG(t, 50, 40), status: models.ImageStatusPending, wantStatus: models.ImageStatusReady},
+		{name: "not an image", body: []byte("definitely not a picture"), status: models.ImageStatusPending, wantStatus: models.ImageStatusFailed, wantErr: true, wantKept: true},
+		{name: "too many pixels", body: pngClaiming(t, 20000, 20000), status: models.ImageStatusPending, wantStatus: models.ImageStatusFailed, wantErr: true, permanent: true, wantKept: true},
+		{name: "already processed", body: testPNG(t, 10, 10), status: models.ImageStatusReady, wantStatus: models.ImageStatusReady, wantKept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestStorage(t)
+			original := putTestObject(t, store, tt.body, storage.PutOptions{Folder: "uploads/general", FileName: "scan.png", Private: true})
+			img := models.Image{
+				ID:        uuid.New(),
+				UserID:    uuid.New(),
+				PublicID:  original.Key,
+				FileName:  "scan.png",
+				ImageType: "general",
+				Status:    tt.status,
+			}
+			repo := newFakeImageRepo(img)
+
+			err := ImageProcessorNewUsecase(repo, store).Process(context.Background(), img.ID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process error %v, want error: %v", err, tt.wantErr)
+			}
+			if queue.IsPermanent(err) != tt.permanent {
+				t.Errorf("Process error %v, want permanent: %v", err, tt.permanent)
+			}
+
+			got, _ := repo.FindByID(img.ID)
+			if got.Status != tt.wantStatus {
+				t.Errorf("status %q, want %q", got.Status, tt.wantStatus)
+			}
+			if kept := exists(t, store, original.Key); kept != tt.wantKept {
+				t.Errorf("original kept: %v, want %v", kept, tt.wantKept)
+			}
+			if tt.wantStatus != models.ImageStatusReady || tt.status == models.ImageStatusReady {
+				return
+			}
+
+			for _, key := range []string{got.PublicID, got.ThumbnailPublicID, got.MediumPublicID} {
+				if key == "" || !exists(t, store, key) {
+					t.Errorf("processed object %q is missing", key)
+				}
+			}
+			if got.PublicID == original.Key {
+				t.Error("processed image still points at the unprocessed original")
+			}
+			if got.Width == 0 || got.Height == 0 || got.FileSize == 0 {
+				t.Errorf("dimensions and size not recorded: %dx%d, %d bytes", got.Width, got.Height, got.FileSize)
+			}
+		})
+	}
+}
+
+// pngClaiming returns a tiny PNG whose header claims the given dimensions,
+// the shape of a decompression bomb
+func pngClaiming(t *testing.T, w, h int) []byte {
+	t.Helper()
+	data := testPNG(t, 1, 1)
+	// The IHDR chunk follows the 8 byte signature: length, type, data, CRC
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], uint32(w))
+	binary.BigEndian.PutUint32(ihdr[4:8], uint32(h))
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	return data
+}
+
+func TestImageProcessorMissingImage(t *testing.T) {
+	store, _ := newTestStorage(t)
+
+	// Deleted before the worker got to it: nothing to do, nothing to retry
+	if err := ImageProcessorNewUsecase(newFakeImageRepo(), store).Process(context.Background(), uuid.New()); err != nil {
+		t.Errorf("Process: %v, want nil", err)
+	}
+}
+
+func TestImageProcessorMissingObject(t *testing.T) {
+	store, _ := newTestStorage(t)
+	img := models.Image{ID: uuid.New(), PublicID: "uploads/general/gone.png", FileName: "gone.png", ImageType: "general", Status: models.ImageStatusPending}
+	repo := newFakeImageRepo(img)
+
+	if err := ImageProcessorNewUsecase(repo, store).Process(context.Background(), img.ID); err == nil {
+		t.Fatal("Process succeeded without a stored original")
+	}
+	if got, _ := repo.FindByID(img.ID); got.Status != models.ImageStatusFailed || got.ProcessingError == "" {
+		t.Errorf("status %q (%q), want failed with a reason", got.Status, got.ProcessingError)
+	}
+}
@@ -4,43 +4,66 @@ import (
 	"context"
 	"fmt"
 	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/queue"
 	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/storage"
 	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
 	"hospital_management_system/internal/pkg/validators"
+	"log"
 	"mime/multipart"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// imageURLTTL is how long links handed out by GetImageURL stay valid
+const imageURLTTL = 5 * time.Minute
+
 type ImageUsecase interface {
 	UploadImage(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, req *dto.ImageUploadRequest) (*models.Image, error)
 	UploadMultipleImages(ctx context.Context, files []multipart.File, fileHeaders []*multipart.FileHeader, req *dto.ImageUploadRequest) ([]*models.Image, []error)
-	GetImageByID(id uuid.UUID) (*models.Image, error)
-	GetUserImages(userID uuid.UUID, page, pageSize int) (*dto.ListResponse, error)
+	GetImageByID(ctx context.Context, id uuid.UUID) (*models.Image, error)
+	GetUserImages(ctx context.Context, userID uuid.UUID, page, pageSize int) (*dto.ListResponse, error)
+	GetImageURL(ctx context.Context, id uuid.UUID, variant string) (*dto.ImageURLResponse, error)
 	DeleteImage(ctx context.Context, id uuid.UUID) error
 }
 
 type imageUsecase struct {
-	repo               repository.ImageRepository
-	cloudinaryUploader *helpers.CloudinaryUploader
+	repo      repository.ImageRepository
+	storage   storage.Storage
+	outbox    repository.OutboxRepository
+	auditUC   AuditUsecase
 }
 
-func ImageNewUsecase(repo repository.ImageRepository, cloudinaryUploader *helpers.CloudinaryUploader) ImageUsecase {
+func ImageNewUsecase(repo repository.ImageRepository, store storage.Storage, outbox repository.OutboxRepository, auditUC AuditUsecase) ImageUsecase {
 	return &imageUsecase{
-		repo:               repo,
-		cloudinaryUploader: cloudinaryUploader,
+		repo:    repo,
+		storage: store,
+		outbox:  outbox,
+		auditUC: auditUC,
 	}
 }
 
 func (u *imageUsecase) UploadImage(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, req *dto.ImageUploadRequest) (*models.Image, error) {
-	if err := validators.ValidateImage(fileHeader); err != nil {
+	if req.ImageType == models.ImageTypeDocument {
+		return nil, helpers.NewAppError(400, "Use the documents API to upload documents")
+	}
+	contentType, err := validators.ValidateImage(file, fileHeader)
+	if err != nil {
 		return nil, err
 	}
 
 	folder := "uploads/" + req.ImageType
-	uploaded, err := u.cloudinaryUploader.UploadImage(file, fileHeader, &helpers.UploadOptions{Folder: folder})
+	uploaded, err := u.storage.Put(ctx, file, storage.PutOptions{
+		Folder:      folder,
+		FileName:    fileHeader.Filename,
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		Private:     true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload image: %w", err)
 	}
@@ -48,17 +71,30 @@ func (u *imageUsecase) UploadImage(ctx context.Context, file multipart.File, fil
 	image := &models.Image{
 		UserID:    req.UserID,
 		URL:       uploaded.URL,
-		PublicID:  uploaded.PublicID,
+		PublicID:  uploaded.Key,
 		FileName:  uploaded.FileName,
-		FileSize:  uploaded.FileSize,
-		FileType:  uploaded.FileType,
+		FileSize:  uploaded.Size,
+		FileType:  uploaded.ContentType,
 		Width:     uploaded.Width,
 		Height:    uploaded.Height,
 		ImageType: req.ImageType,
+		Status:    models.ImageStatusPending,
 	}
 
-	if err := u.repo.Create(image); err != nil {
-		_ = u.cloudinaryUploader.Delete(uploaded.PublicID)
+	// EXIF stripping and variants happen in the image worker, which is
+	// queued in the same transaction so no image is left unprocessed
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.repo.CreateTx(tx, image); err != nil {
+			return err
+		}
+		msg, err := models.NewOutboxMessage("", queue.ImageQueue, queue.ImageJobType, queue.ImageJob{ImageID: image.ID})
+		if err != nil {
+			return err
+		}
+		return u.outbox.Add(tx, msg)
+	})
+	if err != nil {
+		_ = u.storage.Delete(ctx, uploaded.Key)
 		return nil, fmt.Errorf("failed to save image record: %w", err)
 	}
 
@@ -96,31 +132,48 @@ func (u *imageUsecase) UploadMultipleImages(ctx context.Context, files []multipa
 	return images, errors
 }
 
-
 // GetImageByID retrieves image by ID
-func (u *imageUsecase) GetImageByID(id uuid.UUID) (*models.Image, error) {
+func (u *imageUsecase) GetImageByID(ctx context.Context, id uuid.UUID) (*models.Image, error) {
 	image, err := u.repo.FindByID(id)
 	if err != nil {
 		return nil, helpers.NewAppError(404, "Image not found")
 	}
+	if err := canViewImages(ctx, image.UserID); err != nil {
+		return nil, err
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceImage,
+		ResourceID:   image.ID.String(),
+	})
 
 	return image, nil
 }
 
 // GetUserImages retrieves all images for a user with pagination
-func (u *imageUsecase) GetUserImages(userID uuid.UUID, page, pageSize int) (*dto.ListResponse, error) {
+func (u *imageUsecase) GetUserImages(ctx context.Context, userID uuid.UUID, page, pageSize int) (*dto.ListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 10
 	}
+	if err := canViewImages(ctx, userID); err != nil {
+		return nil, err
+	}
 
 	images, total, err := u.repo.FindByUserID(userID, page, pageSize)
 	if err != nil {
 		return nil, helpers.NewAppError(500, "Failed to retrieve images")
 	}
 
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceUserImages,
+		ResourceID:   userID.String(),
+	})
+
 	// Convert []models.Image to []interface{}
 	data := make([]interface{}, len(images))
 	for i, img := range images {
@@ -143,17 +196,93 @@ func (u *imageUsecase) GetUserImages(userID uuid.UUID, page, pageSize int) (*dto
 	return response, nil
 }
 
-// DeleteImage deletes image from both Cloudinary and database
+// GetImageURL returns a short-lived link to the image file, or to one of
+// its variants once the image worker has produced them
+func (u *imageUsecase) GetImageURL(ctx context.Context, id uuid.UUID, variant string) (*dto.ImageURLResponse, error) {
+	image, err := u.repo.FindByID(id)
+	if err != nil {
+		return nil, helpers.NewAppError(404, "Image not found")
+	}
+	if err := canViewImages(ctx, image.UserID); err != nil {
+		return nil, err
+	}
+
+	// Until processing finishes the stored file may still carry EXIF/GPS data
+	switch image.Status {
+	case models.ImageStatusPending:
+		return nil, helpers.NewAppError(409, "Image is still being processed")
+	case models.ImageStatusFailed:
+		return nil, helpers.NewAppError(422, "Image could not be processed")
+	}
+
+	key := image.PublicID
+	switch variant {
+	case "":
+	case models.ImageVariantThumbnail:
+		key = image.ThumbnailPublicID
+	case models.ImageVariantMedium:
+		key = image.MediumPublicID
+	default:
+		return nil, helpers.NewAppError(400, "Invalid variant. Use thumbnail or medium")
+	}
+	if key == "" {
+		return nil, helpers.NewAppError(404, "Variant not available for this image")
+	}
+
+	link, err := u.storage.URL(ctx, key, imageURLTTL)
+	if err != nil {
+		return nil, helpers.NewAppError(500, "Failed to create image link")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDownload,
+		ResourceType: models.AuditResourceImage,
+		ResourceID:   image.ID.String(),
+	})
+
+	return &dto.ImageURLResponse{
+		URL:       link,
+		Variant:   variant,
+		ExpiresAt: time.Now().Add(imageURLTTL),
+	}, nil
+}
+
+// canViewImages lets patients see, and delete, only their own images; staff
+// roles that passed the images permission check may act on anyone's
+func canViewImages(ctx context.Context, ownerID uuid.UUID) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+	if actor.Role == models.RolePatient && actor.ID != ownerID {
+		return helpers.NewAppError(403, "You do not have access to these images")
+	}
+	return nil
+}
+
+// DeleteImage deletes image from both storage and database
 func (u *imageUsecase) DeleteImage(ctx context.Context, id uuid.UUID) error {
 	// Get image record
 	image, err := u.repo.FindByID(id)
 	if err != nil {
 		return helpers.NewAppError(404, "Image not found")
 	}
+	// Patients hold images:delete for their own uploads only
+	if err := canViewImages(ctx, image.UserID); err != nil {
+		return err
+	}
 
-	// Delete from Cloudinary
-	if err := u.cloudinaryUploader.Delete(image.PublicID); err != nil {
-		return helpers.NewAppError(500, "Failed to delete image from Cloudinary")
+	// Delete from storage
+	if err := u.storage.Delete(ctx, image.PublicID); err != nil {
+		return helpers.NewAppError(500, "Failed to delete image from storage")
+	}
+	for _, key := range []string{image.ThumbnailPublicID, image.MediumPublicID} {
+		if key == "" {
+			continue
+		}
+		if err := u.storage.Delete(ctx, key); err != nil {
+			log.Println("Failed to delete image variant:", err)
+		}
 	}
 
 	// Delete from database
@@ -161,5 +290,11 @@ func (u *imageUsecase) DeleteImage(ctx context.Context, id uuid.UUID) error {
 		return helpers.NewAppError(500, "Failed to delete image record")
 	}
 
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceImage,
+		ResourceID:   image.ID.String(),
+		Before:       image,
+	})
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,879 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxLabOrderTests     = 20
+	maxLabTestParameters = 50
+)
+
+// LabUsecase runs the laboratory: the test catalogue, and orders from the
+// doctor's request through sample collection and result entry to the
+// release of the results to the patient's record
+type LabUsecase interface {
+	CreateTest(ctx context.Context, req *dto.CreateLabTestRequest) (*models.LabTest, error)
+	UpdateTest(ctx context.Context, id uuid.UUID, req *dto.UpdateLabTestRequest) (*models.LabTest, error)
+	GetTest(id uuid.UUID) (*models.LabTest, error)
+	ListTests(filter *dto.LabTestFilter) (*dto.ListResponse, error)
+
+	CreateOrder(ctx context.Context, req *dto.CreateLabOrderRequest) (*models.LabOrder, error)
+	// GetOrder returns an order; patients only see their own, and only see
+	// results once released. Doctors and other staff outside the lab see
+	// the orders of patients they order tests for and orders shared with them.
+	GetOrder(ctx context.Context, id uuid.UUID) (*models.LabOrder, error)
+	ListOrders(ctx context.Context, filter *dto.LabOrderFilter) (*dto.ListResponse, error)
+	// GrantAccess shares an order with a staff member; only the patient,
+	// the ordering doctor and admins can share
+	GrantAccess(ctx context.Context, id uuid.UUID, req *dto.LabOrderAccessRequest) error
+	RevokeAccess(ctx context.Context, id, userID uuid.UUID) error
+	CancelOrder(ctx context.Context, id uuid.UUID, req *dto.CancelLabOrderRequest) (*models.LabOrder, error)
+	// CollectSample records a specimen; the order is collected once every
+	// sample type its tests need has one
+	CollectSample(ctx context.Context, id uuid.UUID, req *dto.CollectLabSampleRequest) (*models.LabOrder, error)
+	EnterResults(ctx context.Context, id, orderTestID uuid.UUID, req *dto.EnterLabResultsRequest) (*models.LabOrder, error)
+	// Release completes a fully resulted order and notifies the patient
+	Release(ctx context.Context, id uuid.UUID) (*models.LabOrder, error)
+}
+
+type labUsecase struct {
+	repo     repository.LabRepository
+	userRepo repository.UserRepository
+	notifyUC NotificationUsecase
+	auditUC  AuditUsecase
+}
+
+func LabNewUsecase(repo repository.LabRepository, userRepo repository.UserRepository, notifyUC NotificationUsecase, auditUC AuditUsecase) LabUsecase {
+	return &labUsecase{repo: repo, userRepo: userRepo, notifyUC: notifyUC, auditUC: auditUC}
+}
+
+func (u *labUsecase) CreateTest(ctx context.Context, req *dto.CreateLabTestRequest) (*models.LabTest, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	name := strings.TrimSpace(req.Name)
+	sampleType := strings.ToLower(strings.TrimSpace(req.SampleType))
+	if code == "" || name == "" || sampleType == "" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Code, name and sample_type are required")
+	}
+	if req.Price < 0 {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Price cannot be negative")
+	}
+	params, err := labParameters(req.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	test := &models.LabTest{
+		Code:        code,
+		Name:        name,
+		SampleType:  sampleType,
+		Price:       req.Price,
+		Description: req.Description,
+		IsActive:    true,
+		Parameters:  params,
+	}
+	if err := u.repo.CreateTest(test); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, helpers.NewAppError(http.StatusConflict, "A lab test with this code already exists")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to create lab test")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceLabTest,
+		ResourceID:   test.ID.String(),
+		After:        test,
+	})
+	return test, nil
+}
+
+func (u *labUsecase) UpdateTest(ctx context.Context, id uuid.UUID, req *dto.UpdateLabTestRequest) (*models.LabTest, error) {
+	test, err := u.findTest(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *test
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Name cannot be empty")
+		}
+		test.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.SampleType != nil {
+		if strings.TrimSpace(*req.SampleType) == "" {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Sample type cannot be empty")
+		}
+		test.SampleType = strings.ToLower(strings.TrimSpace(*req.SampleType))
+	}
+	if req.Price != nil {
+		if *req.Price < 0 {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Price cannot be negative")
+		}
+		test.Price = *req.Price
+	}
+	if req.Description != nil {
+		test.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		test.IsActive = *req.IsActive
+	}
+	var params []models.LabTestParameter
+	if req.Parameters != nil {
+		if params, err = labParameters(req.Parameters); err != nil {
+			return nil, err
+		}
+		existing := make(map[uuid.UUID]bool, len(test.Parameters))
+		for _, p := range test.Parameters {
+			existing[p.ID] = true
+		}
+		edited := make(map[uuid.UUID]bool, len(req.Parameters))
+		for i, r := range req.Parameters {
+			if r.ID == nil {
+				continue
+			}
+			if !existing[*r.ID] {
+				return nil, helpers.NewAppError(http.StatusBadRequest, "Parameter "+r.ID.String()+" is not part of "+test.Name)
+			}
+			if edited[*r.ID] {
+				return nil, helpers.NewAppError(http.StatusBadRequest, "Parameter "+r.ID.String()+" is listed twice")
+			}
+			edited[*r.ID] = true
+			params[i].ID = *r.ID
+		}
+		if sameLabParameters(test.Parameters, params) {
+			params = nil
+		} else {
+			// Orders in progress are resulted against the parameters they
+			// were placed with
+			open, err := u.repo.CountOpenOrders(test.ID)
+			if err != nil {
+				return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+			}
+			if open > 0 {
+				return nil, helpers.NewAppError(http.StatusConflict, fmt.Sprintf("Parameters cannot change while %d open orders include this test; release or cancel them first", open))
+			}
+		}
+	}
+
+	if err := u.repo.UpdateTest(test, params); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update lab test")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceLabTest,
+		ResourceID:   test.ID.String(),
+		Before:       before,
+		After:        test,
+	})
+	return test, nil
+}
+
+func (u *labUsecase) GetTest(id uuid.UUID) (*models.LabTest, error) {
+	return u.findTest(id)
+}
+
+func (u *labUsecase) ListTests(filter *dto.LabTestFilter) (*dto.ListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	tests, total, err := u.repo.ListTests(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve lab tests")
+	}
+
+	data := make([]interface{}, len(tests))
+	for i, t := range tests {
+		data[i] = t
+	}
+	return labListResponse(data, total, filter.Page, filter.PageSize), nil
+}
+
+func (u *labUsecase) CreateOrder(ctx context.Context, req *dto.CreateLabOrderRequest) (*models.LabOrder, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	patient, err := u.userRepo.FindByID(req.PatientID)
+	if err != nil || patient == nil || patient.IsDeleted || patient.Role != models.RolePatient {
+		return nil, helpers.NewAppError(http.StatusNotFound, "Patient not found")
+	}
+
+	priority := models.LabOrderPriority(req.Priority)
+	if priority == "" {
+		priority = models.LabPriorityRoutine
+	}
+	if !models.IsValidLabOrderPriority(priority) {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid priority. Use routine, urgent or stat")
+	}
+
+	if len(req.TestIDs) == 0 || len(req.TestIDs) > maxLabOrderTests {
+		return nil, helpers.NewAppError(http.StatusBadRequest, fmt.Sprintf("Order between 1 and %d tests", maxLabOrderTests))
+	}
+	seen := make(map[uuid.UUID]bool, len(req.TestIDs))
+	var ids []uuid.UUID
+	for _, raw := range req.TestIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid test ID "+raw)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	tests, err := u.repo.FindTestsByIDs(ids)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if len(tests) != len(ids) {
+		return nil, helpers.NewAppError(http.StatusNotFound, "Lab test not found")
+	}
+
+	order := &models.LabOrder{
+		PatientID:     patient.ID,
+		OrderedBy:     actor.ID,
+		Priority:      priority,
+		Status:        models.LabOrderOrdered,
+		ClinicalNotes: req.ClinicalNotes,
+	}
+	for _, t := range tests {
+		if !t.IsActive {
+			return nil, helpers.NewAppError(http.StatusBadRequest, t.Name+" can no longer be ordered")
+		}
+		order.Tests = append(order.Tests, models.LabOrderTest{LabTestID: t.ID})
+	}
+	if err := u.repo.CreateOrder(order); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to create lab order")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		After:        map[string]interface{}{"patient_id": order.PatientID, "priority": order.Priority, "test_ids": ids},
+	})
+	return u.findOrder(order.ID)
+}
+
+func (u *labUsecase) GetOrder(ctx context.Context, id uuid.UUID) (*models.LabOrder, error) {
+	actor, order, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hideUnreleased(actor, order)
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+	})
+	return order, nil
+}
+
+// ListOrders confines patients to their own orders and staff outside the
+// lab to the orders they may see
+func (u *labUsecase) ListOrders(ctx context.Context, filter *dto.LabOrderFilter) (*dto.ListResponse, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case actor.Role == models.RolePatient:
+		if filter.PatientID != "" && filter.PatientID != actor.ID.String() {
+			return nil, helpers.NewAppError(http.StatusForbidden, "Patients can only see their own lab orders")
+		}
+		filter.PatientID = actor.ID.String()
+	case !seesAllLabOrders(actor):
+		filter.VisibleTo = &actor.ID
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	orders, total, err := u.repo.ListOrders(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve lab orders")
+	}
+
+	resourceID := "all"
+	if filter.PatientID != "" {
+		resourceID = "patient:" + filter.PatientID
+	}
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionView,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   resourceID,
+		After:        map[string]interface{}{"count": len(orders), "scoped": filter.VisibleTo != nil},
+	})
+
+	data := make([]interface{}, len(orders))
+	for i := range orders {
+		hideUnreleased(actor, &orders[i])
+		data[i] = orders[i]
+	}
+	return labListResponse(data, total, filter.Page, filter.PageSize), nil
+}
+
+func (u *labUsecase) GrantAccess(ctx context.Context, id uuid.UUID, req *dto.LabOrderAccessRequest) error {
+	actor, order, err := u.manageable(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	grantee, err := u.userRepo.FindByID(req.UserID)
+	if err != nil || grantee == nil || grantee.IsDeleted {
+		return helpers.NewAppError(http.StatusNotFound, "User not found")
+	}
+	if grantee.Role == models.RolePatient {
+		return helpers.NewAppError(http.StatusBadRequest, "Lab orders can only be shared with staff")
+	}
+
+	if err := u.repo.Grant(&models.LabOrderAccess{OrderID: order.ID, UserID: grantee.ID, GrantedBy: actor.ID}); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to grant access")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionAccessGrant,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		After:        map[string]interface{}{"user_id": grantee.ID},
+	})
+	return nil
+}
+
+func (u *labUsecase) RevokeAccess(ctx context.Context, id, userID uuid.UUID) error {
+	_, order, err := u.manageable(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.Revoke(order.ID, userID); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to revoke access")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionAccessRevoke,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		Before:       map[string]interface{}{"user_id": userID},
+	})
+	return nil
+}
+
+func (u *labUsecase) CancelOrder(ctx context.Context, id uuid.UUID, req *dto.CancelLabOrderRequest) (*models.LabOrder, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "A reason is required")
+	}
+	actor, order, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !managesOrder(actor, order) {
+		return nil, helpers.NewAppError(http.StatusForbidden, "Only the patient, the ordering doctor or an admin can cancel a lab order")
+	}
+
+	ok, err := u.repo.SetStatus(nil, order.ID,
+		[]models.LabOrderStatus{models.LabOrderOrdered, models.LabOrderCollected},
+		models.LabOrderCancelled, map[string]interface{}{"cancel_reason": reason})
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to cancel lab order")
+	}
+	if !ok {
+		return nil, helpers.NewAppError(http.StatusConflict, "Only open lab orders can be cancelled")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionStatusChange,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		Before:       map[string]interface{}{"status": order.Status},
+		After:        map[string]interface{}{"status": models.LabOrderCancelled, "cancel_reason": reason},
+	})
+	return u.findOrder(order.ID)
+}
+
+func (u *labUsecase) CollectSample(ctx context.Context, id uuid.UUID, req *dto.CollectLabSampleRequest) (*models.LabOrder, error) {
+	actor, order, err := u.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.LabOrderOrdered && order.Status != models.LabOrderCollected {
+		return nil, helpers.NewAppError(http.StatusConflict, "Samples can only be collected for open lab orders")
+	}
+
+	sampleType := strings.ToLower(strings.TrimSpace(req.SampleType))
+	needed := order.SampleTypesNeeded()
+	if !needed[sampleType] {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "None of the ordered tests takes a "+req.SampleType+" sample")
+	}
+	barcode := strings.TrimSpace(req.Barcode)
+	if barcode == "" {
+		barcode = "LAB-" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:10])
+	}
+
+	sample := &models.LabSample{
+		OrderID:     order.ID,
+		SampleType:  sampleType,
+		Barcode:     barcode,
+		CollectedBy: actor.ID,
+		CollectedAt: time.Now(),
+		Notes:       req.Notes,
+	}
+
+	// The order is locked while the sample is added, so it cannot land on
+	// an order being cancelled, and completeness is judged on every sample
+	// committed by then
+	errNotOpen := errors.New("lab order not open")
+	err = u.repo.Transaction(func(tx *gorm.DB) error {
+		status, err := u.repo.LockOrder(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if status != models.LabOrderOrdered && status != models.LabOrderCollected {
+			return errNotOpen
+		}
+
+		if err := u.repo.AddSample(tx, sample); err != nil {
+			return err
+		}
+		if order.Samples, err = u.repo.ListSamples(tx, order.ID); err != nil {
+			return err
+		}
+		if status != models.LabOrderOrdered || !order.SamplesComplete() {
+			return nil
+		}
+		_, err = u.repo.SetStatus(tx, order.ID, []models.LabOrderStatus{models.LabOrderOrdered}, models.LabOrderCollected, nil)
+		return err
+	})
+	if errors.Is(err, errNotOpen) {
+		return nil, helpers.NewAppError(http.StatusConflict, "Samples can only be collected for open lab orders")
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, helpers.NewAppError(http.StatusConflict, "Barcode is already in use")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to record sample")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		After:        map[string]interface{}{"sample": sample.Barcode, "sample_type": sample.SampleType},
+	})
+	return u.findOrder(order.ID)
+}
+
+func (u *labUsecase) EnterResults(ctx context.Context, id, orderTestID uuid.UUID, req *dto.EnterLabResultsRequest) (*models.LabOrder, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := u.findOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.LabOrderCollected {
+		return nil, helpers.NewAppError(http.StatusConflict, "Results can only be entered once samples are collected and until they are released")
+	}
+
+	var orderTest *models.LabOrderTest
+	for i := range order.Tests {
+		if order.Tests[i].ID == orderTestID {
+			orderTest = &order.Tests[i]
+		}
+	}
+	if orderTest == nil {
+		return nil, helpers.NewAppError(http.StatusNotFound, "Test not found in this lab order")
+	}
+	if len(req.Results) == 0 {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "At least one result is required")
+	}
+
+	params := make(map[uuid.UUID]models.LabTestParameter, len(orderTest.LabTest.Parameters))
+	for _, p := range orderTest.LabTest.Parameters {
+		params[p.ID] = p
+	}
+
+	now := time.Now()
+	var results []models.LabResult
+	var critical []string
+	for _, entry := range req.Results {
+		p, ok := params[entry.ParameterID]
+		if !ok {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Parameter "+entry.ParameterID.String()+" is not part of "+orderTest.LabTest.Name)
+		}
+		value := strings.TrimSpace(entry.Value)
+		if value == "" {
+			return nil, helpers.NewAppError(http.StatusBadRequest, p.Name+" needs a value")
+		}
+		flag, err := p.Evaluate(value)
+		if err != nil {
+			return nil, helpers.NewAppError(http.StatusBadRequest, err.Error())
+		}
+		results = append(results, models.LabResult{
+			OrderTestID:   orderTest.ID,
+			ParameterID:   p.ID,
+			Name:          p.Name,
+			Value:         value,
+			Unit:          p.Unit,
+			RefLow:        p.RefLow,
+			RefHigh:       p.RefHigh,
+			ReferenceText: p.ReferenceText,
+			Flag:          flag,
+			Position:      p.Position,
+			EnteredBy:     actor.ID,
+			EnteredAt:     now,
+		})
+		if flag.Critical() {
+			critical = append(critical, strings.TrimSpace(p.Name+" "+value+" "+p.Unit)+" ("+strings.ReplaceAll(string(flag), "_", " ")+")")
+		}
+	}
+	if req.Notes != nil {
+		orderTest.Notes = *req.Notes
+	}
+
+	var alert map[string]string
+	if len(critical) > 0 {
+		patientName := order.PatientID.String()[:8]
+		if patient, err := u.userRepo.FindByID(order.PatientID.String()); err == nil && patient != nil {
+			patientName = patient.Name
+		}
+		alert = map[string]string{
+			"OrderID":   order.ID.String()[:8],
+			"Patient":   patientName,
+			"Test":      orderTest.LabTest.Name,
+			"Results":   strings.Join(critical, "; "),
+			"EnteredAt": now.Format("2006-01-02 15:04"),
+		}
+	}
+
+	// The order is locked while results are saved, so they cannot land on
+	// an order being released or cancelled, and completeness is judged on
+	// the results committed by then. A critical value reaches the ordering
+	// doctor in the same transaction.
+	errNotCollected := errors.New("lab order not collected")
+	err = u.repo.Transaction(func(tx *gorm.DB) error {
+		status, err := u.repo.LockOrder(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if status != models.LabOrderCollected {
+			return errNotCollected
+		}
+
+		done, err := u.repo.ResultedParameters(tx, orderTest.ID)
+		if err != nil {
+			return err
+		}
+		resulted := make(map[uuid.UUID]bool, len(params))
+		for _, id := range append(done, resultParameterIDs(results)...) {
+			if _, ok := params[id]; ok {
+				resulted[id] = true
+			}
+		}
+		if len(resulted) == len(params) {
+			orderTest.ResultedBy = &actor.ID
+			orderTest.ResultedAt = &now
+		}
+
+		if err := u.repo.SaveResults(tx, orderTest, results); err != nil {
+			return err
+		}
+		if alert == nil {
+			return nil
+		}
+		return u.notifyUC.Enqueue(tx, order.OrderedBy, models.NotificationLabCriticalResult, alert)
+	})
+	if errors.Is(err, errNotCollected) {
+		return nil, helpers.NewAppError(http.StatusConflict, "Results can only be entered once samples are collected and until they are released")
+	}
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save results")
+	}
+
+	flags := make(map[string]models.LabResultFlag, len(results))
+	for _, r := range results {
+		flags[r.Name] = r.Flag
+	}
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		After:        map[string]interface{}{"test": orderTest.LabTest.Code, "flags": flags, "complete": orderTest.ResultedAt != nil, "critical": len(critical) > 0},
+	})
+	return u.findOrder(order.ID)
+}
+
+func (u *labUsecase) Release(ctx context.Context, id uuid.UUID) (*models.LabOrder, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := u.findOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.LabOrderCollected {
+		return nil, helpers.NewAppError(http.StatusConflict, "Only collected lab orders can be released")
+	}
+	var names []string
+	for _, t := range order.Tests {
+		if t.ResultedAt == nil {
+			return nil, helpers.NewAppError(http.StatusConflict, t.LabTest.Name+" still has results missing")
+		}
+		names = append(names, t.LabTest.Name)
+	}
+
+	// The patient is told in the same transaction, so a release is never
+	// left unannounced
+	now := time.Now()
+	errNotCollected := errors.New("lab order not collected")
+	err = u.repo.Transaction(func(tx *gorm.DB) error {
+		ok, err := u.repo.SetStatus(tx, order.ID, []models.LabOrderStatus{models.LabOrderCollected}, models.LabOrderCompleted,
+			map[string]interface{}{"released_by": actor.ID, "released_at": now})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errNotCollected
+		}
+		return u.notifyUC.Enqueue(tx, order.PatientID, models.NotificationLabResultsReady, map[string]string{
+			"OrderID":    order.ID.String()[:8],
+			"Tests":      strings.Join(names, ", "),
+			"ReleasedAt": now.Format("2006-01-02 15:04"),
+		})
+	})
+	if errors.Is(err, errNotCollected) {
+		return nil, helpers.NewAppError(http.StatusConflict, "Only collected lab orders can be released")
+	}
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to release results")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionStatusChange,
+		ResourceType: models.AuditResourceLabOrder,
+		ResourceID:   order.ID.String(),
+		Before:       map[string]interface{}{"status": order.Status},
+		After:        map[string]interface{}{"status": models.LabOrderCompleted},
+	})
+	return u.findOrder(order.ID)
+}
+
+// viewable loads the order and keeps patients to their own and staff
+// outside the lab to the orders they may see
+func (u *labUsecase) viewable(ctx context.Context, id uuid.UUID) (*models.User, *models.LabOrder, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := u.findOrder(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if actor.ID == order.PatientID || seesAllLabOrders(actor) {
+		return actor, order, nil
+	}
+	visible := false
+	if actor.Role != models.RolePatient {
+		if visible, err = u.repo.VisibleTo(order, actor.ID); err != nil {
+			return nil, nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+		}
+	}
+	if !visible {
+		return nil, nil, helpers.NewAppError(http.StatusNotFound, "Lab order not found")
+	}
+	return actor, order, nil
+}
+
+// manageable checks the caller may change who has access
+func (u *labUsecase) manageable(ctx context.Context, id uuid.UUID) (*models.User, *models.LabOrder, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := u.findOrder(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !managesOrder(actor, order) {
+		return nil, nil, helpers.NewAppError(http.StatusForbidden, "Only the patient, the ordering doctor or an admin can manage access")
+	}
+	return actor, order, nil
+}
+
+// managesOrder is true for the patient, the ordering doctor and admins
+func managesOrder(actor *models.User, order *models.LabOrder) bool {
+	return actor.Role == models.RoleAdmin || actor.ID == order.PatientID || actor.ID == order.OrderedBy
+}
+
+// seesAllLabOrders is true for the roles that work the lab's order lists:
+// collecting samples and entering results needs every open order
+func seesAllLabOrders(actor *models.User) bool {
+	switch actor.Role {
+	case models.RoleAdmin, models.RoleNurse, models.RoleLabTechnician:
+		return true
+	}
+	return false
+}
+
+func (u *labUsecase) findOrder(id uuid.UUID) (*models.LabOrder, error) {
+	order, err := u.repo.FindOrderByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Lab order not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	return order, nil
+}
+
+func (u *labUsecase) findTest(id uuid.UUID) (*models.LabTest, error) {
+	test, err := u.repo.FindTestByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Lab test not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	return test, nil
+}
+
+// hideUnreleased strips results the lab has not released yet from what a
+// patient sees; they may still be corrected
+func hideUnreleased(actor *models.User, order *models.LabOrder) {
+	if actor.Role != models.RolePatient || order.Status == models.LabOrderCompleted {
+		return
+	}
+	for i := range order.Tests {
+		order.Tests[i].Results = nil
+		order.Tests[i].Notes = ""
+	}
+}
+
+// labParameters validates the parameters of a catalogue test
+func labParameters(reqs []dto.LabTestParameterRequest) ([]models.LabTestParameter, error) {
+	if len(reqs) == 0 || len(reqs) > maxLabTestParameters {
+		return nil, helpers.NewAppError(http.StatusBadRequest, fmt.Sprintf("A lab test needs between 1 and %d parameters", maxLabTestParameters))
+	}
+
+	params := make([]models.LabTestParameter, 0, len(reqs))
+	names := make(map[string]bool, len(reqs))
+	for i, r := range reqs {
+		name := strings.TrimSpace(r.Name)
+		if name == "" {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Every parameter needs a name")
+		}
+		if names[strings.ToLower(name)] {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Duplicate parameter "+name)
+		}
+		names[strings.ToLower(name)] = true
+
+		valueType := models.LabValueType(r.ValueType)
+		if valueType == "" {
+			valueType = models.LabValueNumeric
+		}
+		switch valueType {
+		case models.LabValueNumeric:
+			if r.RefLow != nil && r.RefHigh != nil && *r.RefLow > *r.RefHigh {
+				return nil, helpers.NewAppError(http.StatusBadRequest, name+": ref_low is above ref_high")
+			}
+			if (r.CriticalLow != nil && r.RefLow != nil && *r.CriticalLow > *r.RefLow) ||
+				(r.CriticalHigh != nil && r.RefHigh != nil && *r.CriticalHigh < *r.RefHigh) {
+				return nil, helpers.NewAppError(http.StatusBadRequest, name+": critical limits must lie outside the reference range")
+			}
+		case models.LabValueText:
+			if r.RefLow != nil || r.RefHigh != nil || r.CriticalLow != nil || r.CriticalHigh != nil {
+				return nil, helpers.NewAppError(http.StatusBadRequest, name+": text parameters take a reference_text, not numeric limits")
+			}
+		default:
+			return nil, helpers.NewAppError(http.StatusBadRequest, name+": value_type must be numeric or text")
+		}
+
+		params = append(params, models.LabTestParameter{
+			Name:          name,
+			Unit:          strings.TrimSpace(r.Unit),
+			ValueType:     valueType,
+			RefLow:        r.RefLow,
+			RefHigh:       r.RefHigh,
+			CriticalLow:   r.CriticalLow,
+			CriticalHigh:  r.CriticalHigh,
+			ReferenceText: strings.TrimSpace(r.ReferenceText),
+			Position:      i + 1,
+		})
+	}
+	return params, nil
+}
+
+func resultParameterIDs(results []models.LabResult) []uuid.UUID {
+	ids := make([]uuid.UUID, len(results))
+	for i, r := range results {
+		ids[i] = r.ParameterID
+	}
+	return ids
+}
+
+// sameLabParameters reports whether an update leaves the parameters as they are
+func sameLabParameters(old, updated []models.LabTestParameter) bool {
+	if len(old) != len(updated) {
+		return false
+	}
+	for i, p := range updated {
+		o := old[i]
+		if p.ID != o.ID || p.Name != o.Name || p.Unit != o.Unit || p.ValueType != o.ValueType ||
+			p.ReferenceText != o.ReferenceText || p.Position != o.Position ||
+			!sameLimit(p.RefLow, o.RefLow) || !sameLimit(p.RefHigh, o.RefHigh) ||
+			!sameLimit(p.CriticalLow, o.CriticalLow) || !sameLimit(p.CriticalHigh, o.CriticalHigh) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameLimit(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func labListResponse(data []interface{}, total int64, page, pageSize int) *dto.ListResponse {
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+	return &dto.ListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+}
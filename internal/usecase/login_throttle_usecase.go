@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// throttlePolicy describes when a subject starts being slowed down and when it gets locked
+type throttlePolicy struct {
+	freeAttempts int           // failures allowed before delays kick in
+	baseDelay    time.Duration // delay after the first throttled failure, doubled each time
+	maxDelay     time.Duration
+	lockAfter    int
+	lockFor      time.Duration
+	window       time.Duration // failures older than this are forgotten
+}
+
+var (
+	accountThrottle = throttlePolicy{
+		freeAttempts: 3,
+		baseDelay:    2 * time.Second,
+		maxDelay:     time.Minute,
+		lockAfter:    8,
+		lockFor:      15 * time.Minute,
+		window:       15 * time.Minute,
+	}
+	ipThrottle = throttlePolicy{
+		freeAttempts: 10,
+		baseDelay:    time.Second,
+		maxDelay:     time.Minute,
+		lockAfter:    50,
+		lockFor:      30 * time.Minute,
+		window:       30 * time.Minute,
+	}
+)
+
+type LoginThrottleUsecase interface {
+	Check(email, ip string) error
+	RecordFailure(email, ip string)
+	RecordSuccess(email string)
+	Unlock(ctx context.Context, userID string) error
+}
+
+type loginThrottleUsecase struct {
+	repo     repository.LoginThrottleRepository
+	userRepo repository.UserRepository
+	notifyUC NotificationUsecase
+	auditUC  AuditUsecase
+}
+
+func LoginThrottleNewUsecase(repo repository.LoginThrottleRepository, userRepo repository.UserRepository, notifyUC NotificationUsecase, auditUC AuditUsecase) LoginThrottleUsecase {
+	return &loginThrottleUsecase{repo: repo, userRepo: userRepo, notifyUC: notifyUC, auditUC: auditUC}
+}
+
+// Check rejects the attempt while the account or IP is locked or inside its back-off delay
+func (u *loginThrottleUsecase) Check(email, ip string) error {
+	if err := u.check(models.ThrottleKey(models.ThrottleScopeAccount, normalizeEmail(email)), accountThrottle); err != nil {
+		return err
+	}
+	if ip != "" {
+		return u.check(models.ThrottleKey(models.ThrottleScopeIP, ip), ipThrottle)
+	}
+	return nil
+}
+
+func (u *loginThrottleUsecase) check(key string, policy throttlePolicy) error {
+	t, err := u.repo.FindByKey(key)
+	if err != nil {
+		log.Println("Failed to read login throttle:", err)
+		return nil
+	}
+	if t == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return helpers.NewAppError(http.StatusTooManyRequests,
+			fmt.Sprintf("Too many failed attempts. Try again after %s", t.LockedUntil.Format(time.RFC3339)))
+	}
+
+	if t.LastFailedAt == nil || now.Sub(*t.LastFailedAt) > policy.window {
+		return nil
+	}
+
+	if delay := policy.delayFor(t.FailedCount); delay > 0 {
+		retryAt := t.LastFailedAt.Add(delay)
+		if now.Before(retryAt) {
+			wait := int(math.Ceil(retryAt.Sub(now).Seconds()))
+			return helpers.NewAppError(http.StatusTooManyRequests,
+				fmt.Sprintf("Too many failed attempts. Try again in %d seconds", wait))
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and notifies the owner when the account gets locked
+func (u *loginThrottleUsecase) RecordFailure(email, ip string) {
+	t, locked, err := u.repo.RecordFailure(
+		models.ThrottleKey(models.ThrottleScopeAccount, normalizeEmail(email)),
+		models.ThrottleScopeAccount,
+		accountThrottle.window, accountThrottle.lockAfter, accountThrottle.lockFor,
+	)
+	if err != nil {
+		log.Println("Failed to record login failure:", err)
+	} else if locked {
+		go u.notifyLocked(email, ip, *t.LockedUntil)
+	}
+
+	if ip == "" {
+		return
+	}
+	if _, _, err := u.repo.RecordFailure(
+		models.ThrottleKey(models.ThrottleScopeIP, ip),
+		models.ThrottleScopeIP,
+		ipThrottle.window, ipThrottle.lockAfter, ipThrottle.lockFor,
+	); err != nil {
+		log.Println("Failed to record login failure:", err)
+	}
+}
+
+// RecordSuccess clears the account counter. IP counters are left to expire so one
+// valid account cannot be used to reset an IP that is guessing other passwords.
+func (u *loginThrottleUsecase) RecordSuccess(email string) {
+	if err := u.repo.Reset(models.ThrottleKey(models.ThrottleScopeAccount, normalizeEmail(email))); err != nil {
+		log.Println("Failed to reset login throttle:", err)
+	}
+}
+
+// Unlock lets an admin lift an account lock before it expires
+func (u *loginThrottleUsecase) Unlock(ctx context.Context, userID string) error {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return helpers.NewAppError(http.StatusNotFound, "User not found")
+	}
+
+	if err := u.repo.Reset(models.ThrottleKey(models.ThrottleScopeAccount, normalizeEmail(user.Email))); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to unlock account")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionAccountUnlock,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   user.ID.String(),
+	})
+	return nil
+}
+
+func (u *loginThrottleUsecase) notifyLocked(email, ip string, until time.Time) {
+	user, err := u.userRepo.FindByEmail(email)
+	if err != nil || user == nil {
+		return
+	}
+
+	if err := u.notifyUC.Notify(user, models.NotificationAccountLocked, map[string]string{
+		"LockedUntil": until.Format("2006-01-02 15:04 MST"),
+		"IP":          ip,
+	}); err != nil {
+		log.Println("Failed to send account locked notification:", err)
+	}
+}
+
+// delayFor returns the back-off required after the given number of failures
+func (p throttlePolicy) delayFor(failures int) time.Duration {
+	over := failures - p.freeAttempts
+	if over <= 0 {
+		return 0
+	}
+	delay := p.baseDelay << uint(over-1)
+	if delay <= 0 || delay > p.maxDelay {
+		return p.maxDelay
+	}
+	return delay
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
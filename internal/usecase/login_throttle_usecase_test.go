@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeThrottleRepo keeps counters in memory with the same rules as the
+// Postgres upsert
+type fakeThrottleRepo struct {
+	mu   sync.Mutex
+	rows map[string]*models.LoginThrottle
+}
+
+func (r *fakeThrottleRepo) FindByKey(key string) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.rows[key]
+	if !ok {
+		return nil, nil
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (r *fakeThrottleRepo) RecordFailure(key, scope string, window time.Duration, lockAfter int, lockFor time.Duration) (*models.LoginThrottle, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	t, ok := r.rows[key]
+	if !ok {
+		t = &models.LoginThrottle{Key: key, Scope: scope}
+		r.rows[key] = t
+	}
+	if t.LastFailedAt == nil || now.Sub(*t.LastFailedAt) > window || (t.LockedUntil != nil && now.After(*t.LockedUntil)) {
+		t.FailedCount = 0
+		t.LockedUntil = nil
+	}
+	t.FailedCount++
+	t.LastFailedAt = &now
+
+	locked := false
+	if t.LockedUntil == nil && t.FailedCount >= lockAfter {
+		until := now.Add(lockFor)
+		t.LockedUntil = &until
+		locked = true
+	}
+	cp := *t
+	return &cp, locked, nil
+}
+
+func (r *fakeThrottleRepo) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rows, key)
+	return nil
+}
+
+// ageFailures moves every recorded failure back in time, as if the client had
+// waited out its back-off
+func (r *fakeThrottleRepo) ageFailures(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.rows {
+		at := t.LastFailedAt.Add(-d)
+		t.LastFailedAt = &at
+	}
+}
+
+type fakeThrottleUsers struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (r *fakeThrottleUsers) FindByID(id string) (*models.User, error) {
+	if r.user == nil || r.user.ID.String() != id {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+func (r *fakeThrottleUsers) FindByEmail(email string) (*models.User, error) {
+	return r.user, nil
+}
+
+// fakeNotifier records which events were sent
+type fakeNotifier struct {
+	NotificationUsecase
+	events chan models.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(user *models.User, event models.NotificationEvent, data map[string]string) error {
+	n.events <- event
+	return nil
+}
+
+func newTestThrottle() (*loginThrottleUsecase, *fakeThrottleRepo, *fakeNotifier, *fakeAudit, *models.User) {
+	user := &models.User{ID: uuid.New(), Email: "nurse@example.com"}
+	repo := &fakeThrottleRepo{rows: map[string]*models.LoginThrottle{}}
+	notifier := &fakeNotifier{events: make(chan models.NotificationEvent, 4)}
+	audit := &fakeAudit{}
+	uc := LoginThrottleNewUsecase(repo, &fakeThrottleUsers{user: user}, notifier, audit).(*loginThrottleUsecase)
+	return uc, repo, notifier, audit, user
+}
+
+func statusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if appErr, ok := err.(*helpers.AppError); ok {
+		return appErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+func TestLoginThrottleLock(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		age      time.Duration // how long ago the failures happened
+		want     int
+	}{
+		{"no failures", 0, 0, http.StatusOK},
+		{"free attempts", accountThrottle.freeAttempts, 0, http.StatusOK},
+		{"inside back-off", accountThrottle.freeAttempts + 1, 0, http.StatusTooManyRequests},
+		{"back-off waited out", accountThrottle.freeAttempts + 1, accountThrottle.maxDelay, http.StatusOK},
+		{"locked", accountThrottle.lockAfter, 0, http.StatusTooManyRequests},
+		{"still locked after back-off", accountThrottle.lockAfter, accountThrottle.maxDelay, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, repo, _, _, user := newTestThrottle()
+			for i := 0; i < tt.failures; i++ {
+				uc.RecordFailure(user.Email, "")
+			}
+			repo.ageFailures(tt.age)
+
+			if got := statusOf(uc.Check(user.Email, "")); got != tt.want {
+				t.Errorf("Check = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleNotifiesOnLock(t *testing.T) {
+	uc, _, notifier, _, user := newTestThrottle()
+	for i := 0; i < accountThrottle.lockAfter; i++ {
+		uc.RecordFailure(user.Email, "10.0.0.1")
+	}
+
+	select {
+	case event := <-notifier.events:
+		if event != models.NotificationAccountLocked {
+			t.Errorf("notified %q, want %q", event, models.NotificationAccountLocked)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("owner was not notified of the lock")
+	}
+
+	// Further failures while locked must not notify again
+	uc.RecordFailure(user.Email, "10.0.0.1")
+	select {
+	case event := <-notifier.events:
+		t.Errorf("notified %q again while already locked", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLoginThrottleUnlock(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID func(user *models.User) string
+		want   int
+		freed  bool
+	}{
+		{"unlocks the account", func(u *models.User) string { return u.ID.String() }, http.StatusOK, true},
+		{"unknown user", func(*models.User) string { return uuid.NewString() }, http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, _, audit, user := newTestThrottle()
+			for i := 0; i < accountThrottle.lockAfter; i++ {
+				uc.RecordFailure(user.Email, "")
+			}
+
+			if got := statusOf(uc.Unlock(context.Background(), tt.userID(user))); got != tt.want {
+				t.Fatalf("Unlock = %d, want %d", got, tt.want)
+			}
+			freed := uc.Check(user.Email, "") == nil
+			if freed != tt.freed {
+				t.Errorf("account usable after unlock = %v, want %v", freed, tt.freed)
+			}
+			if audited := len(audit.entries) == 1 && audit.entries[0].Action == models.AuditActionAccountUnlock; audited != tt.freed {
+				t.Errorf("unlock audited = %v, want %v", audited, tt.freed)
+			}
+		})
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	p := throttlePolicy{freeAttempts: 3, baseDelay: 2 * time.Second, maxDelay: time.Minute}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{8, 32 * time.Second},
+		{9, time.Minute},
+		{100, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.delayFor(tt.failures); got != tt.want {
+			t.Errorf("delayFor(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/realtime"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/sms"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/templates"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// notificationChannel delivers one notification over one medium. n has no ID
+// when it was not recorded beforehand through the outbox.
+type notificationChannel interface {
+	send(n *models.Notification, user *models.User, data map[string]string) error
+}
+
+// emailChannel queues the templated email for the SMTP worker
+type emailChannel struct {
+	emailUC EmailUsecase
+}
+
+func (c *emailChannel) send(n *models.Notification, user *models.User, data map[string]string) error {
+	_, err := c.emailUC.QueueTemplate(user.ID, user.Email, models.EmailType(n.Event), data)
+	return err
+}
+
+// smsChannel renders the short bundled text for the event
+type smsChannel struct {
+	sender sms.Sender
+}
+
+func (c *smsChannel) send(n *models.Notification, user *models.User, data map[string]string) error {
+	src, err := templates.SMS(string(n.Event))
+	if err != nil {
+		return err
+	}
+	body, err := executeText("sms", src, data)
+	if err != nil {
+		return err
+	}
+	return c.sender.Send(context.Background(), user.Phone, strings.TrimSpace(body))
+}
+
+// inAppChannel stores the notification in the user's inbox, or shows the
+// row recorded through the outbox, reusing the email template's subject and
+// plain-text body, and pushes it to any stream the user has open
+type inAppChannel struct {
+	repo       repository.NotificationRepository
+	templateUC EmailTemplateUsecase
+	hub        *realtime.Hub
+}
+
+func (c *inAppChannel) send(n *models.Notification, user *models.User, data map[string]string) error {
+	rendered, err := c.templateUC.Render(models.EmailType(n.Event), data)
+	if err != nil {
+		return err
+	}
+	n.Title = rendered.Subject
+	n.Body = strings.TrimSpace(rendered.Text)
+
+	if n.ID != uuid.Nil {
+		if err := c.repo.Show(n); err != nil {
+			return err
+		}
+		n.Hidden = false
+	} else {
+		payload, err := models.ToJSON(data)
+		if err != nil {
+			return err
+		}
+		n.Data = payload
+		if err := c.repo.Create(n); err != nil {
+			return err
+		}
+	}
+
+	c.hub.Publish(user.ID, realtime.Event{ID: n.ID.String(), Name: NotificationStreamEvent, Data: n})
+	if count, err := c.repo.UnreadCount(user.ID); err == nil {
+		c.hub.Publish(user.ID, realtime.Event{Name: UnreadCountStreamEvent, Data: &dto.NotificationUnreadResponse{Unread: count}})
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/realtime"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/sms"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationUsecase is the single entry point for telling a user about
+// something. It decides the channels from the event defaults and the
+// user's preferences, then hands off to each channel.
+type NotificationUsecase interface {
+	Notify(user *models.User, event models.NotificationEvent, data map[string]string) error
+	// Enqueue records a notification in tx, the caller's transaction. It is
+	// sent by Deliver once the transaction has committed.
+	Enqueue(tx *gorm.DB, userID uuid.UUID, event models.NotificationEvent, data map[string]string) error
+	// Deliver sends a notification recorded by Enqueue on the channels that
+	// have not delivered it yet, failing while any of them still has not
+	Deliver(ctx context.Context, job queue.NotificationJob) error
+
+	Preferences(ctx context.Context) ([]dto.NotificationEventPreferences, error)
+	UpdatePreferences(ctx context.Context, req *dto.NotificationPreferencesRequest) ([]dto.NotificationEventPreferences, error)
+
+	// Inbox of the caller
+	List(ctx context.Context, filter *dto.NotificationFilter) (*dto.ListResponse, error)
+	UnreadCount(ctx context.Context) (*dto.NotificationUnreadResponse, error)
+	MarkRead(ctx context.Context, id uuid.UUID) error
+	MarkAllRead(ctx context.Context) (*dto.NotificationUnreadResponse, error)
+	// Subscribe streams the caller's new notifications and unread counts
+	Subscribe(ctx context.Context) (<-chan realtime.Event, func(), error)
+}
+
+// Events pushed to open notification streams
+const (
+	NotificationStreamEvent = "notification"
+	UnreadCountStreamEvent  = "unread_count"
+)
+
+type notificationUsecase struct {
+	repo     repository.NotificationRepository
+	userRepo repository.UserRepository
+	outbox   repository.OutboxRepository
+	hub      *realtime.Hub
+	channels map[string]notificationChannel
+}
+
+func NotificationNewUsecase(repo repository.NotificationRepository, userRepo repository.UserRepository, outbox repository.OutboxRepository, emailUC EmailUsecase, templateUC EmailTemplateUsecase, smsSender sms.Sender, hub *realtime.Hub) NotificationUsecase {
+	return &notificationUsecase{
+		repo:     repo,
+		userRepo: userRepo,
+		outbox:   outbox,
+		hub:      hub,
+		channels: map[string]notificationChannel{
+			models.ChannelEmail: &emailChannel{emailUC: emailUC},
+			models.ChannelSMS:   &smsChannel{sender: smsSender},
+			models.ChannelInApp: &inAppChannel{repo: repo, templateUC: templateUC, hub: hub},
+		},
+	}
+}
+
+func (u *notificationUsecase) Notify(user *models.User, event models.NotificationEvent, data map[string]string) error {
+	return u.send(&models.Notification{UserID: user.ID, Event: event}, user, data)
+}
+
+// send delivers n on every channel enabled for the user. For a notification
+// recorded by Enqueue, channels that already delivered it are skipped and
+// each one that succeeds is recorded, so a retry only repeats the failures.
+func (u *notificationUsecase) send(n *models.Notification, user *models.User, data map[string]string) error {
+	event := n.Event
+	cfg, ok := models.NotificationEvents[event]
+	if !ok {
+		return fmt.Errorf("unknown notification event %q", event)
+	}
+	tracked := n.ID != uuid.Nil
+
+	// The event defaults still apply without preferences, so carry on
+	// rather than dropping the notification
+	prefs, err := u.repo.ListPreferences(user.ID)
+	if err != nil {
+		log.Println("Failed to load notification preferences:", err)
+	}
+	enabled := resolveChannels(event, cfg, prefs)
+
+	payload := make(map[string]string, len(data)+1)
+	payload["Name"] = user.Name
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	var errs []error
+	for _, name := range models.NotificationChannels {
+		if !enabled[name] || n.DeliveredTo(name) {
+			continue
+		}
+		if err := u.channels[name].send(n, user, payload); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if tracked {
+			if err := u.repo.MarkDelivered(n.ID, name); err != nil {
+				errs = append(errs, fmt.Errorf("%s: record delivery: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (u *notificationUsecase) Enqueue(tx *gorm.DB, userID uuid.UUID, event models.NotificationEvent, data map[string]string) error {
+	if _, ok := models.NotificationEvents[event]; !ok {
+		return fmt.Errorf("unknown notification event %q", event)
+	}
+
+	// The row tracks delivery per channel; it joins the inbox once the
+	// in-app channel has delivered it
+	payload, err := models.ToJSON(data)
+	if err != nil {
+		return err
+	}
+	n := &models.Notification{UserID: userID, Event: event, Data: payload, Hidden: true}
+	if err := u.repo.CreateTx(tx, n); err != nil {
+		return err
+	}
+
+	msg, err := models.NewOutboxMessage("", queue.NotificationQueue, queue.NotificationJobType, queue.NotificationJob{
+		NotificationID: n.ID,
+		UserID:         userID,
+		Event:          string(event),
+		Data:           data,
+	})
+	if err != nil {
+		return err
+	}
+	return u.outbox.Add(tx, msg)
+}
+
+func (u *notificationUsecase) Deliver(ctx context.Context, job queue.NotificationJob) error {
+	user, err := u.userRepo.FindByID(job.UserID.String())
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return queue.Permanent(fmt.Errorf("user %s not found", job.UserID))
+	}
+
+	// Jobs queued before deliveries were tracked carry no row
+	if job.NotificationID == uuid.Nil {
+		return u.Notify(user, models.NotificationEvent(job.Event), job.Data)
+	}
+	n, err := u.repo.FindByID(job.NotificationID)
+	if err != nil {
+		return err
+	}
+	if n == nil {
+		return nil // removed along with its user
+	}
+	return u.send(n, user, job.Data)
+}
+
+func (u *notificationUsecase) Preferences(ctx context.Context) ([]dto.NotificationEventPreferences, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := u.repo.ListPreferences(actor.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load notification preferences")
+	}
+	return describePreferences(prefs), nil
+}
+
+func (u *notificationUsecase) UpdatePreferences(ctx context.Context, req *dto.NotificationPreferencesRequest) ([]dto.NotificationEventPreferences, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make([]models.NotificationPreference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		event := models.NotificationEvent(p.Event)
+		cfg, known := models.NotificationEvents[event]
+		if !known && event != models.AllNotificationEvents {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Unknown event: "+p.Event)
+		}
+		if !models.IsValidNotificationChannel(p.Channel) {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Unknown channel: "+p.Channel)
+		}
+		if !p.Enabled && slices.Contains(cfg.Required, p.Channel) {
+			return nil, helpers.NewAppError(http.StatusBadRequest, fmt.Sprintf("%s notifications for %s cannot be turned off", p.Channel, p.Event))
+		}
+		prefs = append(prefs, models.NotificationPreference{
+			UserID:  actor.ID,
+			Event:   event,
+			Channel: p.Channel,
+			Enabled: p.Enabled,
+		})
+	}
+
+	if err := u.repo.SavePreferences(prefs); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save notification preferences")
+	}
+	return u.Preferences(ctx)
+}
+
+func (u *notificationUsecase) List(ctx context.Context, filter *dto.NotificationFilter) (*dto.ListResponse, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.UserID = actor.ID
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	items, total, err := u.repo.List(filter)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to retrieve notifications")
+	}
+
+	data := make([]interface{}, len(items))
+	for i, n := range items {
+		data[i] = n
+	}
+	totalPages := int(total) / filter.PageSize
+	if int(total)%filter.PageSize > 0 {
+		totalPages++
+	}
+	return &dto.ListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (u *notificationUsecase) UnreadCount(ctx context.Context) (*dto.NotificationUnreadResponse, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.unread(actor.ID)
+}
+
+func (u *notificationUsecase) MarkRead(ctx context.Context, id uuid.UUID) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+
+	found, err := u.repo.MarkRead(actor.ID, id)
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to update notification")
+	}
+	if !found {
+		return helpers.NewAppError(http.StatusNotFound, "Notification not found")
+	}
+
+	// Keep the badge in the user's other tabs in step
+	u.unread(actor.ID)
+	return nil
+}
+
+func (u *notificationUsecase) MarkAllRead(ctx context.Context) (*dto.NotificationUnreadResponse, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.repo.MarkAllRead(actor.ID); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update notifications")
+	}
+	return u.unread(actor.ID)
+}
+
+func (u *notificationUsecase) Subscribe(ctx context.Context) (<-chan realtime.Event, func(), error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, unsubscribe, err := u.hub.Subscribe(actor.ID)
+	if err != nil {
+		return nil, nil, helpers.NewAppError(http.StatusTooManyRequests, err.Error())
+	}
+	return events, unsubscribe, nil
+}
+
+// unread counts the user's unread notifications and pushes the count to
+// their open streams
+func (u *notificationUsecase) unread(userID uuid.UUID) (*dto.NotificationUnreadResponse, error) {
+	count, err := u.repo.UnreadCount(userID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to count notifications")
+	}
+	resp := &dto.NotificationUnreadResponse{Unread: count}
+	u.hub.Publish(userID, realtime.Event{Name: UnreadCountStreamEvent, Data: resp})
+	return resp, nil
+}
+
+// resolveChannels applies, in order: the event defaults, the user's "*"
+// preferences, their preferences for this event, and the required channels
+func resolveChannels(event models.NotificationEvent, cfg models.NotificationEventConfig, prefs []models.NotificationPreference) map[string]bool {
+	enabled := make(map[string]bool, len(models.NotificationChannels))
+	for _, ch := range cfg.Defaults {
+		enabled[ch] = true
+	}
+	for _, p := range prefs {
+		if p.Event == models.AllNotificationEvents {
+			enabled[p.Channel] = p.Enabled
+		}
+	}
+	for _, p := range prefs {
+		if p.Event == event {
+			enabled[p.Channel] = p.Enabled
+		}
+	}
+	for _, ch := range cfg.Required {
+		enabled[ch] = true
+	}
+	return enabled
+}
+
+func describePreferences(prefs []models.NotificationPreference) []dto.NotificationEventPreferences {
+	out := make([]dto.NotificationEventPreferences, 0, len(models.TemplatedEmailTypes))
+	for _, typ := range models.TemplatedEmailTypes {
+		event := models.NotificationEvent(typ)
+		cfg := models.NotificationEvents[event]
+		enabled := resolveChannels(event, cfg, prefs)
+
+		item := dto.NotificationEventPreferences{Event: string(event)}
+		for _, ch := range models.NotificationChannels {
+			item.Channels = append(item.Channels, dto.NotificationChannelState{
+				Channel:  ch,
+				Enabled:  enabled[ch],
+				Required: slices.Contains(cfg.Required, ch),
+			})
+		}
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeNotificationRepo keeps notification rows in memory
+type fakeNotificationRepo struct {
+	repository.NotificationRepository
+	rows map[uuid.UUID]*models.Notification
+}
+
+func (r *fakeNotificationRepo) FindByID(id uuid.UUID) (*models.Notification, error) {
+	n, ok := r.rows[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *n
+	return &cp, nil
+}
+
+func (r *fakeNotificationRepo) MarkDelivered(id uuid.UUID, channel string) error {
+	n := r.rows[id]
+	var channels []string
+	if len(n.Delivered) > 0 {
+		_ = json.Unmarshal(n.Delivered, &channels)
+	}
+	n.Delivered, _ = models.ToJSON(append(channels, channel))
+	return nil
+}
+
+func (r *fakeNotificationRepo) ListPreferences(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	return nil, nil
+}
+
+// fakeChannel counts sends and fails while failing is set
+type fakeChannel struct {
+	sent    int
+	failing bool
+}
+
+func (c *fakeChannel) send(n *models.Notification, user *models.User, data map[string]string) error {
+	if c.failing {
+		return errors.New("gateway down")
+	}
+	c.sent++
+	return nil
+}
+
+func TestNotificationDeliverRetriesFailedChannels(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Name: "Dr Who"}
+	n := &models.Notification{ID: uuid.New(), UserID: user.ID, Event: models.NotificationLabCriticalResult, Hidden: true}
+	repo := &fakeNotificationRepo{rows: map[uuid.UUID]*models.Notification{n.ID: n}}
+	channels := map[string]*fakeChannel{
+		models.ChannelInApp: {},
+		models.ChannelEmail: {},
+		models.ChannelSMS:   {failing: true},
+	}
+	uc := &notificationUsecase{
+		repo:     repo,
+		userRepo: &fakeThrottleUsers{user: user},
+		channels: map[string]notificationChannel{},
+	}
+	for name, ch := range channels {
+		uc.channels[name] = ch
+	}
+	job := queue.NotificationJob{NotificationID: n.ID, UserID: user.ID, Event: string(n.Event)}
+
+	tests := []struct {
+		name    string
+		smsDown bool
+		wantErr bool
+		want    map[string]int // sends per channel so far
+	}{
+		{"sms fails", true, true, map[string]int{models.ChannelInApp: 1, models.ChannelEmail: 1, models.ChannelSMS: 0}},
+		{"retry only repeats sms", false, false, map[string]int{models.ChannelInApp: 1, models.ChannelEmail: 1, models.ChannelSMS: 1}},
+		{"redelivered job sends nothing", false, false, map[string]int{models.ChannelInApp: 1, models.ChannelEmail: 1, models.ChannelSMS: 1}},
+	}
+	for _, tt := range tests {
+		channels[models.ChannelSMS].failing = tt.smsDown
+		err := uc.Deliver(context.Background(), job)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: Deliver error %v, want error: %v", tt.name, err, tt.wantErr)
+		}
+		for name, want := range tt.want {
+			if got := channels[name].sent; got != want {
+				t.Errorf("%s: %s sent %d times, want %d", tt.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestNotificationDeliverUnknownUser(t *testing.T) {
+	uc := &notificationUsecase{userRepo: &fakeThrottleUsers{}}
+	err := uc.Deliver(context.Background(), queue.NotificationJob{UserID: uuid.New(), Event: string(models.NotificationBookingUpdate)})
+	if !queue.IsPermanent(err) {
+		t.Errorf("Deliver error %v, want a permanent error", err)
+	}
+}
@@ -1,12 +1,11 @@
 package usecase
 
 import (
-	"hospital_management_system/internal/infra/rabbitmq"
+	"hospital_management_system/internal/events"
 	"hospital_management_system/internal/infra/repository"
 	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
 	"hospital_management_system/internal/pkg/utils"
-	"log"
 	"net/http"
 	"time"
 
@@ -20,20 +19,20 @@ type OtpUsecase interface {
 }
 
 type otpUsecase struct {
-	repo      repository.OtpRepository
-	emailUc   EmailUsecase
-	userUc    UserUsecase
-	publisher *rabbitmq.Publisher
+	repo     repository.OtpRepository
+	outbox   repository.OutboxRepository
+	notifyUC NotificationUsecase
+	userUc   UserUsecase
 }
 
-func OtpNewUsecase(repo repository.OtpRepository, emailUc EmailUsecase, userUc UserUsecase, publisher *rabbitmq.Publisher) OtpUsecase {
-	return &otpUsecase{repo: repo, emailUc: emailUc, userUc: userUc, publisher: publisher}
+func OtpNewUsecase(repo repository.OtpRepository, outbox repository.OutboxRepository, notifyUC NotificationUsecase, userUc UserUsecase) OtpUsecase {
+	return &otpUsecase{repo: repo, outbox: outbox, notifyUC: notifyUC, userUc: userUc}
 }
 
 // GenerateAndSaveOTP creates, saves, and returns a new OTP
 func (u *otpUsecase) GenerateAndSaveOTP(email string, purpose string) (*models.OTP, error) {
 	user, err := u.userUc.FindByEmail(email)
-	if err != nil {
+	if err != nil || user == nil {
 		return nil, helpers.NewAppError(http.StatusNotFound, "User not found")
 	}
 	otpCode := utils.GenerateOTP()
@@ -46,45 +45,25 @@ func (u *otpUsecase) GenerateAndSaveOTP(email string, purpose string) (*models.O
 		ExpiresAt: expiration,
 	}
 
-	if err := u.repo.SaveOTP(otp); err != nil {
-		return nil, helpers.NewAppError(500, "Failed to save OTP")
+	event := models.NotificationOTP
+	if purpose == models.OTPPurposePasswordReset {
+		event = models.NotificationPasswordReset
 	}
 
-	// Render email template
-	body, err := utils.RenderEmailTemplate("templates/otp_email.html", map[string]string{
-		"Name": user.Name,
-		"Code": otpCode,
+	// The code is delivered on the user's channels after the OTP commits;
+	// recording both together means a broker outage cannot lose it
+	err = u.repo.Transaction(func(tx *gorm.DB) error {
+		if err := u.repo.SaveOTP(tx, otp); err != nil {
+			return err
+		}
+		return u.notifyUC.Enqueue(tx, user.ID, event, map[string]string{
+			"Code": otpCode,
+		})
 	})
 	if err != nil {
-		log.Println("Failed to render email template:", err)
+		return nil, helpers.NewAppError(500, "Failed to save OTP")
 	}
 
-	// Asynchronous tasks: Create email record and publish to RabbitMQ
-	go func() {
-		emailRecord, err := u.emailUc.CreateEmail(
-			user.ID,
-			user.Email,
-			"OTP Verification",
-			body,
-			models.EmailTypeOTP,
-		)
-		if err != nil {
-			log.Println("Failed to create email record:", err)
-			return
-		}
-
-		// Publish email job to RabbitMQ
-		job := helpers.EmailJob{
-			EmailID: emailRecord.ID,
-			To:      emailRecord.Email,
-			Subject: emailRecord.Subject,
-			Body:    emailRecord.Body,
-		}
-		if err := u.publisher.Publish(job); err != nil {
-			log.Println("Failed to publish email job:", err)
-		}
-	}()
-
 	return otp, nil
 }
 
@@ -99,6 +78,11 @@ func (u *otpUsecase) ValidateOTP(email string, code string) error {
 		return helpers.NewAppError(400, "OTP expired")
 	}
 
+	user, err := u.userUc.FindByEmail(email)
+	if err != nil || user == nil {
+		return helpers.NewAppError(404, "User not found")
+	}
+
 	// Use transaction to ensure both operations succeed or fail together
 	err = u.repo.Transaction(func(tx *gorm.DB) error {
 		// Mark OTP as used
@@ -111,7 +95,10 @@ func (u *otpUsecase) ValidateOTP(email string, code string) error {
 			return err
 		}
 
-		return nil
+		if user.IsVerified {
+			return nil
+		}
+		return recordEvent(u.outbox, tx, events.UserVerified{UserID: user.ID})
 	})
 
 	if err != nil {
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/infra/queue"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"log"
+	"time"
+)
+
+const (
+	outboxBatch       = 100
+	outboxRetention   = 7 * 24 * time.Hour // dispatched rows are kept this long for debugging
+	outboxPurgeEvery  = time.Hour
+	outboxMaxBackoff  = 5 * time.Minute
+	outboxBaseBackoff = time.Second
+)
+
+// OutboxPublisher sends encoded outbox messages to the broker, a batch at
+// a time so their confirms can be awaited together
+type OutboxPublisher interface {
+	PublishBatch(msgs []queue.Publishing) []error
+}
+
+// OutboxRelay publishes committed outbox messages. Several relays may run
+// at once; each message is published by one of them, at least once.
+type OutboxRelay interface {
+	Run(ctx context.Context)
+}
+
+type outboxRelay struct {
+	repo      repository.OutboxRepository
+	publisher OutboxPublisher
+	poll      time.Duration
+}
+
+func OutboxRelayNewUsecase(repo repository.OutboxRepository, publisher OutboxPublisher) (OutboxRelay, error) {
+	poll, err := time.ParseDuration(config.ENV.OutboxPollInterval)
+	if err != nil || poll <= 0 {
+		return nil, fmt.Errorf("OUTBOX_POLL_INTERVAL must be a positive duration, got %q", config.ENV.OutboxPollInterval)
+	}
+	return &outboxRelay{repo: repo, publisher: publisher, poll: poll}, nil
+}
+
+func (r *outboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.poll)
+	defer ticker.Stop()
+	lastPurge := time.Time{}
+
+	for {
+		r.drain()
+
+		if time.Since(lastPurge) >= outboxPurgeEvery {
+			if _, err := r.repo.PurgeDispatched(time.Now().Add(-outboxRetention)); err != nil {
+				log.Println("Failed to purge outbox:", err)
+			}
+			lastPurge = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publishes full batches until the outbox is empty or publishing fails
+func (r *outboxRelay) drain() {
+	for {
+		n, err := r.repo.Dispatch(outboxBatch, r.publish, outboxBackoff)
+		if err != nil {
+			log.Println("Failed to dispatch outbox:", err)
+			return
+		}
+		if n < outboxBatch {
+			return
+		}
+	}
+}
+
+func (r *outboxRelay) publish(msgs []models.OutboxMessage) []error {
+	batch := make([]queue.Publishing, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = queue.Publishing{
+			Exchange:   msg.Exchange,
+			RoutingKey: msg.RoutingKey,
+			MessageID:  msg.ID.String(),
+			Type:       msg.Type,
+			Body:       msg.Payload,
+		}
+	}
+	return r.publisher.PublishBatch(batch)
+}
+
+// outboxBackoff doubles the wait after each failed publish
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 1; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > outboxMaxBackoff {
+		d = outboxMaxBackoff
+	}
+	return d
+}
@@ -1,23 +1,27 @@
 package usecase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"hospital_management_system/config"
 	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/events"
 	"hospital_management_system/internal/infra/repository"
 	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
+	"log"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type PaymentUsecase interface {
 	InitPayment(req *dto.InitPaymentRequest) (*dto.InitPaymentResponse, error)
-	HandleSuccessCallback(req dto.SSLCallbackRequest) error
+	HandleSuccessCallback(ctx context.Context, req dto.SSLCallbackRequest) error
 	HandleFailCallback(req dto.SSLCallbackRequest) error
 	GetAll() ([]models.Payment, error)
 }
@@ -25,10 +29,14 @@ type PaymentUsecase interface {
 type paymentUsecase struct {
 	paymentRepo repository.PaymentRepository
 	bookingRepo repository.BookingRepository
+	userRepo    repository.UserRepository
+	outbox      repository.OutboxRepository
+	auditUC     AuditUsecase
+	notifyUC    NotificationUsecase
 }
 
-func NewPaymentUsecase(paymentRepo repository.PaymentRepository, bookingRepo repository.BookingRepository) PaymentUsecase {
-	return &paymentUsecase{paymentRepo, bookingRepo}
+func NewPaymentUsecase(paymentRepo repository.PaymentRepository, bookingRepo repository.BookingRepository, userRepo repository.UserRepository, outbox repository.OutboxRepository, auditUC AuditUsecase, notifyUC NotificationUsecase) PaymentUsecase {
+	return &paymentUsecase{paymentRepo, bookingRepo, userRepo, outbox, auditUC, notifyUC}
 }
 
 func (u *paymentUsecase) InitPayment(req *dto.InitPaymentRequest) (*dto.InitPaymentResponse, error) {
@@ -98,7 +106,7 @@ func (u *paymentUsecase) InitPayment(req *dto.InitPaymentRequest) (*dto.InitPaym
 	}, nil
 }
 
-func (u *paymentUsecase) HandleSuccessCallback(req dto.SSLCallbackRequest) error {
+func (u *paymentUsecase) HandleSuccessCallback(ctx context.Context, req dto.SSLCallbackRequest) error {
 	payment, err := u.paymentRepo.GetByTranID(req.TranID)
 	if err != nil {
 		return helpers.NewAppError(404, "Payment record not found")
@@ -111,11 +119,80 @@ func (u *paymentUsecase) HandleSuccessCallback(req dto.SSLCallbackRequest) error
 	payment.ValidationID = req.ValID
 	payment.TransactionAt = &t
 
-	if err := u.paymentRepo.Update(payment); err != nil {
-		return helpers.NewAppError(500, "Failed to update payment")
+	bookingID := payment.BookingID.String()
+	before, _ := u.bookingRepo.GetByID(bookingID)
+
+	// The payment, the booking it confirms and their events commit together
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.paymentRepo.UpdateTx(tx, payment); err != nil {
+			return helpers.NewAppError(500, "Failed to update payment")
+		}
+		if err := u.bookingRepo.UpdateStatusTx(tx, bookingID, models.BookingConfirmed); err != nil {
+			return err
+		}
+		if err := recordEvent(u.outbox, tx, events.PaymentSucceeded{
+			PaymentID:     payment.ID,
+			BookingID:     payment.BookingID,
+			TranID:        payment.TranID,
+			Amount:        payment.Amount,
+			Method:        payment.Method,
+			TransactionAt: payment.TransactionAt,
+		}); err != nil {
+			return err
+		}
+		if before == nil || before.Status == models.BookingConfirmed {
+			return nil
+		}
+		return recordEvent(u.outbox, tx, events.BookingStatusChanged{
+			BookingID: before.ID,
+			PatientID: before.PatientID,
+			From:      string(before.Status),
+			To:        string(models.BookingConfirmed),
+			Reason:    "payment",
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Confirmed by the payment gateway, so there is no actor
+	entry := AuditEntry{
+		Action:       models.AuditActionStatusChange,
+		ResourceType: models.AuditResourceBooking,
+		ResourceID:   bookingID,
+		After:        map[string]interface{}{"status": models.BookingConfirmed},
+	}
+	if before != nil {
+		entry.Before = map[string]interface{}{"status": before.Status}
+	}
+	u.auditUC.Record(ctx, entry)
+
+	if before != nil {
+		go u.notifyPaid(payment, before)
+	}
+	return nil
+}
+
+// notifyPaid sends the receipt and, for a newly confirmed booking, the confirmation
+func (u *paymentUsecase) notifyPaid(payment *models.Payment, booking *models.Booking) {
+	patient, err := u.userRepo.FindByID(booking.PatientID.String())
+	if err != nil || patient == nil {
+		log.Println("Failed to find patient for payment notification:", err)
+		return
+	}
+
+	if err := u.notifyUC.Notify(patient, models.NotificationPaymentReceipt, map[string]string{
+		"Amount":    fmt.Sprintf("%.2f BDT", payment.Amount),
+		"Reference": payment.TranID,
+		"PaidAt":    payment.TransactionAt.Format("2006-01-02 15:04"),
+	}); err != nil {
+		log.Println("Failed to send payment receipt:", err)
 	}
 
-	return u.bookingRepo.UpdateStatus(payment.BookingID.String(), models.BookingConfirmed)
+	if booking.Status != models.BookingConfirmed {
+		booking.Status = models.BookingConfirmed
+		notifyBookingConfirmed(u.notifyUC, patient, booking)
+	}
 }
 
 func (u *paymentUsecase) HandleFailCallback(req dto.SSLCallbackRequest) error {
@@ -124,7 +201,43 @@ func (u *paymentUsecase) HandleFailCallback(req dto.SSLCallbackRequest) error {
 		return nil
 	}
 	payment.Status = models.PaymentFailed
-	return u.paymentRepo.Update(payment)
+	err = u.outbox.Transaction(func(tx *gorm.DB) error {
+		if err := u.paymentRepo.UpdateTx(tx, payment); err != nil {
+			return err
+		}
+		return recordEvent(u.outbox, tx, events.PaymentFailed{
+			PaymentID: payment.ID,
+			BookingID: payment.BookingID,
+			TranID:    payment.TranID,
+			Amount:    payment.Amount,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	go u.notifyFailed(payment)
+	return nil
+}
+
+func (u *paymentUsecase) notifyFailed(payment *models.Payment) {
+	booking, err := u.bookingRepo.GetByID(payment.BookingID.String())
+	if err != nil {
+		log.Println("Failed to find booking for payment notification:", err)
+		return
+	}
+	patient, err := u.userRepo.FindByID(booking.PatientID.String())
+	if err != nil || patient == nil {
+		log.Println("Failed to find patient for payment notification:", err)
+		return
+	}
+
+	if err := u.notifyUC.Notify(patient, models.NotificationPaymentFailed, map[string]string{
+		"Amount":    fmt.Sprintf("%.2f BDT", payment.Amount),
+		"Reference": payment.TranID,
+	}); err != nil {
+		log.Println("Failed to send payment failure notice:", err)
+	}
 }
 
 
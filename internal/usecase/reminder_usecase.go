@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/events"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	reminderBatch      = 50
+	reminderLease      = 5 * time.Minute // a crashed scheduler's claims are retried after this
+	reminderRetryDelay = 5 * time.Minute
+	reminderMaxTries   = 3
+)
+
+// ReminderUsecase sends booking reminders at the offsets in REMINDER_OFFSETS
+type ReminderUsecase interface {
+	// ScheduleForBooking queues the reminders for a booking. Offsets that are
+	// already in the past are skipped; existing reminders are left alone.
+	ScheduleForBooking(b *models.Booking) error
+	// CancelForBooking drops the booking's reminders that have not gone out
+	CancelForBooking(bookingID uuid.UUID, reason string) error
+	// Start backfills reminders for upcoming bookings, then sends due ones
+	// until ctx is cancelled. Safe to run on several servers at once.
+	Start(ctx context.Context)
+	// Subscribe keeps reminders in step with booking events
+	Subscribe(r events.Registrar)
+}
+
+type reminderUsecase struct {
+	repo        repository.BookingReminderRepository
+	bookingRepo repository.BookingRepository
+	userRepo    repository.UserRepository
+	notifyUC    NotificationUsecase
+	offsets     []time.Duration
+	poll        time.Duration
+}
+
+func ReminderNewUsecase(repo repository.BookingReminderRepository, bookingRepo repository.BookingRepository, userRepo repository.UserRepository, notifyUC NotificationUsecase) (ReminderUsecase, error) {
+	var offsets []time.Duration
+	for _, s := range strings.Split(config.ENV.ReminderOffsets, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid REMINDER_OFFSETS entry %q", s)
+		}
+		offsets = append(offsets, d)
+	}
+
+	poll, err := time.ParseDuration(config.ENV.ReminderPollInterval)
+	if err != nil || poll < 10*time.Second {
+		return nil, fmt.Errorf("REMINDER_POLL_INTERVAL must be a duration of at least 10s, got %q", config.ENV.ReminderPollInterval)
+	}
+
+	return &reminderUsecase{
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		userRepo:    userRepo,
+		notifyUC:    notifyUC,
+		offsets:     offsets,
+		poll:        poll,
+	}, nil
+}
+
+func (u *reminderUsecase) ScheduleForBooking(b *models.Booking) error {
+	start := bookingStart(b)
+	if start == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var reminders []models.BookingReminder
+	for _, offset := range u.offsets {
+		at := start.Add(-offset)
+		if at.Before(now) {
+			continue
+		}
+		reminders = append(reminders, models.BookingReminder{
+			BookingID: b.ID,
+			PatientID: b.PatientID,
+			Offset:    offset.String(),
+			RemindAt:  at,
+			Status:    models.ReminderPending,
+		})
+	}
+	return u.repo.CreateMany(reminders)
+}
+
+func (u *reminderUsecase) CancelForBooking(bookingID uuid.UUID, reason string) error {
+	_, err := u.repo.CancelForBooking(bookingID, reason)
+	return err
+}
+
+func (u *reminderUsecase) Subscribe(r events.Registrar) {
+	events.On(r, func(ctx context.Context, env events.Envelope, e events.BookingCreated) error {
+		b, err := u.bookingRepo.GetByID(e.BookingID.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // deleted since
+		}
+		if err != nil {
+			return err
+		}
+		return u.ScheduleForBooking(b)
+	})
+	events.On(r, func(ctx context.Context, env events.Envelope, e events.BookingStatusChanged) error {
+		if to := models.BookingStatus(e.To); to != models.BookingCanceled && to != models.BookingCompleted {
+			return nil
+		}
+		return u.CancelForBooking(e.BookingID, "booking "+e.To)
+	})
+	events.On(r, func(ctx context.Context, env events.Envelope, e events.BookingDeleted) error {
+		return u.CancelForBooking(e.BookingID, "booking deleted")
+	})
+}
+
+func (u *reminderUsecase) Start(ctx context.Context) {
+	if len(u.offsets) == 0 {
+		return
+	}
+
+	// Bookings made before reminders existed, or while offsets differed
+	if bookings, err := u.bookingRepo.ListUpcoming(time.Now()); err != nil {
+		log.Println("Failed to load bookings for reminders:", err)
+	} else {
+		for i := range bookings {
+			if err := u.ScheduleForBooking(&bookings[i]); err != nil {
+				log.Println("Failed to schedule booking reminders:", err)
+			}
+		}
+	}
+
+	ticker := time.NewTicker(u.poll)
+	defer ticker.Stop()
+	for {
+		u.sendDue()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue works through everything due, a batch at a time
+func (u *reminderUsecase) sendDue() {
+	for {
+		claimed, err := u.repo.ClaimDue(time.Now(), reminderBatch, reminderLease)
+		if err != nil {
+			log.Println("Failed to claim due reminders:", err)
+			return
+		}
+		for i := range claimed {
+			u.send(&claimed[i])
+		}
+		if len(claimed) < reminderBatch {
+			return
+		}
+	}
+}
+
+func (u *reminderUsecase) send(rem *models.BookingReminder) {
+	booking, err := u.bookingRepo.GetByID(rem.BookingID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.finish(rem, models.ReminderCanceled, "booking deleted")
+			return
+		}
+		u.retry(rem, err)
+		return
+	}
+	if booking.Status == models.BookingCanceled || booking.Status == models.BookingCompleted {
+		u.finish(rem, models.ReminderCanceled, "booking "+string(booking.Status))
+		return
+	}
+
+	// The server may have been down past the appointment
+	now := time.Now()
+	start := bookingStart(booking)
+	if start == nil || !start.After(now) {
+		u.finish(rem, models.ReminderCanceled, "booking already started")
+		return
+	}
+
+	patient, err := u.userRepo.FindByID(rem.PatientID.String())
+	if err != nil {
+		u.retry(rem, err)
+		return
+	}
+	if patient == nil {
+		u.finish(rem, models.ReminderFailed, "patient not found")
+		return
+	}
+
+	// Not retried: some channels may already have delivered
+	if err := u.notifyUC.Notify(patient, models.NotificationBookingReminder, map[string]string{
+		"BookingID": booking.ID.String(),
+		"Date":      bookingDate(booking),
+		"TimeLeft":  humanizeDuration(start.Sub(now)),
+	}); err != nil {
+		u.finish(rem, models.ReminderFailed, err.Error())
+		return
+	}
+
+	if err := u.repo.MarkSent(rem.ID); err != nil {
+		log.Println("Failed to mark reminder sent:", err)
+	}
+}
+
+func (u *reminderUsecase) retry(rem *models.BookingReminder, cause error) {
+	if rem.Attempts >= reminderMaxTries {
+		u.finish(rem, models.ReminderFailed, cause.Error())
+		return
+	}
+	at := time.Now().Add(reminderRetryDelay * time.Duration(rem.Attempts))
+	if err := u.repo.Retry(rem.ID, at, cause.Error()); err != nil {
+		log.Println("Failed to reschedule reminder:", err)
+	}
+}
+
+func (u *reminderUsecase) finish(rem *models.BookingReminder, status, reason string) {
+	if err := u.repo.Finish(rem.ID, status, reason); err != nil {
+		log.Println("Failed to update reminder:", err)
+	}
+}
+
+// bookingStart is the appointment time for service bookings and the
+// check-in date for room bookings
+func bookingStart(b *models.Booking) *time.Time {
+	if b.ScheduledAt != nil {
+		return b.ScheduledAt
+	}
+	return b.CheckInDate
+}
+
+// humanizeDuration renders d as "24 hours", "1 hour" or "45 minutes"
+func humanizeDuration(d time.Duration) string {
+	if d >= time.Hour {
+		hours := int(d.Round(time.Hour) / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes <= 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
@@ -0,0 +1,458 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type RoleUsecase interface {
+	SeedDefaults() error
+	Create(ctx context.Context, req *dto.CreateRoleRequest) (*models.Role, error)
+	GetByID(id string) (*models.Role, error)
+	GetAll() ([]models.Role, error)
+	Update(ctx context.Context, id string, req *dto.UpdateRoleRequest) (*models.Role, error)
+	Delete(ctx context.Context, id string) error
+	AssignRole(ctx context.Context, userID string, req *dto.AssignRoleRequest) error
+	// CheckStaffRole rejects names that are not an existing role a staff
+	// account may be created with; admins and patients have their own
+	// registration routes
+	CheckStaffRole(name string) error
+
+	CreatePermission(req *dto.CreatePermissionRequest) (*models.Permission, error)
+	GetAllPermissions() ([]models.Permission, error)
+	HasPermission(roleName, resource, action string) (bool, error)
+	RequiresTwoFactor(roleName string) (bool, error)
+}
+
+type roleUsecase struct {
+	repo     repository.RoleRepository
+	userRepo repository.UserRepository
+	auditUC  AuditUsecase
+
+	// role name → set of "resource:action" keys
+	cacheMu sync.RWMutex
+	cache   map[string]map[string]bool
+}
+
+func RoleNewUsecase(repo repository.RoleRepository, userRepo repository.UserRepository, auditUC AuditUsecase) RoleUsecase {
+	return &roleUsecase{
+		repo:     repo,
+		userRepo: userRepo,
+		auditUC:  auditUC,
+		cache:    make(map[string]map[string]bool),
+	}
+}
+
+// SeedDefaults makes sure the built-in permissions and roles exist.
+// Existing roles keep their permissions, except that permissions introduced
+// since the last start are added to the built-in roles that list them by
+// default. Admin always gets every permission.
+func (u *roleUsecase) SeedDefaults() error {
+	created := make(map[string]bool)
+	for _, p := range models.DefaultPermissions() {
+		perm := p
+		_, isNew, err := u.repo.FirstOrCreatePermission(&perm)
+		if err != nil {
+			return fmt.Errorf("failed to seed permission %s: %w", perm.Key(), err)
+		}
+		if isNew {
+			created[perm.Key()] = true
+		}
+	}
+
+	allPerms, err := u.repo.GetAllPermissions()
+	if err != nil {
+		return err
+	}
+
+	admin, err := u.ensureRole(models.RoleAdmin, nil)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.ReplacePermissions(admin, allPerms); err != nil {
+		return fmt.Errorf("failed to seed admin permissions: %w", err)
+	}
+
+	for name, keys := range models.DefaultRolePermissions {
+		role, err := u.ensureRole(name, keys)
+		if err != nil {
+			return err
+		}
+		if err := u.grantNewDefaults(role, keys, created); err != nil {
+			return err
+		}
+		if err := u.withdraw(role, models.WithdrawnRolePermissions[name]); err != nil {
+			return err
+		}
+	}
+
+	u.invalidateCache()
+	return nil
+}
+
+// ensureRole creates a system role with the given permissions if it does not exist yet
+func (u *roleUsecase) ensureRole(name string, keys []string) (*models.Role, error) {
+	role, err := u.repo.GetByName(name)
+	if err == nil {
+		return role, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	perms, err := u.repo.FindPermissionsByKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	role = &models.Role{
+		Name:        name,
+		IsSystem:    true,
+		Permissions: perms,
+	}
+	if _, err := u.repo.Create(role); err != nil {
+		return nil, fmt.Errorf("failed to seed role %s: %w", name, err)
+	}
+	return role, nil
+}
+
+// grantNewDefaults adds freshly created permissions from keys to an existing role
+func (u *roleUsecase) grantNewDefaults(role *models.Role, keys []string, created map[string]bool) error {
+	have := make(map[string]bool, len(role.Permissions))
+	for _, p := range role.Permissions {
+		have[p.Key()] = true
+	}
+
+	var missing []string
+	for _, k := range keys {
+		if created[k] && !have[k] {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	perms, err := u.repo.FindPermissionsByKeys(missing)
+	if err != nil {
+		return err
+	}
+	granted := append(role.Permissions, perms...)
+	if err := u.repo.ReplacePermissions(role, granted); err != nil {
+		return fmt.Errorf("failed to seed permissions for role %s: %w", role.Name, err)
+	}
+	role.Permissions = granted
+	return nil
+}
+
+// withdraw removes the given permission keys from a built-in role
+func (u *roleUsecase) withdraw(role *models.Role, keys []string) error {
+	withdrawn := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		withdrawn[k] = true
+	}
+
+	kept := make([]models.Permission, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		if !withdrawn[p.Key()] {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(role.Permissions) {
+		return nil
+	}
+	if err := u.repo.ReplacePermissions(role, kept); err != nil {
+		return fmt.Errorf("failed to withdraw permissions from role %s: %w", role.Name, err)
+	}
+	return nil
+}
+
+func (u *roleUsecase) Create(ctx context.Context, req *dto.CreateRoleRequest) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if name == "" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Role name is required")
+	}
+
+	if existing, _ := u.repo.GetByName(name); existing != nil {
+		return nil, helpers.NewAppError(http.StatusConflict, "Role with this name already exists")
+	}
+
+	perms, err := u.resolvePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        name,
+		Description: req.Description,
+		Require2FA:  req.Require2FA,
+		Permissions: perms,
+	}
+	if _, err := u.repo.Create(role); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to create role")
+	}
+
+	u.invalidateCache()
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceRole,
+		ResourceID:   role.ID.String(),
+		After:        auditRole(role),
+	})
+	return role, nil
+}
+
+func (u *roleUsecase) GetByID(id string) (*models.Role, error) {
+	role, err := u.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Role not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	return role, nil
+}
+
+func (u *roleUsecase) GetAll() ([]models.Role, error) {
+	return u.repo.GetAll()
+}
+
+func (u *roleUsecase) Update(ctx context.Context, id string, req *dto.UpdateRoleRequest) (*models.Role, error) {
+	role, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := auditRole(role)
+
+	if req.Permissions != nil {
+		if role.Name == models.RoleAdmin {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Admin permissions cannot be changed")
+		}
+		perms, err := u.resolvePermissions(*req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		if err := u.repo.ReplacePermissions(role, perms); err != nil {
+			return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update role permissions")
+		}
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Require2FA != nil {
+		role.Require2FA = *req.Require2FA
+	}
+
+	updated, err := u.repo.Update(role)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update role")
+	}
+
+	u.invalidateCache()
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceRole,
+		ResourceID:   updated.ID.String(),
+		Before:       before,
+		After:        auditRole(updated),
+	})
+	return updated, nil
+}
+
+func (u *roleUsecase) Delete(ctx context.Context, id string) error {
+	role, err := u.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return helpers.NewAppError(http.StatusBadRequest, "Built-in roles cannot be deleted")
+	}
+
+	count, err := u.repo.CountUsers(role.Name)
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if count > 0 {
+		return helpers.NewAppError(http.StatusConflict, "Role is still assigned to users")
+	}
+
+	if err := u.repo.Delete(role); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to delete role")
+	}
+
+	u.invalidateCache()
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceRole,
+		ResourceID:   role.ID.String(),
+		Before:       auditRole(role),
+	})
+	return nil
+}
+
+// AssignRole changes the role of an existing user
+func (u *roleUsecase) AssignRole(ctx context.Context, userID string, req *dto.AssignRoleRequest) error {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return helpers.NewAppError(http.StatusNotFound, "User not found")
+	}
+
+	if _, err := u.repo.GetByName(req.Role); err != nil {
+		return helpers.NewAppError(http.StatusBadRequest, "Unknown role")
+	}
+
+	if err := u.userRepo.UpdateRole(userID, req.Role); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to assign role")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionRoleAssign,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   user.ID.String(),
+		Before:       map[string]interface{}{"role": user.Role},
+		After:        map[string]interface{}{"role": req.Role},
+	})
+	return nil
+}
+
+func (u *roleUsecase) CheckStaffRole(name string) error {
+	if name == "" || name == models.RoleAdmin || name == models.RolePatient {
+		return helpers.NewAppError(http.StatusBadRequest, "Staff accounts need a staff role")
+	}
+	if _, err := u.repo.GetByName(name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewAppError(http.StatusBadRequest, "Unknown role")
+		}
+		return helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	return nil
+}
+
+func (u *roleUsecase) CreatePermission(req *dto.CreatePermissionRequest) (*models.Permission, error) {
+	resource := strings.ToLower(strings.TrimSpace(req.Resource))
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	if resource == "" || action == "" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Resource and action are required")
+	}
+
+	existing, err := u.repo.FindPermissionsByKeys([]string{models.PermissionKey(resource, action)})
+	if err == nil && len(existing) > 0 {
+		return nil, helpers.NewAppError(http.StatusConflict, "Permission already exists")
+	}
+
+	perm := &models.Permission{
+		Resource:    resource,
+		Action:      action,
+		Description: req.Description,
+	}
+	if _, err := u.repo.CreatePermission(perm); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to create permission")
+	}
+
+	// Admin always holds every permission
+	if admin, err := u.repo.GetByName(models.RoleAdmin); err == nil {
+		_ = u.repo.ReplacePermissions(admin, append(admin.Permissions, *perm))
+	}
+
+	u.invalidateCache()
+	return perm, nil
+}
+
+func (u *roleUsecase) GetAllPermissions() ([]models.Permission, error) {
+	return u.repo.GetAllPermissions()
+}
+
+// HasPermission reports whether the role grants the action on the resource
+func (u *roleUsecase) HasPermission(roleName, resource, action string) (bool, error) {
+	key := models.PermissionKey(resource, action)
+
+	u.cacheMu.RLock()
+	perms, ok := u.cache[roleName]
+	u.cacheMu.RUnlock()
+	if ok {
+		return perms[key], nil
+	}
+
+	role, err := u.repo.GetByName(roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	perms = make(map[string]bool, len(role.Permissions))
+	for _, p := range role.Permissions {
+		perms[p.Key()] = true
+	}
+
+	u.cacheMu.Lock()
+	u.cache[roleName] = perms
+	u.cacheMu.Unlock()
+
+	return perms[key], nil
+}
+
+// RequiresTwoFactor reports whether users holding the role must use 2FA
+func (u *roleUsecase) RequiresTwoFactor(roleName string) (bool, error) {
+	role, err := u.repo.GetByName(roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return role.Require2FA, nil
+}
+
+// resolvePermissions converts "resource:action" keys to rows and rejects unknown ones
+func (u *roleUsecase) resolvePermissions(keys []string) ([]models.Permission, error) {
+	perms, err := u.repo.FindPermissionsByKeys(keys)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+
+	found := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		found[p.Key()] = true
+	}
+	for _, k := range keys {
+		if !found[k] {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Unknown permission: "+k)
+		}
+	}
+	return perms, nil
+}
+
+// auditRole flattens a role for the audit trail so permission changes diff cleanly
+func auditRole(role *models.Role) map[string]interface{} {
+	keys := make([]string, len(role.Permissions))
+	for i, p := range role.Permissions {
+		keys[i] = p.Key()
+	}
+	sort.Strings(keys)
+	return map[string]interface{}{
+		"name":               role.Name,
+		"description":        role.Description,
+		"require_two_factor": role.Require2FA,
+		"permissions":        keys,
+	}
+}
+
+func (u *roleUsecase) invalidateCache() {
+	u.cacheMu.Lock()
+	u.cache = make(map[string]map[string]bool)
+	u.cacheMu.Unlock()
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/utils/jwt"
+	"log"
+	"time"
+)
+
+const (
+	// How often every instance reloads keys and checks whether a rotation is due
+	signingKeyRefreshInterval = 5 * time.Minute
+	// The next key is published this long before it starts signing, so verifiers
+	// that cache the JWKS see it first. Must be longer than the refresh interval.
+	signingKeyPrePublish = time.Hour
+)
+
+type SigningKeyUsecase interface {
+	Init() error
+	Run(ctx context.Context)
+	JWKS() jwt.JWKSet
+}
+
+type signingKeyUsecase struct {
+	repo      repository.SigningKeyRepository
+	algorithm string
+	rotation  time.Duration
+	grace     time.Duration
+}
+
+func SigningKeyNewUsecase(repo repository.SigningKeyRepository) (SigningKeyUsecase, error) {
+	u := &signingKeyUsecase{repo: repo, algorithm: config.ENV.JWTSigningAlg}
+
+	switch u.algorithm {
+	case jwt.AlgHS256, jwt.AlgRS256, jwt.AlgEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", u.algorithm)
+	}
+
+	var err error
+	if u.rotation, err = time.ParseDuration(config.ENV.JWTKeyRotation); err != nil || u.rotation <= signingKeyPrePublish {
+		return nil, fmt.Errorf("invalid JWT_KEY_ROTATION %q", config.ENV.JWTKeyRotation)
+	}
+	if u.grace, err = time.ParseDuration(config.ENV.JWTKeyGrace); err != nil || u.grace <= 0 {
+		return nil, fmt.Errorf("invalid JWT_KEY_GRACE %q", config.ENV.JWTKeyGrace)
+	}
+	if config.ENV.JWTAcceptHS256 && u.algorithm != jwt.AlgHS256 {
+		log.Printf("WARNING: JWT_ACCEPT_HS256 is on; legacy HS256 tokens are still accepted. Turn it off once they have expired.")
+	}
+	return u, nil
+}
+
+// Init makes sure a signing key exists and loads the key set before the server starts
+func (u *signingKeyUsecase) Init() error {
+	if err := u.rotate(time.Now()); err != nil {
+		return err
+	}
+	return u.load(time.Now())
+}
+
+// Run keeps the in-memory key set in sync and rotates keys on schedule
+func (u *signingKeyUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(signingKeyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := u.rotate(now); err != nil {
+				log.Println("Failed to rotate JWT signing keys:", err)
+			}
+			if err := u.load(now); err != nil {
+				log.Println("Failed to reload JWT signing keys:", err)
+			}
+		}
+	}
+}
+
+func (u *signingKeyUsecase) JWKS() jwt.JWKSet {
+	return jwt.JWKS()
+}
+
+// rotate creates the current key if there is none and pre-publishes its
+// successor once the current key is close to the end of its signing window
+func (u *signingKeyUsecase) rotate(now time.Time) error {
+	if u.algorithm == jwt.AlgHS256 {
+		return nil
+	}
+
+	return u.repo.WithLock(func(repo repository.SigningKeyRepository) error {
+		keys, err := repo.FindUsable(now)
+		if err != nil {
+			return err
+		}
+
+		// Keys are newest first, so the first one is the latest scheduled key
+		var latest *models.SigningKey
+		for i := range keys {
+			if keys[i].Algorithm == u.algorithm {
+				latest = &keys[i]
+				break
+			}
+		}
+
+		switch {
+		case latest == nil || !now.Before(latest.SignUntil):
+			if err := u.createKey(repo, now); err != nil {
+				return err
+			}
+		case now.After(latest.SignUntil.Add(-signingKeyPrePublish)):
+			if err := u.createKey(repo, latest.SignUntil); err != nil {
+				return err
+			}
+		}
+
+		return repo.DeleteExpired(now)
+	})
+}
+
+func (u *signingKeyUsecase) createKey(repo repository.SigningKeyRepository, activeFrom time.Time) error {
+	priv, err := jwt.GenerateKey(u.algorithm)
+	if err != nil {
+		return err
+	}
+	kid, err := jwt.KeyID(priv.Public())
+	if err != nil {
+		return err
+	}
+	pub, err := jwt.EncodePublicKey(priv.Public())
+	if err != nil {
+		return err
+	}
+	sealed, err := jwt.SealPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	signUntil := activeFrom.Add(u.rotation)
+	key := &models.SigningKey{
+		Kid:         kid,
+		Algorithm:   u.algorithm,
+		PrivateKey:  sealed,
+		PublicKey:   pub,
+		ActiveFrom:  activeFrom,
+		SignUntil:   signUntil,
+		VerifyUntil: signUntil.Add(u.grace),
+	}
+	if err := repo.Create(key); err != nil {
+		return err
+	}
+	log.Printf("Created JWT signing key %s (%s), active from %s", kid, u.algorithm, activeFrom.Format(time.RFC3339))
+	return nil
+}
+
+// load pushes the usable keys from the database into the jwt package
+func (u *signingKeyUsecase) load(now time.Time) error {
+	rows, err := u.repo.FindUsable(now)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]jwt.Key, 0, len(rows))
+	for _, row := range rows {
+		pub, err := jwt.DecodePublicKey(row.PublicKey)
+		if err != nil {
+			log.Printf("Skipping JWT signing key %s: %v", row.Kid, err)
+			continue
+		}
+		key := jwt.Key{
+			Kid:         row.Kid,
+			Algorithm:   row.Algorithm,
+			PublicKey:   pub,
+			ActiveFrom:  row.ActiveFrom,
+			SignUntil:   row.SignUntil,
+			VerifyUntil: row.VerifyUntil,
+		}
+		// A key sealed with a different JWT_SECRET can still verify but not sign
+		if priv, err := jwt.OpenPrivateKey(row.PrivateKey); err == nil {
+			key.PrivateKey = priv
+		} else {
+			log.Printf("JWT signing key %s is verify-only: %v", row.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	jwt.SetKeys(keys)
+	return nil
+}
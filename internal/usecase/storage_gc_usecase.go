@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/infra/storage"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"log"
+	"net/http"
+	"time"
+)
+
+// gcFolders are the top-level folders the application uploads into
+var gcFolders = []string{"uploads", "documents", "user_profiles", "room_images"}
+
+// StorageGCUsecase finds stored files that no database row references any
+// more, such as profile images left behind by a failed registration
+type StorageGCUsecase interface {
+	// Run sweeps every folder once. With dryRun the orphans are only reported.
+	Run(ctx context.Context, dryRun bool) (*dto.StorageGCReport, error)
+	// Schedule sweeps every STORAGE_GC_INTERVAL until ctx is cancelled
+	Schedule(ctx context.Context)
+}
+
+type storageGCUsecase struct {
+	repo     repository.StorageGCRepository
+	storage  storage.Storage
+	auditUC  AuditUsecase
+	minAge   time.Duration
+	interval time.Duration
+}
+
+func StorageGCNewUsecase(repo repository.StorageGCRepository, store storage.Storage, auditUC AuditUsecase) (StorageGCUsecase, error) {
+	minAge, err := time.ParseDuration(config.ENV.StorageGCMinAge)
+	if err != nil || minAge < time.Hour {
+		return nil, fmt.Errorf("STORAGE_GC_MIN_AGE must be a duration of at least 1h, got %q", config.ENV.StorageGCMinAge)
+	}
+	interval, err := time.ParseDuration(config.ENV.StorageGCInterval)
+	if err != nil || interval < 0 {
+		return nil, fmt.Errorf("invalid STORAGE_GC_INTERVAL %q", config.ENV.StorageGCInterval)
+	}
+	return &storageGCUsecase{
+		repo:     repo,
+		storage:  store,
+		auditUC:  auditUC,
+		minAge:   minAge,
+		interval: interval,
+	}, nil
+}
+
+func (u *storageGCUsecase) Run(ctx context.Context, dryRun bool) (*dto.StorageGCReport, error) {
+	inventory, ok := u.storage.(storage.Inventory)
+	if !ok {
+		return nil, helpers.NewAppError(http.StatusNotImplemented, "Storage driver cannot list files")
+	}
+
+	var report *dto.StorageGCReport
+	ran, err := u.repo.TryLock(func() error {
+		var err error
+		report, err = u.sweep(ctx, inventory, dryRun)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		return nil, helpers.NewAppError(http.StatusConflict, "A storage sweep is already running")
+	}
+	return report, nil
+}
+
+func (u *storageGCUsecase) Schedule(ctx context.Context) {
+	if u.interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := u.Run(ctx, !config.ENV.StorageGCDelete)
+			if err != nil {
+				log.Println("Storage sweep failed:", err)
+				continue
+			}
+			if report.ForcedDryRun {
+				log.Printf("Storage sweep did not delete anything: %d saved URLs could not be resolved", len(report.UnresolvedURLs))
+			}
+			for _, f := range report.Folders {
+				log.Printf("Storage sweep %s: %d scanned, %d orphaned, %d deleted", f.Folder, f.Scanned, len(f.Orphans), f.Deleted)
+			}
+		}
+	}
+}
+
+func (u *storageGCUsecase) sweep(ctx context.Context, inventory storage.Inventory, dryRun bool) (*dto.StorageGCReport, error) {
+	report := &dto.StorageGCReport{
+		DryRun:    dryRun,
+		MinAge:    u.minAge.String(),
+		StartedAt: time.Now(),
+	}
+
+	referenced, unresolved, err := u.references(inventory)
+	if err != nil {
+		return nil, err
+	}
+	report.UnresolvedURLs = unresolved
+
+	// An unresolved link may still point at one of our objects under a
+	// form the driver does not recognise, so nothing is safe to delete
+	if len(unresolved) > 0 && !dryRun {
+		dryRun = true
+		report.DryRun = true
+		report.ForcedDryRun = true
+	}
+
+	cutoff := report.StartedAt.Add(-u.minAge)
+	var deleted []string
+	for _, folder := range gcFolders {
+		result := dto.StorageGCFolder{Folder: folder, Orphans: []dto.StorageGCObject{}}
+
+		// Collect first: deleting while listing would upset paging cursors
+		err := inventory.List(ctx, folder+"/", func(obj storage.ObjectInfo) error {
+			result.Scanned++
+			switch {
+			case referenced[obj.Key]:
+				result.Referenced++
+			case obj.LastModified.After(cutoff):
+				// may belong to an upload whose row is not written yet
+				result.TooRecent++
+			default:
+				result.Orphans = append(result.Orphans, dto.StorageGCObject{
+					Key:          obj.Key,
+					Size:         obj.Size,
+					LastModified: obj.LastModified,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", folder, err)
+		}
+
+		if !dryRun {
+			for _, orphan := range result.Orphans {
+				if err := u.storage.Delete(ctx, orphan.Key); err != nil {
+					result.Failed = append(result.Failed, orphan.Key+": "+err.Error())
+					continue
+				}
+				result.Deleted++
+				deleted = append(deleted, orphan.Key)
+			}
+		}
+		report.Folders = append(report.Folders, result)
+	}
+	report.FinishedAt = time.Now()
+
+	if len(deleted) > 0 {
+		u.auditUC.Record(ctx, AuditEntry{
+			Action:       models.AuditActionDelete,
+			ResourceType: models.AuditResourceStorage,
+			ResourceID:   "orphans",
+			Before:       map[string]interface{}{"keys": deleted},
+		})
+	}
+	return report, nil
+}
+
+// references builds the set of keys still in use. Links saved on rooms,
+// doctors and patients are mapped back to keys by the driver.
+func (u *storageGCUsecase) references(inventory storage.Inventory) (map[string]bool, []string, error) {
+	keys, err := u.repo.ReferencedKeys()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load image references: %w", err)
+	}
+	urls, err := u.repo.ReferencedURLs()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load URL references: %w", err)
+	}
+
+	referenced := make(map[string]bool, len(keys)+len(urls))
+	for _, k := range keys {
+		referenced[k] = true
+	}
+	var unresolved []string
+	for _, link := range urls {
+		if key, ok := inventory.KeyFromURL(link); ok {
+			referenced[key] = true
+		} else {
+			unresolved = append(unresolved, link)
+		}
+	}
+	return referenced, unresolved, nil
+}
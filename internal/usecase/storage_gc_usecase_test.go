@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"hospital_management_system/internal/infra/storage"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeGCRepo serves fixed references and never contends for the lock
+type fakeGCRepo struct {
+	keys   []string
+	urls   []string
+	locked bool
+}
+
+func (r *fakeGCRepo) ReferencedKeys() ([]string, error) { return r.keys, nil }
+func (r *fakeGCRepo) ReferencedURLs() ([]string, error) { return r.urls, nil }
+
+func (r *fakeGCRepo) TryLock(fn func() error) (bool, error) {
+	if r.locked {
+		return false, nil
+	}
+	return true, fn()
+}
+
+func TestStorageGCRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		external    bool // a saved link the driver cannot map to a key
+		wantDeleted bool
+	}{
+		{name: "dry run", dryRun: true},
+		{name: "delete", wantDeleted: true},
+		{name: "delete refused with unresolved URLs", external: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, root := newTestStorage(t)
+			ctx := context.Background()
+			old := time.Now().Add(-48 * time.Hour)
+			age := func(key string) {
+				if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(key)), old, old); err != nil {
+					t.Fatalf("Chtimes: %v", err)
+				}
+			}
+
+			referenced := putTestObject(t, store, []byte("kept"), storage.PutOptions{Folder: "uploads/general", FileName: "a.png"})
+			age(referenced.Key)
+			linked := putTestObject(t, store, []byte("room"), storage.PutOptions{Folder: "room_images", FileName: "room.png"})
+			age(linked.Key)
+			orphan := putTestObject(t, store, []byte("orphan"), storage.PutOptions{Folder: "user_profiles", FileName: "p.png"})
+			age(orphan.Key)
+			recent := putTestObject(t, store, []byte("recent"), storage.PutOptions{Folder: "documents", FileName: "d.pdf"})
+			outside := putTestObject(t, store, []byte("other"), storage.PutOptions{Folder: "elsewhere", FileName: "x.txt"})
+			age(outside.Key)
+
+			repo := &fakeGCRepo{
+				keys: []string{referenced.Key},
+				urls: []string{linked.URL},
+			}
+			if tt.external {
+				repo.urls = append(repo.urls, "https://example.com/avatar.png")
+			}
+			audit := &fakeAudit{}
+			gc, err := StorageGCNewUsecase(repo, store, audit)
+			if err != nil {
+				t.Fatalf("StorageGCNewUsecase: %v", err)
+			}
+
+			report, err := gc.Run(ctx, tt.dryRun)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			var orphans, tooRecent, deleted int
+			for _, f := range report.Folders {
+				orphans += len(f.Orphans)
+				tooRecent += f.TooRecent
+				deleted += f.Deleted
+			}
+			if orphans != 1 || tooRecent != 1 {
+				t.Errorf("%d orphans and %d too recent, want 1 and 1", orphans, tooRecent)
+			}
+			if tt.external && len(report.UnresolvedURLs) != 1 {
+				t.Errorf("unresolved URLs %v, want the external avatar only", report.UnresolvedURLs)
+			}
+			if forced := tt.external && !tt.dryRun; report.ForcedDryRun != forced || (forced && !report.DryRun) {
+				t.Errorf("forced dry run %v (dry run %v), want %v", report.ForcedDryRun, report.DryRun, forced)
+			}
+
+			for _, key := range []string{referenced.Key, linked.Key, recent.Key, outside.Key} {
+				if !exists(t, store, key) {
+					t.Errorf("%s was deleted", key)
+				}
+			}
+			if gone := !exists(t, store, orphan.Key); gone != tt.wantDeleted {
+				t.Errorf("orphan deleted: %v, want %v", gone, tt.wantDeleted)
+			}
+			if tt.wantDeleted && (deleted != 1 || len(audit.entries) != 1) {
+				t.Errorf("%d deleted and %d audit entries, want 1 and 1", deleted, len(audit.entries))
+			}
+			if !tt.wantDeleted && len(audit.entries) != 0 {
+				t.Errorf("dry run recorded %d audit entries", len(audit.entries))
+			}
+		})
+	}
+}
+
+func TestStorageGCRunLocked(t *testing.T) {
+	store, _ := newTestStorage(t)
+	gc, err := StorageGCNewUsecase(&fakeGCRepo{locked: true}, store, &fakeAudit{})
+	if err != nil {
+		t.Fatalf("StorageGCNewUsecase: %v", err)
+	}
+	if _, err := gc.Run(context.Background(), true); err == nil {
+		t.Error("Run succeeded while another sweep held the lock")
+	}
+}
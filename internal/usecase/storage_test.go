@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"hospital_management_system/config"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/storage"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+)
+
+// newTestStorage runs storage-backed usecases on the filesystem driver in a
+// temporary directory, so the tests need no cloud account
+func newTestStorage(t *testing.T) (storage.Storage, string) {
+	t.Helper()
+	config.ENV = &config.Config{
+		StorageURLTTL:     "1h",
+		StorageGCMinAge:   "24h",
+		StorageGCInterval: "0",
+	}
+	root := t.TempDir()
+	store, err := storage.NewLocal(root, storage.NewURLSigner("test-secret", "http://localhost/api/v1/files"))
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	return store, root
+}
+
+func putTestObject(t *testing.T, store storage.Storage, body []byte, opts storage.PutOptions) *storage.Object {
+	t.Helper()
+	obj, err := store.Put(context.Background(), bytes.NewReader(body), opts)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	return obj
+}
+
+func exists(t *testing.T, store storage.Storage, key string) bool {
+	t.Helper()
+	r, err := store.Open(context.Background(), key)
+	if err != nil {
+		return false
+	}
+	r.Close()
+	return true
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{G: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// fakeAudit keeps recorded entries in memory
+type fakeAudit struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (a *fakeAudit) Record(ctx context.Context, entry AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func (a *fakeAudit) Search(filter *dto.AuditLogFilter) (*dto.ListResponse, error) {
+	return &dto.ListResponse{}, nil
+}
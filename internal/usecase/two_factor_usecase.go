@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	twoFactorIssuer        = "Hospital Management"
+	twoFactorSkew          = 1 // accept one step either side for clock drift
+	twoFactorRecoveryCodes = 10
+)
+
+type TwoFactorUsecase interface {
+	Enroll(userID string) (*dto.TwoFactorEnrollResponse, error)
+	Enable(userID string, code string) ([]string, error)
+	Disable(userID string, code string) error
+	RegenerateRecoveryCodes(userID string, code string) ([]string, error)
+	Verify(userID uuid.UUID, code, recoveryCode string) error
+	Status(userID string) (*dto.TwoFactorStatusResponse, error)
+	IsEnabled(userID uuid.UUID) (bool, error)
+	Reset(ctx context.Context, userID string) error
+}
+
+type twoFactorUsecase struct {
+	repo     repository.TwoFactorRepository
+	userRepo repository.UserRepository
+	roleUC   RoleUsecase
+	auditUC  AuditUsecase
+}
+
+func TwoFactorNewUsecase(repo repository.TwoFactorRepository, userRepo repository.UserRepository, roleUC RoleUsecase, auditUC AuditUsecase) TwoFactorUsecase {
+	return &twoFactorUsecase{repo: repo, userRepo: userRepo, roleUC: roleUC, auditUC: auditUC}
+}
+
+// Enroll creates (or replaces) a pending secret; 2FA stays off until Enable confirms a code
+func (u *twoFactorUsecase) Enroll(userID string) (*dto.TwoFactorEnrollResponse, error) {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := u.repo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if tf != nil && tf.IsEnabled {
+		return nil, helpers.NewAppError(http.StatusConflict, "Two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to generate secret")
+	}
+
+	if tf == nil {
+		tf = &models.TwoFactor{UserID: user.ID}
+	}
+	tf.Secret = secret
+	tf.IsEnabled = false
+	tf.EnabledAt = nil
+	tf.LastUsedStep = 0
+
+	if err := u.repo.Save(tf); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save two-factor secret")
+	}
+
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OtpAuthURI: utils.TOTPAuthURI(twoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms the pending secret with a code and returns fresh recovery codes
+func (u *twoFactorUsecase) Enable(userID string, code string) ([]string, error) {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := u.repo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if tf == nil {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Start enrollment first")
+	}
+	if tf.IsEnabled {
+		return nil, helpers.NewAppError(http.StatusConflict, "Two-factor authentication is already enabled")
+	}
+
+	step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now(), twoFactorSkew)
+	if !ok {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid two-factor code")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Enable(user.ID, step, hashes); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to enable two-factor authentication")
+	}
+	return codes, nil
+}
+
+// Disable turns 2FA off after proving possession; not allowed when the role enforces it
+func (u *twoFactorUsecase) Disable(userID string, code string) error {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return err
+	}
+
+	required, err := u.roleUC.RequiresTwoFactor(user.Role)
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if required {
+		return helpers.NewAppError(http.StatusForbidden, "Two-factor authentication is required for your role")
+	}
+
+	if err := u.Verify(user.ID, code, ""); err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteByUserID(user.ID); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to disable two-factor authentication")
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the old codes and issues a new set
+func (u *twoFactorUsecase) RegenerateRecoveryCodes(userID string, code string) ([]string, error) {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.Verify(user.ID, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to save recovery codes")
+	}
+	return codes, nil
+}
+
+// Verify accepts either a TOTP code or an unused recovery code
+func (u *twoFactorUsecase) Verify(userID uuid.UUID, code, recoveryCode string) error {
+	tf, err := u.repo.FindByUserID(userID)
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if tf == nil || !tf.IsEnabled {
+		return helpers.NewAppError(http.StatusBadRequest, "Two-factor authentication is not enabled")
+	}
+
+	if recoveryCode != "" {
+		used, err := u.repo.UseRecoveryCode(userID, utils.HashRecoveryCode(recoveryCode))
+		if err != nil {
+			return helpers.NewAppError(http.StatusInternalServerError, "Database error")
+		}
+		if !used {
+			return helpers.NewAppError(http.StatusUnauthorized, "Invalid recovery code")
+		}
+		return nil
+	}
+
+	step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now(), twoFactorSkew)
+	if !ok || step <= tf.LastUsedStep {
+		return helpers.NewAppError(http.StatusUnauthorized, "Invalid two-factor code")
+	}
+
+	// Conditional, so of two concurrent requests with the same code only one passes
+	used, err := u.repo.UseStep(userID, step)
+	if err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	if !used {
+		return helpers.NewAppError(http.StatusUnauthorized, "Invalid two-factor code")
+	}
+	return nil
+}
+
+func (u *twoFactorUsecase) Status(userID string) (*dto.TwoFactorStatusResponse, error) {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := u.IsEnabled(user.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	required, err := u.roleUC.RequiresTwoFactor(user.Role)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+
+	status := &dto.TwoFactorStatusResponse{Enabled: enabled, Required: required}
+	if enabled {
+		status.RemainingRecoveryCodes, _ = u.repo.CountUnusedRecoveryCodes(user.ID)
+	}
+	return status, nil
+}
+
+func (u *twoFactorUsecase) IsEnabled(userID uuid.UUID) (bool, error) {
+	tf, err := u.repo.FindByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.IsEnabled, nil
+}
+
+// Reset lets an admin wipe a user's enrollment, e.g. after a lost device
+func (u *twoFactorUsecase) Reset(ctx context.Context, userID string) error {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return helpers.NewAppError(http.StatusNotFound, "User not found")
+	}
+
+	if err := u.repo.DeleteByUserID(user.ID); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to reset two-factor authentication")
+	}
+
+	u.auditUC.Record(ctx, AuditEntry{
+		Action:       models.AuditActionTwoFactorReset,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   user.ID.String(),
+	})
+	return nil
+}
+
+func (u *twoFactorUsecase) activeUser(userID string) (*models.User, error) {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, helpers.NewAppError(http.StatusNotFound, "User not found")
+	}
+	// Enrollment is reachable with only the enrollment token, so check the
+	// account the same way login does
+	if err := checkAccountState(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(twoFactorRecoveryCodes)
+	if err != nil {
+		return nil, nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to generate recovery codes")
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = utils.HashRecoveryCode(c)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,421 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/infra/repository"
+	"hospital_management_system/internal/models"
+	"hospital_management_system/internal/pkg/helpers"
+	"hospital_management_system/internal/pkg/validators"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultChunkSize = int64(5 << 20)
+	minChunkSize     = int64(256 << 10)
+	maxChunkSize     = int64(10 << 20)
+
+	uploadSessionTTL = 24 * time.Hour
+
+	// completeAttempts bounds the retries of closing a session whose file
+	// has already been handed off
+	completeAttempts = 3
+)
+
+type UploadUsecase interface {
+	Init(ctx context.Context, req *dto.UploadInitRequest) (*dto.UploadSessionResponse, error)
+	// PutChunk stores chunk index after checking it against its sha256 (hex)
+	PutChunk(ctx context.Context, id uuid.UUID, index int, body io.Reader, checksum string) (*dto.UploadSessionResponse, error)
+	Status(ctx context.Context, id uuid.UUID) (*dto.UploadSessionResponse, error)
+	// Complete assembles the chunks, verifies the file checksum and hands the
+	// file to the target usecase, returning the created image or document
+	Complete(ctx context.Context, id uuid.UUID) (interface{}, error)
+	Abort(ctx context.Context, id uuid.UUID) error
+}
+
+type uploadUsecase struct {
+	repo       repository.UploadSessionRepository
+	roleUC     RoleUsecase
+	imageUC    ImageUsecase
+	documentUC DocumentUsecase
+}
+
+func UploadNewUsecase(repo repository.UploadSessionRepository, roleUC RoleUsecase, imageUC ImageUsecase, documentUC DocumentUsecase) UploadUsecase {
+	return &uploadUsecase{
+		repo:       repo,
+		roleUC:     roleUC,
+		imageUC:    imageUC,
+		documentUC: documentUC,
+	}
+}
+
+func (u *uploadUsecase) Init(ctx context.Context, req *dto.UploadInitRequest) (*dto.UploadSessionResponse, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.FileName = filepath.Base(strings.TrimSpace(req.FileName))
+	req.Checksum = strings.ToLower(strings.TrimSpace(req.Checksum))
+	if req.FileName == "" || req.FileName == "." || req.FileName == "/" {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "file_name is required")
+	}
+	if sum, err := hex.DecodeString(req.Checksum); err != nil || len(sum) != sha256.Size {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "checksum must be a hex encoded SHA-256")
+	}
+
+	var (
+		resource string
+		action   = models.ActionCreate
+		maxSize  int64
+		metadata interface{}
+	)
+	switch req.Target {
+	case models.UploadTargetImage:
+		resource, maxSize = models.ResourceImages, validators.MaxImageSize
+		if req.ImageType == "" {
+			req.ImageType = "general"
+		}
+		if req.ImageType == models.ImageTypeDocument {
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Use the document target to upload documents")
+		}
+		metadata = &dto.ImageUploadRequest{UserID: actor.ID, ImageType: req.ImageType}
+	case models.UploadTargetDocument:
+		resource, maxSize = models.ResourceDocuments, validators.MaxDocumentSize
+		switch {
+		case req.DocumentID != "":
+			if _, err := uuid.Parse(req.DocumentID); err != nil {
+				return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid document ID")
+			}
+			action = models.ActionUpdate
+			metadata = map[string]string{"document_id": req.DocumentID, "note": req.Note}
+		case req.Document != nil:
+			if strings.TrimSpace(req.Document.Title) == "" {
+				return nil, helpers.NewAppError(http.StatusBadRequest, "Title is required")
+			}
+			if !models.IsValidDocumentCategory(models.DocumentCategory(req.Document.Category)) {
+				return nil, helpers.NewAppError(http.StatusBadRequest, "Invalid document category")
+			}
+			metadata = req.Document
+		default:
+			return nil, helpers.NewAppError(http.StatusBadRequest, "Either document or document_id is required")
+		}
+	default:
+		return nil, helpers.NewAppError(http.StatusBadRequest, "target must be image or document")
+	}
+
+	allowed, err := u.roleUC.HasPermission(actor.Role, resource, action)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
+		return nil, helpers.NewAppError(http.StatusForbidden, "Unauthorized: missing permission "+resource+":"+action)
+	}
+
+	if req.FileSize <= 0 || req.FileSize > maxSize {
+		return nil, helpers.NewAppError(http.StatusBadRequest, fmt.Sprintf("file_size must be between 1 byte and %d MB", maxSize>>20))
+	}
+	chunkSize := req.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = defaultChunkSize
+	}
+	if chunkSize < minChunkSize || chunkSize > maxChunkSize {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "chunk_size must be between 256 KB and 10 MB")
+	}
+
+	meta, err := models.ToJSON(metadata)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to encode upload metadata")
+	}
+
+	// Opportunistic cleanup keeps abandoned chunks from piling up
+	if _, err := u.repo.DeleteExpired(time.Now()); err != nil {
+		log.Println("Failed to purge expired upload sessions:", err)
+	}
+
+	session := &models.UploadSession{
+		UserID:      actor.ID,
+		Target:      req.Target,
+		FileName:    req.FileName,
+		FileSize:    req.FileSize,
+		ChunkSize:   chunkSize,
+		TotalChunks: int((req.FileSize + chunkSize - 1) / chunkSize),
+		Checksum:    req.Checksum,
+		Metadata:    meta,
+		Status:      models.UploadStatusPending,
+		ExpiresAt:   time.Now().Add(uploadSessionTTL),
+	}
+	if err := u.repo.Create(session); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to create upload session")
+	}
+	return toUploadResponse(session, []int{}), nil
+}
+
+func (u *uploadUsecase) PutChunk(ctx context.Context, id uuid.UUID, index int, body io.Reader, checksum string) (*dto.UploadSessionResponse, error) {
+	session, err := u.owned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadStatusPending {
+		return nil, helpers.NewAppError(http.StatusConflict, "Upload is no longer accepting chunks")
+	}
+	if index < 0 || index >= session.TotalChunks {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Chunk index out of range")
+	}
+
+	expected := session.ChunkSize
+	if index == session.TotalChunks-1 {
+		expected = session.FileSize - session.ChunkSize*int64(session.TotalChunks-1)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, expected+1))
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusBadRequest, "Failed to read chunk")
+	}
+	if int64(len(data)) != expected {
+		return nil, helpers.NewAppError(http.StatusBadRequest, fmt.Sprintf("Chunk %d must be exactly %d bytes", index, expected))
+	}
+
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(strings.TrimSpace(checksum), actual) {
+		return nil, helpers.NewAppError(http.StatusUnprocessableEntity, "Chunk checksum mismatch")
+	}
+
+	chunk := &models.UploadChunk{
+		SessionID: session.ID,
+		Index:     index,
+		Size:      int64(len(data)),
+		Checksum:  actual,
+		Data:      data,
+	}
+	if err := u.repo.SaveChunk(chunk); err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to store chunk")
+	}
+	return u.response(session)
+}
+
+func (u *uploadUsecase) Status(ctx context.Context, id uuid.UUID) (*dto.UploadSessionResponse, error) {
+	session, err := u.owned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.response(session)
+}
+
+func (u *uploadUsecase) Complete(ctx context.Context, id uuid.UUID) (interface{}, error) {
+	session, err := u.owned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.UploadStatusCompleted {
+		return nil, helpers.NewAppError(http.StatusConflict, "Upload is already completed")
+	}
+	if session.Status == models.UploadStatusFailed {
+		return nil, helpers.NewAppError(http.StatusConflict, "Upload failed; abort it and start again")
+	}
+
+	indexes, err := u.repo.ChunkIndexes(session.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load chunks")
+	}
+	if len(indexes) != session.TotalChunks {
+		return nil, helpers.NewAppError(http.StatusConflict, "Upload is missing chunks")
+	}
+
+	// Only one request may assemble; the loser sees a conflict
+	ok, err := u.repo.TransitionStatus(session.ID, models.UploadStatusPending, models.UploadStatusAssembling)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to update upload session")
+	}
+	if !ok {
+		return nil, helpers.NewAppError(http.StatusConflict, "Upload is already being completed")
+	}
+
+	result, resultID, err := u.handOff(ctx, session)
+	if err != nil {
+		// Let the client retry, e.g. after a transient storage failure
+		if _, revertErr := u.repo.TransitionStatus(session.ID, models.UploadStatusAssembling, models.UploadStatusPending); revertErr != nil {
+			log.Println("Failed to reopen upload session:", revertErr)
+		}
+		return nil, err
+	}
+
+	// The image or document exists now; the session must not stay
+	// assembling, where Abort refuses it
+	for attempt := 1; ; attempt++ {
+		err = u.repo.Complete(session.ID, resultID)
+		if err == nil || attempt == completeAttempts {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+	if err != nil {
+		log.Printf("Failed to mark upload session %s completed (result %s): %v", session.ID, resultID, err)
+		if failErr := u.repo.Fail(session.ID, resultID); failErr != nil {
+			log.Println("Failed to mark upload session failed:", failErr)
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to complete upload session")
+	}
+	return result, nil
+}
+
+func (u *uploadUsecase) Abort(ctx context.Context, id uuid.UUID) error {
+	session, err := u.owned(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.Status == models.UploadStatusAssembling {
+		return helpers.NewAppError(http.StatusConflict, "Upload is being completed")
+	}
+	if err := u.repo.Delete(session.ID); err != nil {
+		return helpers.NewAppError(http.StatusInternalServerError, "Failed to abort upload")
+	}
+	return nil
+}
+
+// handOff writes the chunks to a temp file, checks the whole-file checksum
+// and passes the file to the image or document usecase
+func (u *uploadUsecase) handOff(ctx context.Context, session *models.UploadSession) (interface{}, uuid.UUID, error) {
+	file, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to assemble upload")
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	hash := sha256.New()
+	out := io.MultiWriter(file, hash)
+	for i := 0; i < session.TotalChunks; i++ {
+		chunk, err := u.repo.FindChunk(session.ID, i)
+		if err != nil {
+			return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load chunks")
+		}
+		if _, err := out.Write(chunk.Data); err != nil {
+			return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to assemble upload")
+		}
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != session.Checksum {
+		return nil, uuid.Nil, helpers.NewAppError(http.StatusUnprocessableEntity, "File checksum mismatch; re-send the corrupted chunks")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to assemble upload")
+	}
+
+	header := &multipart.FileHeader{
+		Filename: session.FileName,
+		Size:     session.FileSize,
+		Header:   textproto.MIMEHeader{},
+	}
+	if ct := mime.TypeByExtension(filepath.Ext(session.FileName)); ct != "" {
+		header.Header.Set("Content-Type", ct)
+	}
+
+	switch session.Target {
+	case models.UploadTargetImage:
+		var req dto.ImageUploadRequest
+		if err := json.Unmarshal(session.Metadata, &req); err != nil {
+			return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Invalid upload metadata")
+		}
+		image, err := u.imageUC.UploadImage(ctx, file, header, &req)
+		if err != nil {
+			return nil, uuid.Nil, err
+		}
+		return image, image.ID, nil
+
+	case models.UploadTargetDocument:
+		var version struct {
+			DocumentID string `json:"document_id"`
+			Note       string `json:"note"`
+		}
+		if err := json.Unmarshal(session.Metadata, &version); err == nil && version.DocumentID != "" {
+			docID, err := uuid.Parse(version.DocumentID)
+			if err != nil {
+				return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Invalid upload metadata")
+			}
+			doc, err := u.documentUC.AddVersion(ctx, docID, file, header, version.Note)
+			if err != nil {
+				return nil, uuid.Nil, err
+			}
+			return doc, doc.ID, nil
+		}
+
+		var req dto.DocumentUploadRequest
+		if err := json.Unmarshal(session.Metadata, &req); err != nil {
+			return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Invalid upload metadata")
+		}
+		doc, err := u.documentUC.Upload(ctx, file, header, &req)
+		if err != nil {
+			return nil, uuid.Nil, err
+		}
+		return doc, doc.ID, nil
+	}
+	return nil, uuid.Nil, helpers.NewAppError(http.StatusInternalServerError, "Unknown upload target")
+}
+
+// owned loads a live session belonging to the caller
+func (u *uploadUsecase) owned(ctx context.Context, id uuid.UUID) (*models.UploadSession, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := u.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewAppError(http.StatusNotFound, "Upload not found")
+		}
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Database error")
+	}
+	// Sessions are private to their creator; don't reveal they exist
+	if session.UserID != actor.ID {
+		return nil, helpers.NewAppError(http.StatusNotFound, "Upload not found")
+	}
+	if session.Status != models.UploadStatusCompleted && time.Now().After(session.ExpiresAt) {
+		return nil, helpers.NewAppError(http.StatusGone, "Upload has expired")
+	}
+	return session, nil
+}
+
+func (u *uploadUsecase) response(session *models.UploadSession) (*dto.UploadSessionResponse, error) {
+	indexes, err := u.repo.ChunkIndexes(session.ID)
+	if err != nil {
+		return nil, helpers.NewAppError(http.StatusInternalServerError, "Failed to load chunks")
+	}
+	return toUploadResponse(session, indexes), nil
+}
+
+func toUploadResponse(session *models.UploadSession, received []int) *dto.UploadSessionResponse {
+	if received == nil {
+		received = []int{}
+	}
+	return &dto.UploadSessionResponse{
+		ID:             session.ID,
+		Target:         session.Target,
+		FileName:       session.FileName,
+		FileSize:       session.FileSize,
+		ChunkSize:      session.ChunkSize,
+		TotalChunks:    session.TotalChunks,
+		ReceivedChunks: received,
+		Status:         session.Status,
+		ResultID:       session.ResultID,
+		ExpiresAt:      session.ExpiresAt,
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"hospital_management_system/internal/dto"
+	"hospital_management_system/internal/events"
 	"hospital_management_system/internal/infra/repository"
 	"hospital_management_system/internal/models"
 	"hospital_management_system/internal/pkg/helpers"
@@ -20,13 +21,15 @@ type userUsecase struct {
 	repo       repository.UserRepository
 	doctorUC   DoctorUsecase // inject doctor usecase
 	patientUC PatientUsecase
+	outbox    repository.OutboxRepository
 }
 
-func UserNewUsecase(repo repository.UserRepository, doctorUC DoctorUsecase, patientUC PatientUsecase) UserUsecase {
+func UserNewUsecase(repo repository.UserRepository, doctorUC DoctorUsecase, patientUC PatientUsecase, outbox repository.OutboxRepository) UserUsecase {
 	return &userUsecase{
 		repo:     repo,
 		doctorUC: doctorUC,
 		patientUC: patientUC,
+		outbox:    outbox,
 	}
 }
 
@@ -95,7 +98,10 @@ func (u *userUsecase) Register(req *dto.RegisterRequest) (*models.User, error) {
 				createdUser.Patient = createdPatient
 			}
 		}
-		return nil
+		return recordEvent(u.outbox, tx, events.UserRegistered{
+			UserID: createdUser.ID,
+			Role:   createdUser.Role,
+		})
 	})
 
 	if txErr != nil {
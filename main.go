@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	server "hospital_management_system/cmd"
 	"hospital_management_system/config"
 )
@@ -9,6 +11,12 @@ func main() {
 	// Initialize environment variables and DB
 	config.Init()
 
+	// "worker" sends queued emails instead of serving the API
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		server.RunWorker()
+		return
+	}
+
 	// Run server
 	server.RunServer()
 }
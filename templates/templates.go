@@ -0,0 +1,87 @@
+// Package templates bundles the default email and SMS templates into the
+// binary so messages render regardless of the working directory. Admins can
+// override the email templates with versions stored in the database.
+package templates
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed email/*.html email/*.txt sms/*.txt
+var bundledFS embed.FS
+
+// EmailDefault is the bundled template for one email type
+type EmailDefault struct {
+	Subject string
+	HTML    string
+	Text    string
+	// Sample holds every variable the template may use, with example values
+	// for previews and for validating edited templates
+	Sample map[string]string
+}
+
+var emailSubjects = map[string]string{
+	"otp":                  "OTP Verification",
+	"password_reset":       "Reset your password",
+	"account_locked":       "Your account has been temporarily locked",
+	"booking_confirmation": "Your booking is confirmed",
+	"booking_update":       "Your booking was updated",
+	"booking_reminder":     "Reminder: your booking is in {{.TimeLeft}}",
+	"payment_receipt":      "Payment receipt {{.Reference}}",
+	"payment_failed":       "Payment was not completed",
+	"profile_update":       "Your profile was updated",
+	"lab_results_ready":    "Your lab results are ready",
+	"lab_critical_result":  "CRITICAL lab result for {{.Patient}}",
+}
+
+var emailSamples = map[string]map[string]string{
+	"otp":                  {"Name": "Jane Doe", "Code": "123456"},
+	"password_reset":       {"Name": "Jane Doe", "Code": "123456"},
+	"account_locked":       {"Name": "Jane Doe", "LockedUntil": "2025-01-01 10:30 UTC", "IP": "203.0.113.7"},
+	"booking_confirmation": {"Name": "Jane Doe", "BookingID": "BK-1042", "Date": "2025-01-01 09:00"},
+	"booking_update":       {"Name": "Jane Doe", "BookingID": "BK-1042", "Status": "canceled", "Date": "2025-01-01 09:00"},
+	"booking_reminder":     {"Name": "Jane Doe", "BookingID": "BK-1042", "Date": "2025-01-01 09:00", "TimeLeft": "24 hours"},
+	"payment_receipt":      {"Name": "Jane Doe", "Amount": "150.00", "Reference": "PAY-7781", "PaidAt": "2025-01-01 11:15"},
+	"payment_failed":       {"Name": "Jane Doe", "Amount": "150.00", "Reference": "PAY-7781"},
+	"profile_update":       {"Name": "Jane Doe", "UpdatedAt": "2025-01-01 12:00 UTC"},
+	"lab_results_ready":    {"Name": "Jane Doe", "OrderID": "3f2b9c1e", "Tests": "Complete Blood Count, Lipid Panel", "ReleasedAt": "2025-01-01 14:20"},
+	"lab_critical_result":  {"Name": "Dr. John Smith", "Patient": "Jane Doe", "OrderID": "3f2b9c1e", "Test": "Electrolytes", "Results": "Potassium 6.8 mmol/L (critical high)", "EnteredAt": "2025-01-01 13:05"},
+}
+
+// Email returns the bundled template for an email type
+func Email(emailType string) (*EmailDefault, error) {
+	subject, ok := emailSubjects[emailType]
+	if !ok {
+		return nil, fmt.Errorf("no default template for email type %q", emailType)
+	}
+
+	html, err := bundledFS.ReadFile("email/" + emailType + ".html")
+	if err != nil {
+		return nil, err
+	}
+	text, err := bundledFS.ReadFile("email/" + emailType + ".txt")
+	if err != nil {
+		return nil, err
+	}
+
+	sample := make(map[string]string, len(emailSamples[emailType]))
+	for k, v := range emailSamples[emailType] {
+		sample[k] = v
+	}
+	return &EmailDefault{
+		Subject: subject,
+		HTML:    string(html),
+		Text:    string(text),
+		Sample:  sample,
+	}, nil
+}
+
+// SMS returns the bundled text message template for a notification event
+func SMS(event string) (string, error) {
+	body, err := bundledFS.ReadFile("sms/" + event + ".txt")
+	if err != nil {
+		return "", fmt.Errorf("no SMS template for event %q", event)
+	}
+	return string(body), nil
+}